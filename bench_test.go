@@ -293,3 +293,17 @@ func BenchmarkInterfaceManualTypeSwitch(b *testing.B) {
 }
 
 */
+
+// look up a single fact in a large table
+func BenchmarkFactTable(b *testing.B) {
+	db := NewDatabase()
+	for i := 0; i < 10000; i++ {
+		db = db.Assertz(read.Term_(fmt.Sprintf(`row(%d, x).`, i)))
+	}
+	g := read.Term_(`row(9999, X).`)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = db.Candidates_(g)
+	}
+}
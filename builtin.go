@@ -5,11 +5,17 @@ package golog
 
 import (
 	"fmt"
+	"io"
 	"math/big"
+	"os"
 	"sort"
 	"strings"
+	"unicode/utf8"
 
+	"github.com/mndrix/golog/lex"
+	"github.com/mndrix/golog/read"
 	"github.com/mndrix/golog/term"
+	"github.com/mndrix/golog/write"
 )
 import . "github.com/mndrix/golog/util"
 
@@ -31,6 +37,177 @@ func BuiltinCutTo(m Machine, args []term.Term) ForeignReturn {
 	return m.CutTo(barrierId)
 }
 
+// $dcg_call/3
+//
+// An internal system predicate which might be removed at any time
+// in the future.  It translates a grammar body, like the first argument
+// of phrase/3, and proves the resulting goal.
+func BuiltinDcgCall(m Machine, args []term.Term) ForeignReturn {
+	if _, body := stripModule(userModule, args[0]); term.IsVariable(body) {
+		return ForeignThrow(term.InstantiationError())
+	}
+	goal, err := dcgBody(args[0], args[1], args[2])
+	if err != nil {
+		return foreignError(err)
+	}
+	return m.DemandCutBarrier().PushConj(goal.(term.Callable))
+}
+
+// abolish(+PredicateIndicator) see ISO §8.9.4
+//
+// Removes all clauses of a predicate from the database.  Afterwards,
+// the predicate is undefined.
+func BuiltinAbolish1(m Machine, args []term.Term) ForeignReturn {
+	module, pi := stripModule(userModule, args[0])
+	name, arity, ball := predicateIndicator(pi)
+	if ball != nil {
+		return ForeignThrow(ball)
+	}
+	if _, ok := m.(*machine).lookupForeignIndicator(name, arity); ok {
+		return ForeignThrow(term.PermissionError("modify", "static_procedure", pi))
+	}
+
+	indicator := fmt.Sprintf("%s/%d", name, arity)
+	db := m.(*machine).moduleDatabase(module).Abolish(indicator)
+	return m.(*machine).setModuleDatabase(module, db)
+}
+
+// predicateIndicator validates a predicate indicator term like foo/2
+// and returns its name and arity.  If the term is not a valid predicate
+// indicator, returns an ISO error term describing the problem.
+func predicateIndicator(pi term.Term) (string, int, term.Term) {
+	if term.IsVariable(pi) {
+		return "", 0, term.InstantiationError()
+	}
+	if pi.Indicator() != "//2" {
+		return "", 0, term.TypeError("predicate_indicator", pi)
+	}
+	name := pi.(term.Callable).Arguments()[0]
+	arity := pi.(term.Callable).Arguments()[1]
+	if term.IsVariable(name) || term.IsVariable(arity) {
+		return "", 0, term.InstantiationError()
+	}
+	if !term.IsAtom(name) {
+		return "", 0, term.TypeError("atom", name)
+	}
+	if !term.IsInteger(arity) {
+		return "", 0, term.TypeError("integer", arity)
+	}
+	n := arity.(*term.Integer).Value()
+	if n.Sign() < 0 {
+		return "", 0, term.DomainError("not_less_than_zero", arity)
+	}
+	return name.(*term.Atom).Name(), int(n.Int64()), nil
+}
+
+// asserta(+Clause) see ISO §8.9.1
+//
+// Adds Clause to the database before all other clauses of its predicate.
+func BuiltinAsserta1(m Machine, args []term.Term) ForeignReturn {
+	return assert(m, 'a', args[0])
+}
+
+// assertz(+Clause) see ISO §8.9.2
+//
+// Adds Clause to the database after all other clauses of its predicate.
+// assert/1 is an alias for assertz/1.
+func BuiltinAssertz1(m Machine, args []term.Term) ForeignReturn {
+	return assert(m, 'z', args[0])
+}
+
+// assert adds a clause to a machine's database.  side is 'a' to add
+// the clause at the start or 'z' to add it at the end.
+func assert(m Machine, side rune, t term.Term) ForeignReturn {
+	module, t := stripModule(userModule, t)
+	if term.IsVariable(t) {
+		return ForeignThrow(term.InstantiationError())
+	}
+	head, body := clauseParts(t)
+	module, head = stripModule(module, head)
+	if ret, ok := checkModifiable(m, head); !ok {
+		return ret
+	}
+	body, ok := convertBody(body)
+	if !ok {
+		return ForeignThrow(term.TypeError("callable", body))
+	}
+
+	// later bindings shouldn't affect the clause in the database
+	var clause term.Term = head
+	if body.Indicator() != "true/0" {
+		if module != userModule {
+			body = qualify(module, body)
+		}
+		clause = term.NewCallable(":-", head, body)
+	}
+	clause = term.RenameVariables(clause)
+
+	db := m.(*machine).moduleDatabase(module)
+	switch side {
+	case 'a':
+		db = db.Asserta(clause)
+	case 'z':
+		db = db.Assertz(clause)
+	}
+	return m.(*machine).setModuleDatabase(module, db)
+}
+
+// clauseParts splits a clause into its head and body.  A fact
+// has a body of true/0.
+func clauseParts(t term.Term) (term.Term, term.Term) {
+	if term.IsClause(t) {
+		args := t.(*term.Compound).Arguments()
+		return args[0], args[1]
+	}
+	return t, term.NewAtom("true")
+}
+
+// convertBody converts a term into a clause body as described in
+// ISO §7.6.2.  Variables in goal positions become call/1 goals.
+// Returns false if the term can't be converted.
+func convertBody(t term.Term) (term.Term, bool) {
+	if term.IsVariable(t) {
+		return term.NewCallable("call", t), true
+	}
+	if !term.IsCallable(t) {
+		return t, false
+	}
+
+	x := t.(term.Callable)
+	switch x.Indicator() {
+	case ",/2", ";/2", "->/2":
+		args := x.Arguments()
+		t0, ok := convertBody(args[0])
+		if !ok {
+			return t0, false
+		}
+		t1, ok := convertBody(args[1])
+		if !ok {
+			return t1, false
+		}
+		return term.NewCallable(x.Name(), t0, t1), true
+	}
+	return t, true
+}
+
+// checkModifiable makes sure that head describes a predicate which can
+// be changed by assert and retract.  If it's not, the appropriate
+// ISO error is returned along with false.
+func checkModifiable(m Machine, head term.Term) (ForeignReturn, bool) {
+	if ret, ok := checkCallable(head); !ok {
+		return ret, false
+	}
+	h := head.(term.Callable)
+	if _, ok := m.(*machine).lookupForeign(h); ok {
+		name := term.NewAtom(h.Name())
+		arity := term.NewInt64(int64(h.Arity()))
+		pi := term.NewCallable("/", name, arity)
+		e := term.PermissionError("modify", "static_procedure", pi)
+		return ForeignThrow(e), false
+	}
+	return nil, true
+}
+
 // ,/2
 func BuiltinComma(m Machine, args []term.Term) ForeignReturn {
 	return m.PushConj(args[1].(term.Callable)).PushConj(args[0].(term.Callable))
@@ -44,6 +221,7 @@ func BuiltinGround(m Machine, args []term.Term) ForeignReturn {
 	case term.AtomType,
 		term.IntegerType,
 		term.FloatType,
+		term.BlobType,
 		term.ErrorType:
 		return ForeignTrue()
 	case term.CompoundType:
@@ -67,7 +245,9 @@ func BuiltinIs(m Machine, args []term.Term) ForeignReturn {
 	value := args[0]
 	expression := args[1]
 	num, err := term.ArithmeticEval(expression)
-	MaybePanic(err)
+	if err != nil {
+		return foreignError(err)
+	}
 	return ForeignUnify(value, num)
 }
 
@@ -117,14 +297,45 @@ func BuiltinUnify(m Machine, args []term.Term) ForeignReturn {
 
 // =:=/2
 func BuiltinNumericEquals(m Machine, args []term.Term) ForeignReturn {
+	return numericComparison(args, func(c int) bool { return c == 0 })
+}
+
+// =\=/2
+func BuiltinNumericNotEquals(m Machine, args []term.Term) ForeignReturn {
+	return numericComparison(args, func(c int) bool { return c != 0 })
+}
+
+// </2
+func BuiltinNumericLess(m Machine, args []term.Term) ForeignReturn {
+	return numericComparison(args, func(c int) bool { return c < 0 })
+}
+
+// =</2
+func BuiltinNumericLessEquals(m Machine, args []term.Term) ForeignReturn {
+	return numericComparison(args, func(c int) bool { return c <= 0 })
+}
+
+// >/2
+func BuiltinNumericGreater(m Machine, args []term.Term) ForeignReturn {
+	return numericComparison(args, func(c int) bool { return c > 0 })
+}
+
+// >=/2
+func BuiltinNumericGreaterEquals(m Machine, args []term.Term) ForeignReturn {
+	return numericComparison(args, func(c int) bool { return c >= 0 })
+}
+
+// numericComparison evaluates both arithmetic arguments and succeeds if
+// test accepts the result of comparing them with term.NumberCmp
+func numericComparison(args []term.Term, test func(int) bool) ForeignReturn {
 	// evaluate each arithmetic argument
-	a, err := term.ArithmeticEval(args[0])
-	MaybePanic(err)
-	b, err := term.ArithmeticEval(args[1])
-	MaybePanic(err)
+	a, b, err := term.ArithmeticEval2(args[0], args[1])
+	if err != nil {
+		return foreignError(err)
+	}
 
 	// perform the actual comparison
-	if term.NumberCmp(a, b) == 0 {
+	if test(term.NumberCmp(a, b)) {
 		return ForeignTrue()
 	}
 	return ForeignFail()
@@ -190,57 +401,245 @@ func BuiltinTermGreaterEquals(m Machine, args []term.Term) ForeignReturn {
 	return BuiltinTermEquals(m, args)
 }
 
+// =../2 see ISO §8.5.3
+func BuiltinUniv(m Machine, args []term.Term) ForeignReturn {
+	x, list := args[0], args[1]
+
+	// decompose a term into a list
+	if !term.IsVariable(x) {
+		if term.IsCompound(x) {
+			ts := x.(*term.Compound).Univ()
+			return ForeignUnify(list, term.NewTermList(ts))
+		}
+		return ForeignUnify(list, term.NewTermList([]term.Term{x}))
+	}
+
+	// compose a term from a list
+	if ret, ok := checkList(list); !ok {
+		return ret
+	}
+	ts := term.ProperListToTermSlice(list)
+	if len(ts) == 0 {
+		return ForeignThrow(term.DomainError("non_empty_list", list))
+	}
+	name, rest := ts[0], ts[1:]
+	if term.IsVariable(name) {
+		return ForeignThrow(term.InstantiationError())
+	}
+	if term.IsCompound(name) {
+		return ForeignThrow(term.TypeError("atomic", name))
+	}
+	if len(rest) == 0 {
+		return ForeignUnify(x, name)
+	}
+	if !term.IsAtom(name) {
+		return ForeignThrow(term.TypeError("atom", name))
+	}
+	t := term.NewCallable(name.(*term.Atom).Name(), rest...)
+	return ForeignUnify(x, t)
+}
+
 // (\+)/1
 func BuiltinNot(m Machine, args []term.Term) ForeignReturn {
-	var answer term.Bindings
-	var err error
-	m = m.ClearConjs().ClearDisjs().PushConj(args[0].(term.Callable))
-
+	if ret, ok := checkCallable(args[0]); !ok {
+		return ret
+	}
+	var sub Machine = m.ClearConjs().ClearDisjs()
+	sub = sub.PushConj(args[0].(term.Callable))
 	for {
-		m, answer, err = m.Step()
+		next, answer, err := sub.Step()
 		if err == MachineDone {
-			return ForeignTrue()
+			return m.(*machine).withSideEffectsOf(sub)
 		}
-		MaybePanic(err)
+		if err != nil {
+			sub.(*machine).closeIterators()
+			return foreignError(err)
+		}
+		sub = next
 		if answer != nil {
-			return ForeignFail()
+			sub.(*machine).closeIterators()
+			fail := term.NewCallable("fail")
+			return m.(*machine).withSideEffectsOf(sub).PushConj(fail)
+		}
+	}
+}
+
+// arg(?N, +Term, ?Arg) see ISO §8.5.2
+//
+// Unifies Arg with the Nth argument of Term.  If N is unbound, arg/3
+// enumerates each argument on backtracking.
+func BuiltinArg3(m Machine, args []term.Term) ForeignReturn {
+	n, t, arg := args[0], args[1], args[2]
+	if term.IsVariable(t) {
+		return ForeignThrow(term.InstantiationError())
+	}
+	if !term.IsCompound(t) {
+		return ForeignThrow(term.TypeError("compound", t))
+	}
+	tArgs := t.(*term.Compound).Arguments()
+
+	if term.IsVariable(n) {
+		// (N = 1, Arg = Arg1 ; N = 2, Arg = Arg2 ; ...)
+		goal := term.NewCallable("fail")
+		for i := len(tArgs) - 1; i >= 0; i-- {
+			unifyN := term.NewCallable("=", n, term.NewInt64(int64(i+1)))
+			unifyArg := term.NewCallable("=", arg, tArgs[i])
+			goal = term.NewCallable(";", term.NewCallable(",", unifyN, unifyArg), goal)
 		}
+		return m.PushConj(goal)
+	}
+	if !term.IsInteger(n) {
+		return ForeignThrow(term.TypeError("integer", n))
+	}
+	i := n.(*term.Integer).Value()
+	if !i.IsInt64() || i.Int64() < 1 || i.Int64() > int64(len(tArgs)) {
+		return ForeignFail()
 	}
+	return ForeignUnify(arg, tArgs[i.Int64()-1])
+}
+
+// at_end_of_stream/0,1 see ISO §8.11.8
+//
+// Succeeds if there's nothing left to read from the stream.  Without
+// an argument, checks the current input stream.
+func BuiltinAtEndOfStream(m Machine, args []term.Term) ForeignReturn {
+	s, ret := inputStream(m, args, 0, false)
+	if ret != nil {
+		return ret
+	}
+	if _, err := s.r.Peek(1); err == io.EOF {
+		return ForeignTrue()
+	}
+	return ForeignFail()
+}
+
+// atom(@Term) see ISO §8.3.2
+func BuiltinAtom1(m Machine, args []term.Term) ForeignReturn {
+	return typeTest(term.IsAtom(args[0]))
+}
+
+// atom_chars(?Atom, ?Chars) see ISO §8.16.4
+func BuiltinAtomChars2(m Machine, args []term.Term) ForeignReturn {
+	if !term.IsVariable(args[0]) {
+		if !term.IsAtom(args[0]) {
+			return ForeignThrow(term.TypeError("atom", args[0]))
+		}
+		return ForeignUnify(args[1], textList(args[0].(*term.Atom).Name(), false))
+	}
+
+	text, ball := listText(args[1], false)
+	if ball != nil {
+		return ForeignThrow(ball)
+	}
+	return ForeignUnify(args[0], term.NewAtom(text))
 }
 
 // atom_codes/2 see ISO §8.16.5
 func BuiltinAtomCodes2(m Machine, args []term.Term) ForeignReturn {
 
 	if !term.IsVariable(args[0]) {
+		if !term.IsAtom(args[0]) {
+			return ForeignThrow(term.TypeError("atom", args[0]))
+		}
 		atom := args[0].(*term.Atom)
 		list := term.NewCodeList(atom.Name())
 		return ForeignUnify(args[1], list)
 	} else if !term.IsVariable(args[1]) {
 		runes := make([]rune, 0)
-		list := args[1].(term.Callable)
+		list := args[1]
 		for {
-			switch list.Arity() {
-			case 2:
-				if list.Name() == "." {
-					args := list.Arguments()
-					code := args[0].(*term.Integer)
-					runes = append(runes, code.Code())
-					list = args[1].(term.Callable)
+			if term.IsVariable(list) {
+				return ForeignThrow(term.InstantiationError())
+			}
+			switch list.Indicator() {
+			case "./2":
+				args := list.(term.Callable).Arguments()
+				if term.IsVariable(args[0]) {
+					return ForeignThrow(term.InstantiationError())
 				}
-			case 0:
-				if list.Name() == "[]" {
-					atom := term.NewAtom(string(runes))
-					return ForeignUnify(args[0], atom)
+				if !term.IsInteger(args[0]) {
+					e := term.RepresentationError("character_code")
+					return ForeignThrow(e)
 				}
+				code := args[0].(*term.Integer)
+				runes = append(runes, code.Code())
+				list = args[1]
+			case "[]/0":
+				atom := term.NewAtom(string(runes))
+				return ForeignUnify(args[0], atom)
 			default:
-				msg := fmt.Sprintf("unexpected code list %s", args[1])
-				panic(msg)
+				return ForeignThrow(term.TypeError("list", args[1]))
 			}
 		}
 	}
 
-	msg := fmt.Sprintf("atom_codes/2: error with the arguments %s and %s", args[0], args[1])
-	panic(msg)
+	return ForeignThrow(term.InstantiationError())
+}
+
+// atom_concat(?Atom1, ?Atom2, +Atom3) see ISO §8.16.2
+//
+// If Atom1 and Atom2 are unbound, all the ways of splitting Atom3 are
+// found on backtracking.
+func BuiltinAtomConcat3(m Machine, args []term.Term) ForeignReturn {
+	for _, x := range args[:2] {
+		if !term.IsVariable(x) && !term.IsAtom(x) {
+			return ForeignThrow(term.TypeError("atom", x))
+		}
+	}
+	if !term.IsVariable(args[0]) && !term.IsVariable(args[1]) {
+		left := args[0].(*term.Atom).Name()
+		right := args[1].(*term.Atom).Name()
+		return ForeignUnify(args[2], term.NewAtom(left+right))
+	}
+
+	if term.IsVariable(args[2]) {
+		return ForeignThrow(term.InstantiationError())
+	}
+	if !term.IsAtom(args[2]) {
+		return ForeignThrow(term.TypeError("atom", args[2]))
+	}
+	whole := args[2].(*term.Atom).Name()
+	switch {
+	case term.IsAtom(args[0]):
+		left := args[0].(*term.Atom).Name()
+		if !strings.HasPrefix(whole, left) {
+			return ForeignFail()
+		}
+		return ForeignUnify(args[1], term.NewAtom(whole[len(left):]))
+	case term.IsAtom(args[1]):
+		right := args[1].(*term.Atom).Name()
+		if !strings.HasSuffix(whole, right) {
+			return ForeignFail()
+		}
+		return ForeignUnify(args[0], term.NewAtom(whole[:len(whole)-len(right)]))
+	}
+	return ForeignIterate(&atomSplits{
+		left:  args[0],
+		right: args[1],
+		runes: []rune(whole),
+	})
+}
+
+// atom_length(+Atom, ?Length) see ISO §8.16.1
+func BuiltinAtomLength2(m Machine, args []term.Term) ForeignReturn {
+	if term.IsVariable(args[0]) {
+		return ForeignThrow(term.InstantiationError())
+	}
+	if !term.IsAtom(args[0]) {
+		return ForeignThrow(term.TypeError("atom", args[0]))
+	}
+	length := args[1]
+	if !term.IsVariable(length) {
+		if !term.IsInteger(length) {
+			return ForeignThrow(term.TypeError("integer", length))
+		}
+		if length.(*term.Integer).Value().Sign() < 0 {
+			return ForeignThrow(term.DomainError("not_less_than_zero", length))
+		}
+	}
+
+	n := utf8.RuneCountInString(args[0].(*term.Atom).Name())
+	return ForeignUnify(length, term.NewInt64(int64(n)))
 }
 
 // atom_number/2 as defined in SWI-Prolog
@@ -248,6 +647,9 @@ func BuiltinAtomNumber2(m Machine, args []term.Term) (ret ForeignReturn) {
 	number := args[1]
 
 	if !term.IsVariable(args[0]) {
+		if !term.IsAtom(args[0]) {
+			return ForeignThrow(term.TypeError("atom", args[0]))
+		}
 		atom := args[0].(term.Callable)
 		defer func() { // convert parsing panics into fail
 			if x := recover(); x != nil {
@@ -261,47 +663,391 @@ func BuiltinAtomNumber2(m Machine, args []term.Term) (ret ForeignReturn) {
 		}
 		return ForeignUnify(args[1], number)
 	} else if !term.IsVariable(number) {
+		if !term.IsNumber(number) {
+			return ForeignThrow(term.TypeError("number", number))
+		}
 		atom := term.NewAtom(number.String())
 		return ForeignUnify(args[0], atom)
 	}
 
-	msg := fmt.Sprintf("atom_number/2: Arguments are not sufficiently instantiated: %s and %s", args[0], args[1])
-	panic(msg)
+	return ForeignThrow(term.InstantiationError())
+}
+
+// atomic(@Term) see ISO §8.3.5
+func BuiltinAtomic1(m Machine, args []term.Term) ForeignReturn {
+	x := args[0]
+	return typeTest(term.IsAtom(x) || term.IsNumber(x) || term.IsBlob(x))
+}
+
+// atomic_list_concat(+List, -Atom) and
+// atomic_list_concat(?List, +Separator, ?Atom) as defined in SWI-Prolog
+//
+// Joins atoms and numbers, optionally placing Separator between them.
+// If List isn't sufficiently instantiated, Atom is split at each
+// Separator instead.
+func BuiltinAtomicListConcat(m Machine, args []term.Term) ForeignReturn {
+	list, atom := args[0], args[len(args)-1]
+	sep := ""
+	if len(args) == 3 {
+		if term.IsVariable(args[1]) {
+			return ForeignThrow(term.InstantiationError())
+		}
+		if !term.IsAtom(args[1]) {
+			return ForeignThrow(term.TypeError("atom", args[1]))
+		}
+		sep = args[1].(*term.Atom).Name()
+	}
+
+	complete := true // is list a proper list of atomic terms?
+	var parts []string
+	x := list
+	for ; x.Indicator() == "./2"; x = x.(term.Callable).Arguments()[1] {
+		item := x.(term.Callable).Arguments()[0]
+		if term.IsVariable(item) {
+			complete = false
+			continue
+		}
+		text, ok := atomicText(item)
+		if !ok {
+			return ForeignThrow(term.TypeError("atomic", item))
+		}
+		parts = append(parts, text)
+	}
+	switch {
+	case term.IsVariable(x):
+		complete = false
+	case !term.IsEmptyList(x):
+		return ForeignThrow(term.TypeError("list", list))
+	}
+	if complete {
+		return ForeignUnify(atom, term.NewAtom(strings.Join(parts, sep)))
+	}
+
+	// split mode
+	if len(args) == 2 || term.IsVariable(atom) {
+		return ForeignThrow(term.InstantiationError())
+	}
+	if sep == "" {
+		return ForeignThrow(term.DomainError("non_empty_atom", args[1]))
+	}
+	text, ok := atomicText(atom)
+	if !ok {
+		return ForeignThrow(term.TypeError("atomic", atom))
+	}
+	var atoms []term.Term
+	for _, part := range strings.Split(text, sep) {
+		atoms = append(atoms, term.NewAtom(part))
+	}
+	return ForeignUnify(list, term.SliceToList(atoms))
+}
+
+// bagof(?Template, +Goal, -Bag) see ISO §8.10.2
+//
+// Like findall/3 but solutions are grouped by the bindings of Goal's
+// free variables.  Those are variables which appear in Goal but not in
+// Template and aren't existentially quantified with Var^Goal.  bagof/3
+// iterates over each group on backtracking.  If Goal has no solutions,
+// bagof/3 fails.
+func BuiltinBagof3(m Machine, args []term.Term) ForeignReturn {
+	return collectSolutions(m, args, false)
+}
+
+// collectSolutions implements bagof/3 and setof/3.  If set is true,
+// each group of solutions is sorted and duplicates are removed.
+func collectSolutions(m Machine, args []term.Term, set bool) ForeignReturn {
+	template, goal, bag := args[0], args[1], args[2]
+	witness, goal, ret := freeVariables(template, goal)
+	if ret != nil {
+		return ret
+	}
+
+	// findall(Witness-Template, Goal, Pairs)
+	pair := term.NewCallable("-", witness, template)
+	pairs, m1, err := findSolutions(m, pair, goal)
+	if err != nil {
+		return foreignError(err)
+	}
+
+	// group solutions whose witnesses are variants of each other.  The
+	// sort is stable so each group keeps the order solutions were found.
+	for i := range pairs {
+		pairs[i] = term.RenameVariables(pairs[i]) // each solution is a copy
+	}
+	witnessOf := func(t term.Term) term.Term { return t.(term.Callable).Arguments()[0] }
+	sort.SliceStable(pairs, func(i, j int) bool {
+		return term.Precedes(witnessOf(pairs[i]), witnessOf(pairs[j]))
+	})
+	var groups [][]term.Term
+	for _, p := range pairs {
+		n := len(groups)
+		if n > 0 && term.Variant(witnessOf(groups[n-1][0]), witnessOf(p)) {
+			groups[n-1] = append(groups[n-1], p)
+			continue
+		}
+		groups = append(groups, []term.Term{p})
+	}
+
+	// (Witness = W1a, Witness = W1b, ..., Bag = B1 ; ...)
+	result := term.NewCallable("fail")
+	for i := len(groups) - 1; i >= 0; i-- {
+		instances := make([]term.Term, len(groups[i]))
+		var branch term.Term = term.NewCallable("true")
+		for j, p := range groups[i] {
+			instances[j] = p.(term.Callable).Arguments()[1]
+			unify := term.NewCallable("=", witness, witnessOf(p))
+			branch = term.NewCallable(",", branch, unify)
+		}
+
+		// sorting happens after unification since it may bind variables
+		list := term.NewTermList(instances)
+		if set {
+			branch = term.NewCallable(",", branch, term.NewCallable("sort", list, bag))
+		} else {
+			branch = term.NewCallable(",", branch, term.NewCallable("=", list, bag))
+		}
+		result = term.NewCallable(";", branch, result)
+	}
+	return m1.PushConj(result)
+}
+
+// freeVariables finds the free variables of goal with respect to
+// template, as described in ISO §7.1.1.4.  They're returned in a witness
+// term along with goal stripped of any existential quantification.
+// If goal isn't callable, returns the appropriate ISO error.
+func freeVariables(template, goal term.Term) (term.Term, term.Term, ForeignReturn) {
+	bound := make(map[string]bool)
+	mark := func(t term.Term) {
+		for _, v := range term.OrderedVariables(t) {
+			bound[v.Name+v.Indicator()] = true
+		}
+	}
+	mark(template)
+
+	// strip module qualification and existential quantifiers
+	module, g := stripModule(userModule, goal)
+	for g.Indicator() == "^/2" {
+		args := g.(term.Callable).Arguments()
+		mark(args[0])
+		module, g = stripModule(module, args[1])
+	}
+	if ret, ok := checkCallable(g); !ok {
+		return nil, nil, ret
+	}
+
+	var free []term.Term
+	for _, v := range term.OrderedVariables(g) {
+		if !bound[v.Name+v.Indicator()] {
+			free = append(free, v)
+		}
+	}
+	if module != userModule {
+		g = term.NewCallable(":", term.NewAtom(module), g)
+	}
+	return term.NewTermList(free), g, nil
 }
 
 // call/*
 func BuiltinCall(m Machine, args []term.Term) ForeignReturn {
+	if ret, ok := checkCallable(args[0]); !ok {
+		return ret
+	}
 
 	// build a new goal with extra arguments attached
-	bodyTerm := args[0].(term.Callable)
-	functor := bodyTerm.Name()
-	newArgs := make([]term.Term, 0)
-	newArgs = append(newArgs, bodyTerm.Arguments()...)
-	newArgs = append(newArgs, args[1:]...)
-	goal := term.NewCallable(functor, newArgs...)
+	goal := addArguments(args[0].(term.Callable), args[1:]...)
 
 	// construct a machine that will prove this goal next
 	return m.DemandCutBarrier().PushConj(goal)
 }
 
+// callable(@Term) see ISO §8.3.9
+func BuiltinCallable1(m Machine, args []term.Term) ForeignReturn {
+	return typeTest(term.IsCallable(args[0]))
+}
+
+// catch(:Goal, ?Catcher, :Recovery) see ISO §7.8.9
+//
+// Proves Goal.  If an exception is thrown while proving Goal and it
+// unifies with Catcher, the machine reverts to its state when catch/3
+// was called and proves Recovery instead.
+func BuiltinCatch3(m Machine, args []term.Term) ForeignReturn {
+	cp := NewCatchChoicePoint(m, args[1], args[2])
+	id := cp.(*catchCP).id
+
+	// CATCH_CP, call(Goal), '$catch_exit'(ID)
+	exit := term.NewCallable("$catch_exit", term.NewInt64(id))
+	goal := term.NewCallable("call", args[0])
+	return m.PushDisj(cp).PushConj(exit).PushConj(goal)
+}
+
+// $catch_exit/1
+//
+// An internal system predicate which might be removed at any time
+// in the future.  It marks the end of a catch/3 goal on the
+// conjunction stack.  Once it's been proven, the matching catch/3
+// no longer catches exceptions.
+func BuiltinCatchExit(m Machine, args []term.Term) ForeignReturn {
+	return ForeignTrue()
+}
+
+// char_code(?Char, ?Code) see ISO §8.16.6
+func BuiltinCharCode2(m Machine, args []term.Term) ForeignReturn {
+	char, code := args[0], args[1]
+	if !term.IsVariable(char) {
+		if !isCharacter(char) {
+			return ForeignThrow(term.TypeError("character", char))
+		}
+		c, _ := utf8.DecodeRuneInString(char.(*term.Atom).Name())
+		return ForeignUnify(code, term.NewCode(c))
+	}
+
+	if term.IsVariable(code) {
+		return ForeignThrow(term.InstantiationError())
+	}
+	if !term.IsInteger(code) {
+		return ForeignThrow(term.TypeError("integer", code))
+	}
+	if !isInCharacterCode(code) || code.(*term.Integer).Code() < 0 {
+		return ForeignThrow(term.RepresentationError("character_code"))
+	}
+	return ForeignUnify(char, term.NewAtom(string(code.(*term.Integer).Code())))
+}
+
+// close(+Stream) and close(+Stream, +Options) see ISO §8.11.6
+//
+// Closes a stream.  Closing one of the standard streams does nothing.
+// The only option is force(Bool), which ignores errors while closing.
+func BuiltinClose(m Machine, args []term.Term) ForeignReturn {
+	ms := m.(*machine).streams
+	s, ball := ms.lookup(args[0])
+	if ball != nil {
+		return ForeignThrow(ball)
+	}
+	force := false
+	if len(args) > 1 {
+		if ret, ok := checkList(args[1]); !ok {
+			return ret
+		}
+		for _, opt := range term.ProperListToTermSlice(args[1]) {
+			switch {
+			case term.IsVariable(opt):
+				return ForeignThrow(term.InstantiationError())
+			case opt.String() == "force(true)":
+				force = true
+			case opt.String() == "force(false)":
+				force = false
+			default:
+				return ForeignThrow(term.DomainError("close_option", opt))
+			}
+		}
+	}
+	if s.isStandard() {
+		return ForeignTrue()
+	}
+
+	if err := s.close(); err != nil && !force {
+		return ForeignThrow(term.SystemError(err.Error()))
+	}
+	return m.(*machine).setStreams(ms.remove(s))
+}
+
+// compound(@Term) see ISO §8.3.6
+func BuiltinCompound1(m Machine, args []term.Term) ForeignReturn {
+	return typeTest(term.IsCompound(args[0]))
+}
+
+// copy_term(?Term, ?Copy) see ISO §8.5.4
+//
+// Unifies Copy with a version of Term in which all variables have been
+// replaced by fresh ones.
+func BuiltinCopyTerm2(m Machine, args []term.Term) ForeignReturn {
+	return ForeignUnify(args[1], term.RenameVariables(args[0]))
+}
+
+// current_input(?Stream) see ISO §8.11.1
+func BuiltinCurrentInput1(m Machine, args []term.Term) ForeignReturn {
+	if ret, ok := checkStreamTerm(args[0]); !ok {
+		return ret
+	}
+	return ForeignUnify(args[0], m.(*machine).streams.input.Term())
+}
+
+// current_op(?Priority, ?Specifier, ?Operator) see ISO §8.14.4
+func BuiltinCurrentOp3(m Machine, args []term.Term) ForeignReturn {
+	p, spec, name := args[0], args[1], args[2]
+	if !term.IsVariable(p) && !isOperatorPriority(p) {
+		return ForeignThrow(term.DomainError("operator_priority", p))
+	}
+	if !term.IsVariable(spec) && !isOperatorSpecifier(spec) {
+		return ForeignThrow(term.DomainError("operator_specifier", spec))
+	}
+	if !term.IsVariable(name) && !term.IsAtom(name) {
+		return ForeignThrow(term.TypeError("atom", name))
+	}
+
+	pattern := term.NewCallable("op", p, spec, name)
+	var solutions []term.Term
+	for _, op := range m.(*machine).ops.All() {
+		if term.IsAtom(name) && name.(*term.Atom).Name() != op.Name {
+			continue
+		}
+		solutions = append(solutions, term.NewCallable(
+			"op",
+			term.NewInt64(int64(op.Priority)),
+			term.NewAtom(op.Specifier),
+			term.NewAtom(op.Name),
+		))
+	}
+	return m.PushConj(alternatives(pattern, solutions))
+}
+
+// current_output(?Stream) see ISO §8.11.2
+func BuiltinCurrentOutput1(m Machine, args []term.Term) ForeignReturn {
+	if ret, ok := checkStreamTerm(args[0]); !ok {
+		return ret
+	}
+	return ForeignUnify(args[0], m.(*machine).streams.output.Term())
+}
+
+// dcg_translate_rule(+Rule, -Clause)
+//
+// Translates a grammar rule into the clause Consult would add to the
+// database for it.
+func BuiltinDcgTranslateRule2(m Machine, args []term.Term) ForeignReturn {
+	clause, err := dcgTranslateRule(args[0])
+	if err != nil {
+		return foreignError(err)
+	}
+	return ForeignUnify(args[1], clause)
+}
+
 // downcase_atom(+AnyCase, -LowerCase)
 //
 // Converts the characters of AnyCase into lowercase and unifies the
 // lowercase atom with LowerCase.
 func BuiltinDowncaseAtom2(m Machine, args []term.Term) ForeignReturn {
 	if term.IsVariable(args[0]) {
-		panic("downcase_atom/2: instantiation_error")
+		return ForeignThrow(term.InstantiationError())
 	}
-	anycase := args[0].(term.Callable)
-	if anycase.Arity() != 0 {
-		msg := fmt.Sprintf("downcase_atom/2: type_error(atom, %s)", anycase)
-		panic(msg)
+	if !term.IsAtom(args[0]) {
+		return ForeignThrow(term.TypeError("atom", args[0]))
 	}
+	anycase := args[0].(term.Callable)
 
 	lowercase := term.NewAtom(strings.ToLower(anycase.Name()))
 	return ForeignUnify(args[1], lowercase)
 }
 
+// $erase/1
+//
+// An internal system predicate which might be removed at any time
+// in the future.  It removes a specific, module qualified clause from
+// the database.  See retract/1
+func BuiltinErase(m Machine, args []term.Term) ForeignReturn {
+	module, clause := stripModule(userModule, args[0])
+	db := m.(*machine).moduleDatabase(module).Retract(clause)
+	return m.(*machine).setModuleDatabase(module, db)
+}
+
 // fail/0
 func BuiltinFail(m Machine, args []term.Term) ForeignReturn {
 	return ForeignFail()
@@ -311,31 +1057,191 @@ func BuiltinFail(m Machine, args []term.Term) ForeignReturn {
 func BuiltinFindall3(m Machine, args []term.Term) ForeignReturn {
 	template := args[0]
 	goal := args[1]
+	if ret, ok := checkCallable(goal); !ok {
+		return ret
+	}
+
+	instances, m1, err := findSolutions(m, template, goal)
+	if err != nil {
+		return foreignError(err)
+	}
+	list := term.NewTermList(instances)
+	result := term.NewCallable("=", args[2], list)
+	return m1.PushConj(result)
+}
 
+// findSolutions proves goal in a fresh conjunction and returns an
+// instance of template for each solution, in order.  The returned
+// machine is m with any database changes made while proving goal.
+func findSolutions(m Machine, template, goal term.Term) ([]term.Term, *machine, error) {
 	// call(Goal), X=Template
 	x := term.NewVar("_")
 	call := term.NewCallable("call", goal)
 	unify := term.NewCallable("=", x, template)
 	prove := term.NewCallable(",", call, unify)
-	proofs := m.ClearConjs().ClearDisjs().ProveAll(prove)
 
 	// build a list from the results
 	instances := make([]term.Term, 0)
-	for _, proof := range proofs {
-		t, err := proof.Resolve(x)
-		MaybePanic(err)
-		instances = append(instances, t)
+	sub := m.ClearConjs().ClearDisjs().PushConj(prove)
+	for {
+		next, answer, err := sub.Step()
+		if err == MachineDone {
+			break
+		}
+		if err != nil {
+			sub.(*machine).closeIterators()
+			return nil, nil, err
+		}
+		sub = next
+		if answer != nil {
+			t, err := answer.Resolve(x)
+			MaybePanic(err)
+			instances = append(instances, t)
+		}
+	}
+	return instances, m.(*machine).withSideEffectsOf(sub), nil
+}
+
+// float(@Term) see ISO §8.3.4
+func BuiltinFloat1(m Machine, args []term.Term) ForeignReturn {
+	return typeTest(term.IsFloat(args[0]))
+}
+
+// flush_output/0,1 see ISO §8.11.7
+func BuiltinFlushOutput(m Machine, args []term.Term) ForeignReturn {
+	s, ret := outputStream(m, args, 0, false)
+	if ret != nil {
+		return ret
+	}
+	if err := s.flush(); err != nil {
+		return ForeignThrow(term.SystemError(err.Error()))
+	}
+	return ForeignTrue()
+}
+
+// format/1,2,3 as defined in SWI-Prolog.  The first argument of
+// format/3 is either a stream or one of atom(A), codes(C), codes(C,Tail),
+// chars(C), chars(C,Tail) or string(S).
+func BuiltinFormat(m Machine, args []term.Term) ForeignReturn {
+	f, fArgs := args[len(args)-1], term.Term(term.NewAtom("[]"))
+	if len(args) > 1 {
+		f, fArgs = args[len(args)-2], args[len(args)-1]
+	}
+	text, ball := formatText(f, fArgs, m.(*machine).ops)
+	if ball != nil {
+		return ForeignThrow(ball)
 	}
 
-	return ForeignUnify(args[2], term.NewTermList(instances))
+	if len(args) == 3 {
+		if term.IsVariable(args[0]) {
+			return ForeignThrow(term.InstantiationError())
+		}
+		if ret, ok := formatSink(args[0], text); ok {
+			return ret
+		}
+	}
+	s, ret := outputStream(m, args, 2, false)
+	if ret != nil {
+		return ret
+	}
+	return writeString(s, text)
+}
+
+// functor(?Term, ?Name, ?Arity) see ISO §8.5.1
+//
+// Relates a term to its name and arity.  If Term is unbound, it's
+// unified with a term whose arguments are fresh variables.
+func BuiltinFunctor3(m Machine, args []term.Term) ForeignReturn {
+	t, name, arity := args[0], args[1], args[2]
+
+	// decompose a term
+	if !term.IsVariable(t) {
+		if term.IsCompound(t) {
+			x := t.(*term.Compound)
+			n := term.NewInt64(int64(x.Arity()))
+			return ForeignUnify(name, term.NewAtom(x.Name()), arity, n)
+		}
+		return ForeignUnify(name, t, arity, term.NewInt64(0))
+	}
+
+	// construct a term
+	if term.IsVariable(name) || term.IsVariable(arity) {
+		return ForeignThrow(term.InstantiationError())
+	}
+	if !term.IsInteger(arity) {
+		return ForeignThrow(term.TypeError("integer", arity))
+	}
+	if term.IsCompound(name) {
+		return ForeignThrow(term.TypeError("atomic", name))
+	}
+	n := arity.(*term.Integer).Value()
+	if n.Sign() < 0 {
+		return ForeignThrow(term.DomainError("not_less_than_zero", arity))
+	}
+	if n.Sign() == 0 {
+		return ForeignUnify(t, name)
+	}
+	if !term.IsAtom(name) {
+		return ForeignThrow(term.TypeError("atom", name))
+	}
+	if !n.IsInt64() || n.Int64() > maxArity {
+		return ForeignThrow(term.RepresentationError("max_arity"))
+	}
+	fresh := make([]term.Term, n.Int64())
+	for i := range fresh {
+		fresh[i] = term.NewVar("_")
+	}
+	return ForeignUnify(t, term.NewCallable(name.(*term.Atom).Name(), fresh...))
+}
+
+// maxArity is the largest arity functor/3 will construct
+const maxArity = 1 << 16
+
+// get_byte/1,2 see ISO §8.13.1
+//
+// Reads the next byte from a binary stream.  Unifies with -1 at the
+// end of the stream.
+func BuiltinGetByte(m Machine, args []term.Term) ForeignReturn {
+	return inputByte(m, args, false)
+}
+
+// get_char/1,2 see ISO §8.12.1
+//
+// Reads the next character from a text stream.  Unifies with
+// end_of_file at the end of the stream.
+func BuiltinGetChar(m Machine, args []term.Term) ForeignReturn {
+	return inputChar(m, args, false, false)
+}
+
+// get_code/1,2 see ISO §8.12.1
+//
+// Like get_char but unifies with a character code.  Unifies with -1 at
+// the end of the stream.
+func BuiltinGetCode(m Machine, args []term.Term) ForeignReturn {
+	return inputChar(m, args, false, true)
+}
+
+// integer(@Term) see ISO §8.3.3
+func BuiltinInteger1(m Machine, args []term.Term) ForeignReturn {
+	return typeTest(term.IsInteger(args[0]))
+}
+
+// is_list(@Term) as defined in SWI-Prolog
+//
+// True if Term is a proper list.  Partial lists aren't.
+func BuiltinIsList1(m Machine, args []term.Term) ForeignReturn {
+	return typeTest(term.IsList(args[0]))
 }
 
 // listing/0
 // This should be implemented in pure Prolog, but for debugging purposes,
 // I'm doing it for now as a foreign predicate.  This will go away.
 func BuiltinListing0(m Machine, args []term.Term) ForeignReturn {
-	fmt.Println(m.String())
-	return ForeignTrue()
+	s, ret := outputStream(m, args, 0, false)
+	if ret != nil {
+		return ret
+	}
+	return writeString(s, m.String()+"\n")
 }
 
 // msort(+Unsorted:list, -Sorted:list) is det.
@@ -347,23 +1253,458 @@ func BuiltinListing0(m Machine, args []term.Term) ForeignReturn {
 // The exact implementation is subject to change.  I make no
 // guarantees about sort stability.
 func BuiltinMsort2(m Machine, args []term.Term) ForeignReturn {
+	if ret, ok := checkList(args[0]); !ok {
+		return ret
+	}
 	terms := term.ProperListToTermSlice(args[0])
 	sort.Sort((*term.TermSlice)(&terms))
 	list := term.NewTermList(terms)
 	return ForeignUnify(args[1], list)
 }
 
-// A temporary hack for debugging.  This will disappear once Golog has
-// proper support for format/2
-func BuiltinPrintf(m Machine, args []term.Term) ForeignReturn {
-	template := args[0].(*term.Atom).Name()
-	template = strings.Replace(template, "~n", "\n", -1)
-	if len(args) == 1 {
-		fmt.Printf(template)
-	} else if len(args) == 2 {
-		fmt.Printf(template, args[1])
+// nl/0,1 see ISO §8.12.3
+func BuiltinNl(m Machine, args []term.Term) ForeignReturn {
+	s, ret := outputStream(m, args, 0, false)
+	if ret != nil {
+		return ret
 	}
-	return ForeignTrue()
+	return writeString(s, "\n")
+}
+
+// nonvar(@Term) see ISO §8.3.7
+func BuiltinNonvar1(m Machine, args []term.Term) ForeignReturn {
+	return typeTest(!term.IsVariable(args[0]))
+}
+
+// number(@Term) see ISO §8.3.8
+func BuiltinNumber1(m Machine, args []term.Term) ForeignReturn {
+	return typeTest(term.IsNumber(args[0]))
+}
+
+// number_chars(?Number, ?Chars) see ISO §8.16.7
+func BuiltinNumberChars2(m Machine, args []term.Term) ForeignReturn {
+	return numberText(args, false)
+}
+
+// number_codes(?Number, ?Codes) see ISO §8.16.8
+func BuiltinNumberCodes2(m Machine, args []term.Term) ForeignReturn {
+	return numberText(args, true)
+}
+
+// op(+Priority, +Specifier, +Operators) see ISO §8.14.3
+//
+// Changes the operator table used for reading and writing terms.  Like
+// changes to the database, the new table survives backtracking.
+func BuiltinOp3(m Machine, args []term.Term) ForeignReturn {
+	m1, err := m.(*machine).defineOp(args)
+	if err != nil {
+		return foreignError(err)
+	}
+	return m1
+}
+
+// open(+File, +Mode, -Stream) and open(+File, +Mode, -Stream, +Options)
+// see ISO §8.11.5
+//
+// Opens a file for reading, writing or appending.  Options may include
+// alias(Alias), type(text) or type(binary).  Other ISO options are
+// accepted but ignored.
+func BuiltinOpen(m Machine, args []term.Term) ForeignReturn {
+	file, mode, stream := args[0], args[1], args[2]
+	options := term.Term(term.NewAtom("[]"))
+	if len(args) > 3 {
+		options = args[3]
+	}
+	if term.IsVariable(file) || term.IsVariable(mode) {
+		return ForeignThrow(term.InstantiationError())
+	}
+	if !term.IsAtom(mode) {
+		return ForeignThrow(term.TypeError("atom", mode))
+	}
+	if !term.IsVariable(stream) {
+		return ForeignThrow(term.UninstantiationError(stream))
+	}
+	if ret, ok := checkList(options); !ok {
+		return ret
+	}
+	switch mode.(*term.Atom).Name() {
+	case "read", "write", "append":
+	default:
+		return ForeignThrow(term.DomainError("io_mode", mode))
+	}
+	if !term.IsAtom(file) {
+		return ForeignThrow(term.DomainError("source_sink", file))
+	}
+
+	ms := m.(*machine).streams
+	s := newStream("")
+	s.mode = mode.(*term.Atom).Name()
+	for _, opt := range term.ProperListToTermSlice(options) {
+		if term.IsVariable(opt) {
+			return ForeignThrow(term.InstantiationError())
+		}
+		var arg term.Term
+		if term.IsCompound(opt) && opt.(term.Callable).Arity() == 1 {
+			arg = opt.(term.Callable).Arguments()[0]
+			if term.IsVariable(arg) {
+				return ForeignThrow(term.InstantiationError())
+			}
+		}
+		switch {
+		case opt.Indicator() == "alias/1" && term.IsAtom(arg):
+			s.alias = arg.(*term.Atom).Name()
+			if ms.alias(s.alias) != nil {
+				return ForeignThrow(term.PermissionError("open", "source_sink", opt))
+			}
+		case opt.String() == "type(text)":
+			s.binary = false
+		case opt.String() == "type(binary)":
+			s.binary = true
+		case opt.Indicator() == "eof_action/1", opt.Indicator() == "reposition/1":
+			// accepted for compatibility
+		default:
+			return ForeignThrow(term.DomainError("stream_option", opt))
+		}
+	}
+
+	err := s.openFile(file.(*term.Atom).Name())
+	switch {
+	case os.IsNotExist(err):
+		return ForeignThrow(term.ExistenceError("source_sink", file))
+	case os.IsPermission(err):
+		return ForeignThrow(term.PermissionError("open", "source_sink", file))
+	case err != nil:
+		return ForeignThrow(term.SystemError(err.Error()))
+	}
+
+	m1 := m.(*machine).setStreams(ms.add(s))
+	return m1.PushConj(term.NewCallable("=", stream, s.Term()))
+}
+
+// peek_byte/1,2 see ISO §8.13.2
+func BuiltinPeekByte(m Machine, args []term.Term) ForeignReturn {
+	return inputByte(m, args, true)
+}
+
+// peek_char/1,2 see ISO §8.12.2
+func BuiltinPeekChar(m Machine, args []term.Term) ForeignReturn {
+	return inputChar(m, args, true, false)
+}
+
+// peek_code/1,2 see ISO §8.12.2
+func BuiltinPeekCode(m Machine, args []term.Term) ForeignReturn {
+	return inputChar(m, args, true, true)
+}
+
+// put_byte/1,2 see ISO §8.13.3
+func BuiltinPutByte(m Machine, args []term.Term) ForeignReturn {
+	s, ret := outputStream(m, args, 1, true)
+	if ret != nil {
+		return ret
+	}
+	b := args[len(args)-1]
+	if term.IsVariable(b) {
+		return ForeignThrow(term.InstantiationError())
+	}
+	if !isByte(b) {
+		return ForeignThrow(term.TypeError("byte", b))
+	}
+	n := b.(*term.Integer).Value().Int64()
+	return writeString(s, string([]byte{byte(n)}))
+}
+
+// put_char/1,2 see ISO §8.12.3
+func BuiltinPutChar(m Machine, args []term.Term) ForeignReturn {
+	s, ret := outputStream(m, args, 1, false)
+	if ret != nil {
+		return ret
+	}
+	c := args[len(args)-1]
+	if term.IsVariable(c) {
+		return ForeignThrow(term.InstantiationError())
+	}
+	if !isCharacter(c) {
+		return ForeignThrow(term.TypeError("character", c))
+	}
+	return writeString(s, c.(*term.Atom).Name())
+}
+
+// put_code/1,2 see ISO §8.12.3
+func BuiltinPutCode(m Machine, args []term.Term) ForeignReturn {
+	s, ret := outputStream(m, args, 1, false)
+	if ret != nil {
+		return ret
+	}
+	c := args[len(args)-1]
+	if term.IsVariable(c) {
+		return ForeignThrow(term.InstantiationError())
+	}
+	if !term.IsInteger(c) {
+		return ForeignThrow(term.TypeError("integer", c))
+	}
+	code := c.(*term.Integer).Value()
+	if !code.IsInt64() || !utf8.ValidRune(rune(code.Int64())) {
+		return ForeignThrow(term.RepresentationError("character_code"))
+	}
+	return writeString(s, string(rune(code.Int64())))
+}
+
+// rational(@Term)
+//
+// True if Term is an integer or a float which Golog represents exactly
+// as a rational number (see term.Rational).
+func BuiltinRational1(m Machine, args []term.Term) ForeignReturn {
+	return typeTest(term.IsInteger(args[0]) || term.IsRational(args[0]))
+}
+
+// read/1,2 see ISO §8.14.1
+//
+// Like read_term/2,3 without any options.
+func BuiltinRead(m Machine, args []term.Term) ForeignReturn {
+	args = append(args[:len(args):len(args)], term.NewAtom("[]"))
+	return BuiltinReadTerm(m, args)
+}
+
+// read_term(-Term, +Options) and read_term(+Stream, -Term, +Options)
+// see ISO §8.14.1
+//
+// Reads a term from a text stream.  Unifies Term with end_of_file at
+// the end of the stream.  Options may include variables(Vars),
+// variable_names(Names) and singletons(Names).
+func BuiltinReadTerm(m Machine, args []term.Term) ForeignReturn {
+	s, ret := inputStream(m, args, 2, false)
+	if ret != nil {
+		return ret
+	}
+	t, options := args[len(args)-2], args[len(args)-1]
+	if ret, ok := checkList(options); !ok {
+		return ret
+	}
+	for _, opt := range term.ProperListToTermSlice(options) {
+		switch {
+		case term.IsVariable(opt):
+			return ForeignThrow(term.InstantiationError())
+		case opt.Indicator() == "variables/1":
+		case opt.Indicator() == "variable_names/1":
+		case opt.Indicator() == "singletons/1":
+		default:
+			return ForeignThrow(term.DomainError("read_option", opt))
+		}
+	}
+
+	r := s.termReader()
+	r.SetOperators(m.(*machine).ops)
+	x, err := nextTerm(r)
+	if err == read.NoMoreTerms {
+		s.pastEOF = true
+		x = term.NewAtom("end_of_file")
+	} else if err != nil {
+		return ForeignThrow(term.SyntaxError(err.Error()))
+	}
+
+	// (T = X, Vars = ..., Names = ..., Singletons = ...)
+	unify := []term.Term{t, x}
+	for _, opt := range term.ProperListToTermSlice(options) {
+		arg := opt.(term.Callable).Arguments()[0]
+		switch opt.Indicator() {
+		case "variables/1":
+			unify = append(unify, arg, variableList(x))
+		case "variable_names/1":
+			unify = append(unify, arg, variableNames(x, false))
+		case "singletons/1":
+			unify = append(unify, arg, variableNames(x, true))
+		}
+	}
+	return ForeignUnify(unify...)
+}
+
+// retract(+Clause) see ISO §8.9.3
+//
+// Removes the first clause in the database which unifies with Clause.
+// On backtracking, removes the next matching clause.  Following the
+// logical update view, the clauses considered are those which existed
+// when retract/1 was called.
+func BuiltinRetract1(m Machine, args []term.Term) ForeignReturn {
+	module, t := stripModule(userModule, args[0])
+	if term.IsVariable(t) {
+		return ForeignThrow(term.InstantiationError())
+	}
+	head, body := clauseParts(t)
+	module, head = stripModule(module, head)
+	if ret, ok := checkModifiable(m, head); !ok {
+		return ret
+	}
+	db := m.(*machine).moduleDatabase(module)
+	candidates, err := db.Candidates(head)
+	if err != nil { // undefined predicates have nothing to retract
+		return ForeignFail()
+	}
+
+	// (Clause = Candidate1, '$erase'(M:Candidate1) ; Clause = Candidate2, ...)
+	clause := term.NewCallable(":-", head, body)
+	goal := term.NewCallable("fail")
+	for i := len(candidates) - 1; i >= 0; i-- {
+		candidate := candidates[i]
+		h, b := clauseParts(term.RenameVariables(candidate))
+		unify := term.NewCallable("=", clause, term.NewCallable(":-", h, b))
+		qualified := term.NewCallable(":", term.NewAtom(module), candidate)
+		erase := term.NewCallable("$erase", qualified)
+		goal = term.NewCallable(";", term.NewCallable(",", unify, erase), goal)
+	}
+	return m.PushConj(goal)
+}
+
+// retractall(+Head)
+//
+// Removes all clauses whose head unifies with Head.  Always succeeds.
+func BuiltinRetractall1(m Machine, args []term.Term) ForeignReturn {
+	module, head := stripModule(userModule, args[0])
+	if ret, ok := checkModifiable(m, head); !ok {
+		return ret
+	}
+	// undefined predicates become defined, just like SWI-Prolog
+	db := m.(*machine).moduleDatabase(module).Declare(head.Indicator())
+	candidates := db.Candidates_(head)
+
+	env := m.Bindings()
+	for _, candidate := range candidates {
+		h, _ := clauseParts(term.RenameVariables(candidate))
+		if _, err := head.Unify(env, h); err == nil {
+			db = db.Retract(candidate)
+		}
+	}
+	return m.(*machine).setModuleDatabase(module, db)
+}
+
+// set_input(+Stream) see ISO §8.11.3
+func BuiltinSetInput1(m Machine, args []term.Term) ForeignReturn {
+	s, ret := inputStream(m, args, 0, false)
+	if ret != nil {
+		return ret
+	}
+	m1 := m.(*machine)
+	ss := *m1.streams
+	ss.input = s
+	return m1.setStreams(&ss)
+}
+
+// set_output(+Stream) see ISO §8.11.4
+func BuiltinSetOutput1(m Machine, args []term.Term) ForeignReturn {
+	s, ret := outputStream(m, args, 0, false)
+	if ret != nil {
+		return ret
+	}
+	m1 := m.(*machine)
+	ss := *m1.streams
+	ss.output = s
+	return m1.setStreams(&ss)
+}
+
+// setof(?Template, +Goal, -Set) see ISO §8.10.3
+//
+// Like bagof/3 but each group of solutions is sorted into standard
+// order with duplicates removed.
+func BuiltinSetof3(m Machine, args []term.Term) ForeignReturn {
+	return collectSolutions(m, args, true)
+}
+
+// stream_property(?Stream, ?Property) see ISO §8.11.8
+//
+// Relates open streams to their properties: alias(A), file_name(F),
+// mode(M), input, output and type(T).  Iterates on backtracking.
+func BuiltinStreamProperty2(m Machine, args []term.Term) ForeignReturn {
+	streamTerm, property := args[0], args[1]
+	ms := m.(*machine).streams
+
+	candidates := ms.all()
+	if !term.IsVariable(streamTerm) {
+		s, ball := ms.lookup(streamTerm)
+		if ball != nil {
+			if streamTerm.Indicator() != "$stream/1" {
+				return ForeignThrow(term.DomainError("stream", streamTerm))
+			}
+			return ForeignThrow(ball)
+		}
+		candidates = []*stream{s}
+	}
+	if !term.IsVariable(property) && !isStreamProperty(property) {
+		return ForeignThrow(term.DomainError("stream_property", property))
+	}
+
+	// (Stream = S1, Property = P1 ; Stream = S1, Property = P2 ; ...)
+	goal := term.NewCallable("fail")
+	for i := len(candidates) - 1; i >= 0; i-- {
+		s := candidates[i]
+		props := s.properties()
+		for j := len(props) - 1; j >= 0; j-- {
+			unifyS := term.NewCallable("=", streamTerm, s.Term())
+			unifyP := term.NewCallable("=", property, props[j])
+			goal = term.NewCallable(";", term.NewCallable(",", unifyS, unifyP), goal)
+		}
+	}
+	return m.PushConj(goal)
+}
+
+// sub_atom(+Atom, ?Before, ?Length, ?After, ?SubAtom) see ISO §8.16.3
+//
+// SubAtom is a part of Atom with Before characters preceding it and
+// After characters following it.  All such parts are found on
+// backtracking.
+func BuiltinSubAtom5(m Machine, args []term.Term) ForeignReturn {
+	atom, sub := args[0], args[4]
+	if term.IsVariable(atom) {
+		return ForeignThrow(term.InstantiationError())
+	}
+	if !term.IsAtom(atom) {
+		return ForeignThrow(term.TypeError("atom", atom))
+	}
+	if !term.IsVariable(sub) && !term.IsAtom(sub) {
+		return ForeignThrow(term.TypeError("atom", sub))
+	}
+	runes := []rune(atom.(*term.Atom).Name())
+
+	// Before, Length and After as ints, -1 if they're unbound
+	var known [3]int
+	for i, x := range args[1:4] {
+		known[i] = -1
+		if term.IsVariable(x) {
+			continue
+		}
+		if !term.IsInteger(x) {
+			return ForeignThrow(term.TypeError("integer", x))
+		}
+		n := x.(*term.Integer).Value()
+		if n.Sign() < 0 || !n.IsInt64() || n.Int64() > int64(len(runes)) {
+			return ForeignFail()
+		}
+		known[i] = int(n.Int64())
+	}
+	before, length, after := known[0], known[1], known[2]
+	if term.IsAtom(sub) {
+		length = utf8.RuneCountInString(sub.(*term.Atom).Name())
+	}
+
+	// which positions are worth trying?
+	it := &subAtoms{args: args[1:], runes: runes, length: length, after: after}
+	it.last = len(runes)
+	switch {
+	case before >= 0:
+		it.b, it.last = before, before
+	case length >= 0 && after >= 0:
+		it.b = len(runes) - length - after
+		it.last = it.b
+	}
+	if term.IsAtom(sub) {
+		it.sub = []rune(sub.(*term.Atom).Name())
+	}
+	if it.b == it.last && (length >= 0 || after >= 0) {
+		// at most one solution, so don't leave a choice point
+		if ret, ok := it.Next(m); ok {
+			return ret
+		}
+		return ForeignFail()
+	}
+	return ForeignIterate(it)
 }
 
 // succ(?A:integer, ?B:integer) is det.
@@ -374,23 +1715,122 @@ func BuiltinSucc2(m Machine, args []term.Term) ForeignReturn {
 	y := args[1]
 	zero := big.NewInt(0)
 
+	// validate arguments
+	for _, arg := range args {
+		if term.IsVariable(arg) {
+			continue
+		}
+		if !term.IsInteger(arg) {
+			return ForeignThrow(term.TypeError("integer", arg))
+		}
+		if arg.(*term.Integer).Value().Cmp(zero) < 0 {
+			return ForeignThrow(term.TypeError("not_less_than_zero", arg))
+		}
+	}
+
 	if term.IsInteger(x) {
 		a := x.(*term.Integer)
-		if a.Value().Cmp(zero) < 0 {
-			panic("succ/2: first argument must be 0 or greater")
-		}
 		result := new(big.Int).Add(a.Value(), big.NewInt(1))
 		return ForeignUnify(y, term.NewBigInt(result))
 	} else if term.IsInteger(y) {
 		b := y.(*term.Integer)
 		result := new(big.Int).Add(b.Value(), big.NewInt(-1))
 		if result.Cmp(zero) < 0 {
-			panic("succ/2: first argument must be 0 or greater")
+			return ForeignFail()
 		}
 		return ForeignUnify(x, term.NewBigInt(result))
 	}
 
-	panic("succ/2: one argument must be an integer")
+	return ForeignThrow(term.InstantiationError())
+}
+
+// term_to_atom(?Term, ?Atom) as defined in SWI-Prolog
+//
+// If Atom is bound, it's parsed as a term and unified with Term.
+// Otherwise, Atom is the text of Term as written by writeq/1.
+func BuiltinTermToAtom2(m Machine, args []term.Term) ForeignReturn {
+	t, atom := args[0], args[1]
+	ops := m.(*machine).ops
+	if term.IsVariable(atom) {
+		if term.IsVariable(t) {
+			return ForeignThrow(term.InstantiationError())
+		}
+		return ForeignUnify(atom, term.NewAtom(termText(t, true, ops)))
+	}
+
+	text, ok := atomicText(atom)
+	if !ok {
+		return ForeignThrow(term.TypeError("atom", atom))
+	}
+	r, err := read.NewTermReader(text + " .")
+	if err != nil {
+		return foreignError(err)
+	}
+	r.SetOperators(ops)
+	x, err := nextTerm(r)
+	if err != nil {
+		return ForeignThrow(term.SyntaxError(err.Error()))
+	}
+	return ForeignUnify(t, x)
+}
+
+// term_variables(?Term, ?Vars) see ISO §8.5.5
+//
+// Unifies Vars with a list of the distinct variables in Term, in
+// depth-first, left-to-right order.
+func BuiltinTermVariables2(m Machine, args []term.Term) ForeignReturn {
+	vars := term.OrderedVariables(args[0])
+	ts := make([]term.Term, len(vars))
+	for i, v := range vars {
+		ts[i] = v
+	}
+	return ForeignUnify(args[1], term.NewTermList(ts))
+}
+
+// throw(+Ball) see ISO §7.8.10
+//
+// Raises an exception.  The machine unwinds to the most recent
+// catch/3 whose catcher unifies with Ball.
+func BuiltinThrow1(m Machine, args []term.Term) ForeignReturn {
+	if term.IsVariable(args[0]) {
+		return ForeignThrow(term.InstantiationError())
+	}
+	return ForeignThrow(args[0])
+}
+
+// upcase_atom(+AnyCase, -UpperCase)
+//
+// Converts the characters of AnyCase into uppercase and unifies the
+// uppercase atom with UpperCase.
+func BuiltinUpcaseAtom2(m Machine, args []term.Term) ForeignReturn {
+	if term.IsVariable(args[0]) {
+		return ForeignThrow(term.InstantiationError())
+	}
+	if !term.IsAtom(args[0]) {
+		return ForeignThrow(term.TypeError("atom", args[0]))
+	}
+	anycase := args[0].(term.Callable)
+
+	uppercase := term.NewAtom(strings.ToUpper(anycase.Name()))
+	return ForeignUnify(args[1], uppercase)
+}
+
+// use_module(+Spec) and use_module(+Spec, +Imports)
+//
+// Makes a module's exported predicates, or just those in Imports,
+// visible in the user module.  Spec is as for the use_module directive
+// except that relative file names are resolved against the working
+// directory.
+func BuiltinUseModule(m Machine, args []term.Term) ForeignReturn {
+	var imports term.Term
+	if len(args) > 1 {
+		imports = args[1]
+	}
+	m1, err := m.(*machine).useModule(lex.Position{}, userModule, args[0], imports)
+	if err != nil {
+		return foreignError(err)
+	}
+	return m.(*machine).withSideEffectsOf(m1)
 }
 
 // var(?X) is semidet.
@@ -404,3 +1844,83 @@ func BuiltinVar1(m Machine, args []term.Term) ForeignReturn {
 	}
 	return ForeignFail()
 }
+
+// write/1,2 see ISO §8.14.2
+//
+// Writes a term using operators, without quoting atoms.
+func BuiltinWrite(m Machine, args []term.Term) ForeignReturn {
+	return writeTerm(m, args, &write.Options{NumberVars: true})
+}
+
+// write_canonical/1,2 see ISO §8.14.2
+//
+// Writes a term, quoting atoms where necessary and ignoring operators.
+func BuiltinWriteCanonical(m Machine, args []term.Term) ForeignReturn {
+	return writeTerm(m, args, &write.Options{Quoted: true, IgnoreOps: true})
+}
+
+// write_term(+Term, +Options) and write_term(+Stream, +Term, +Options)
+// see ISO §8.14.2
+//
+// Writes a term.  Supports the ISO options quoted/1, ignore_ops/1,
+// numbervars/1 and variable_names/1 along with max_depth/1.
+func BuiltinWriteTerm(m Machine, args []term.Term) ForeignReturn {
+	opts, ret := writeOptions(args[len(args)-1])
+	if ret != nil {
+		return ret
+	}
+	return writeTerm(m, args[:len(args)-1], opts)
+}
+
+// writeq/1,2 and print/1,2 see ISO §8.14.2
+//
+// Writes a term, quoting atoms where necessary.
+func BuiltinWriteq(m Machine, args []term.Term) ForeignReturn {
+	return writeTerm(m, args, &write.Options{Quoted: true, NumberVars: true})
+}
+
+// checkCallable makes sure that t is callable.  If it's not, the
+// appropriate ISO error is returned along with false.
+func checkCallable(t term.Term) (ForeignReturn, bool) {
+	if term.IsVariable(t) {
+		return ForeignThrow(term.InstantiationError()), false
+	}
+	if !term.IsCallable(t) {
+		return ForeignThrow(term.TypeError("callable", t)), false
+	}
+	return nil, true
+}
+
+// typeTest succeeds if ok is true and fails otherwise
+func typeTest(ok bool) ForeignReturn {
+	if ok {
+		return ForeignTrue()
+	}
+	return ForeignFail()
+}
+
+// checkList makes sure that t is a proper list.  If it's not, the
+// appropriate ISO error is returned along with false.
+func checkList(t term.Term) (ForeignReturn, bool) {
+	for {
+		switch {
+		case term.IsVariable(t):
+			return ForeignThrow(term.InstantiationError()), false
+		case term.IsEmptyList(t):
+			return nil, true
+		case t.Indicator() == "./2":
+			t = t.(term.Callable).Arguments()[1]
+		default:
+			return ForeignThrow(term.TypeError("list", t)), false
+		}
+	}
+}
+
+// foreignError converts a Go error into a Prolog exception.  An
+// *Exception is thrown as is.  Other errors become system errors.
+func foreignError(err error) ForeignReturn {
+	if e, ok := err.(*term.Exception); ok {
+		return ForeignThrow(e.Ball())
+	}
+	return ForeignThrow(term.SystemError(err.Error()))
+}
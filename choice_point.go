@@ -2,6 +2,7 @@ package golog
 
 import (
 	"fmt"
+	"sync/atomic"
 
 	"github.com/mndrix/golog/term"
 )
@@ -124,3 +125,93 @@ func BarrierId(cp ChoicePoint) (int64, bool) {
 	}
 	return -1, false
 }
+
+// a choice point which records an active catch/3 call
+var catchID int64 = 0
+
+type catchCP struct {
+	machine  Machine
+	id       int64
+	catcher  term.Term
+	recovery term.Term
+}
+
+// NewCatchChoicePoint creates a special choice point which remembers
+// the state of a Golog machine when catch/3 was called.  Backtracking
+// into a catch choice point fails.  When an exception is thrown, the
+// machine searches the disjunction stack for these choice points to
+// find the matching catch/3 call.
+func NewCatchChoicePoint(m Machine, catcher, recovery term.Term) ChoicePoint {
+	id := atomic.AddInt64(&catchID, 1)
+	return &catchCP{machine: m, id: id, catcher: catcher, recovery: recovery}
+}
+
+var CatchChoicePointFails error = fmt.Errorf("Catch choice points never succeed")
+
+func (cp *catchCP) Follow() (Machine, error) {
+	return nil, CatchChoicePointFails
+}
+func (cp *catchCP) String() string {
+	return fmt.Sprintf("catch %d: %s -> %s", cp.id, cp.catcher, cp.recovery)
+}
+
+// recover attempts to handle an exception by unifying ball with this
+// choice point's catcher.  On success, it returns a machine which
+// proves the recovery goal.
+func (cp *catchCP) recover(ball term.Term) (Machine, bool) {
+	env, err := cp.catcher.Unify(cp.machine.Bindings(), ball)
+	if err == term.CantUnify {
+		return nil, false
+	}
+	MaybePanic(err)
+
+	goal := term.NewCallable("call", cp.recovery)
+	return cp.machine.SetBindings(env).PushConj(goal), true
+}
+
+// a choice point which produces the remaining solutions of a
+// nondeterministic foreign predicate
+type foreignCP struct {
+	machine   Machine
+	solutions *foreignSolutions
+}
+
+func (cp *foreignCP) Follow() (Machine, error) {
+	for s := cp.solutions; ; s = s.rest {
+		s.produce(cp.machine)
+		if s.ret == nil {
+			return nil, term.CantUnify
+		}
+		m, err := s.m.(*machine).foreignResult(s.ret)
+		if err != term.CantUnify {
+			return m, err
+		}
+	}
+}
+func (cp *foreignCP) String() string {
+	return fmt.Sprintf("foreign iterator %p", cp.solutions.iter)
+}
+
+// a choice point which tries the remaining solutions of a foreign
+// predicate which returned ForeignChoices
+type choicesCP struct {
+	machine Machine
+	rets    []ForeignReturn
+}
+
+func (cp *choicesCP) Follow() (Machine, error) {
+	for i, ret := range cp.rets {
+		m := cp.machine
+		if rest := cp.rets[i+1:]; len(rest) > 0 {
+			m = m.PushDisj(&choicesCP{machine: cp.machine, rets: rest})
+		}
+		m1, err := m.(*machine).foreignResult(ret)
+		if err != term.CantUnify {
+			return m1, err
+		}
+	}
+	return nil, term.CantUnify
+}
+func (cp *choicesCP) String() string {
+	return fmt.Sprintf("foreign choices %d", len(cp.rets))
+}
@@ -1,6 +1,7 @@
 package golog
 
 import (
+	"sort"
 	"strconv"
 
 	"github.com/mndrix/golog/term"
@@ -11,18 +12,28 @@ import (
 // cheap insertion at the front
 // and back and deletion from anywhere.
 // Each clause has a unique identifier
-// which can be used for deletions
+// which can be used for deletions.
+//
+// Clauses are indexed on the principal functor of their head's first
+// argument.  Clauses whose first argument is a variable (or otherwise
+// can't be indexed) are stored under the key unindexed.
 type clauses struct {
 	n         int64 // number of terms in collection
 	lowestId  int64
 	highestId int64
 	terms     ps.Map // maps int64 => Term
+	index     ps.Map // maps first argument key => ps.Map of int64 => Term
 }
 
+// unindexed is the index key for clauses which might match any
+// first argument
+const unindexed = ""
+
 // newClauses returns a new, empty list of clauses
 func newClauses() *clauses {
 	var cs clauses
 	cs.terms = ps.NewMap()
+	cs.index = ps.NewMap()
 	// n, lowestId, highestId correctly default to 0
 	return &cs
 }
@@ -39,16 +50,18 @@ func (self *clauses) cons(t term.Term) *clauses {
 	cs.lowestId--
 	key := strconv.FormatInt(cs.lowestId, 10)
 	cs.terms = self.terms.Set(key, t)
+	cs.index = self.indexed(key, t)
 	return cs
 }
 
-// cons adds a term to the list's back
+// snoc adds a term to the list's back
 func (self *clauses) snoc(t term.Term) *clauses {
 	cs := self.clone()
 	cs.n++
 	cs.highestId++
 	key := strconv.FormatInt(cs.highestId, 10)
 	cs.terms = self.terms.Set(key, t)
+	cs.index = self.indexed(key, t)
 	return cs
 }
 
@@ -69,6 +82,125 @@ func (self *clauses) all() []term.Term {
 	return terms
 }
 
+// without returns a copy of this list without the first term for
+// which f returns true.  The second return value is false if no such
+// term exists.
+func (self *clauses) without(f func(term.Term) bool) (*clauses, bool) {
+	for i := self.lowestId; i <= self.highestId; i++ {
+		key := strconv.FormatInt(i, 10)
+		t, ok := self.terms.Lookup(key)
+		if ok && f(t.(term.Term)) {
+			cs := self.clone()
+			cs.n--
+			cs.terms = self.terms.Delete(key)
+			cs.index = self.unindexed(key, t.(term.Term))
+			return cs, true
+		}
+	}
+	return self, false
+}
+
+// matching returns, in order, those terms whose first argument might
+// unify with arg
+func (self *clauses) matching(arg term.Term) []term.Term {
+	k, ok := indexKey(arg)
+	if !ok {
+		return self.all()
+	}
+
+	// gather clauses from the relevant index entries
+	ids := make([]int64, 0)
+	byId := make(map[int64]term.Term)
+	for _, k := range []string{k, unindexed} {
+		entries, ok := self.index.Lookup(k)
+		if !ok {
+			continue
+		}
+		entries.(ps.Map).ForEach(func(key string, t interface{}) {
+			id, _ := strconv.ParseInt(key, 10, 64)
+			ids = append(ids, id)
+			byId[id] = t.(term.Term)
+		})
+	}
+
+	// restore the original clause order
+	sort.Sort(int64s(ids))
+	terms := make([]term.Term, len(ids))
+	for i, id := range ids {
+		terms[i] = byId[id]
+	}
+	return terms
+}
+
+// indexed returns this list's index with a term added under the given key
+func (self *clauses) indexed(key string, t term.Term) ps.Map {
+	k, ok := clauseIndexKey(t)
+	if !ok {
+		return self.index
+	}
+	entries, ok := self.index.Lookup(k)
+	if !ok {
+		entries = ps.NewMap()
+	}
+	return self.index.Set(k, entries.(ps.Map).Set(key, t))
+}
+
+// unindexed returns this list's index without the term at the given key
+func (self *clauses) unindexed(key string, t term.Term) ps.Map {
+	k, ok := clauseIndexKey(t)
+	if !ok {
+		return self.index
+	}
+	entries, ok := self.index.Lookup(k)
+	if !ok {
+		return self.index
+	}
+	entries = entries.(ps.Map).Delete(key)
+	if entries.(ps.Map).Size() == 0 {
+		return self.index.Delete(k)
+	}
+	return self.index.Set(k, entries)
+}
+
+// clauseIndexKey returns the index key for a clause.  Returns false if
+// the clause's head has no arguments so it's not indexed at all.
+func clauseIndexKey(t term.Term) (string, bool) {
+	head := t
+	if term.IsClause(t) {
+		head = term.Head(t)
+	}
+	if !term.IsCompound(head) {
+		return "", false
+	}
+	arg := head.(term.Callable).Arguments()[0]
+	if k, ok := indexKey(arg); ok {
+		return k, true
+	}
+	return unindexed, true
+}
+
+// indexKey returns the index key for the first argument of a clause
+// head or goal.  It describes the argument's principal functor.  Returns
+// false for arguments that might unify with terms of many different
+// shapes.
+func indexKey(arg term.Term) (string, bool) {
+	switch arg.Type() {
+	case term.VariableType, term.ErrorType:
+		return "", false
+	}
+	if term.IsRational(arg) { // unifies with integers and floats too
+		return "", false
+	}
+	return arg.Indicator(), true
+}
+
+// int64s implements sort.Interface for a slice of int64
+type int64s []int64
+
+func (a int64s) Len() int           { return len(a) }
+func (a int64s) Less(i, j int) bool { return a[i] < a[j] }
+func (a int64s) Swap(i, j int)      { a[i], a[j] = a[j], a[i] }
+
 // invoke a callback on each clause
 func (self *clauses) forEach(f func(term.Term)) {
 	for _, t := range self.all() {
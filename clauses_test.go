@@ -3,6 +3,7 @@ package golog
 import "testing"
 
 import "github.com/mndrix/golog/read"
+import "github.com/mndrix/golog/term"
 
 func TestClauses(t *testing.T) {
 	rt := read.Term_ // convenience
@@ -33,3 +34,76 @@ func TestClauses(t *testing.T) {
 		}
 	}
 }
+
+func TestClausesWithout(t *testing.T) {
+	rt := read.Term_ // convenience
+
+	cs0 := newClauses().
+		snoc(rt(`hi(one).`)).
+		snoc(rt(`hi(two).`)).
+		snoc(rt(`hi(three).`))
+	isTwo := func(x term.Term) bool { return x.String() == `hi(two)` }
+
+	cs1, ok := cs0.without(isTwo)
+	if !ok {
+		t.Errorf("Didn't find hi(two)")
+	}
+	if n := cs1.count(); n != 2 {
+		t.Errorf("Incorrect term count: %d vs 2", n)
+	}
+	expected := []string{`hi(one)`, `hi(three)`}
+	for i, got := range cs1.all() {
+		if got.String() != expected[i] {
+			t.Errorf("Clause %d wrong: %s vs %s", i, got, expected[i])
+		}
+	}
+
+	// original list is untouched
+	if n := cs0.count(); n != 3 {
+		t.Errorf("Original list changed: %d vs 3", n)
+	}
+
+	if _, ok := cs1.without(isTwo); ok {
+		t.Errorf("Found hi(two) after removing it")
+	}
+}
+
+func TestClausesMatching(t *testing.T) {
+	rt := read.Term_ // convenience
+
+	cs0 := newClauses().
+		snoc(rt(`hi(one).`)).
+		snoc(rt(`hi(X) :- X = two.`)).
+		snoc(rt(`hi(one) :- true.`)).
+		snoc(rt(`hi(f(three)).`)).
+		cons(rt(`hi(one, zero).`))
+
+	tests := map[string][]string{
+		`one`:    {`hi(one, zero)`, `hi(one)`, `:-(hi(X), =(X, two))`, `:-(hi(one), true)`},
+		`f(a)`:   {`:-(hi(X), =(X, two))`, `hi(f(three))`},
+		`f(a,b)`: {`:-(hi(X), =(X, two))`},
+		`_`:      {`hi(one, zero)`, `hi(one)`, `:-(hi(X), =(X, two))`, `:-(hi(one), true)`, `hi(f(three))`},
+	}
+	for arg, expected := range tests {
+		got := cs0.matching(rt(arg + ` .`))
+		if len(got) != len(expected) {
+			t.Errorf("%s: wrong number of clauses: %d vs %d", arg, len(got), len(expected))
+			continue
+		}
+		for i := range got {
+			if got[i].String() != expected[i] {
+				t.Errorf("%s: clause %d wrong: %s vs %s", arg, i, got[i], expected[i])
+			}
+		}
+	}
+
+	// removing a clause removes it from the index too
+	isOne := func(x term.Term) bool { return x.String() == `hi(one)` }
+	cs1, _ := cs0.without(isOne)
+	if n := len(cs1.matching(rt(`one.`))); n != 3 {
+		t.Errorf("Wrong number of clauses after removal: %d vs 3", n)
+	}
+	if n := len(cs0.matching(rt(`one.`))); n != 4 {
+		t.Errorf("Removal changed the original clauses: %d vs 4", n)
+	}
+}
@@ -3,23 +3,20 @@
 package main
 
 import (
-	"bufio"
 	"fmt"
 	"io"
 	"os"
-	"strings"
 
 	"github.com/mndrix/golog"
 	"github.com/mndrix/golog/read"
-	"github.com/mndrix/golog/term"
 )
 
 func main() {
-	// create a Golog machine
+	// read queries from the same stdin buffer as Golog's user_input
+	in := golog.UserInput()
 	m := initMachine()
 
 	// ?- do(stuff).
-	in := bufio.NewReader(os.Stdin)
 	for {
 		warnf("?- ")
 
@@ -39,37 +36,7 @@ func main() {
 			continue
 		}
 
-		// execute user's query
-		variables := term.Variables(goal)
-		answers := m.ProveAll(goal)
-
-		// showing 0 results is easy and fun!
-		if len(answers) == 0 {
-			warnf("no.\n\n")
-			continue
-		}
-
-		// show each answer in turn
-		if variables.Size() == 0 {
-			warnf("yes.\n")
-			continue
-		}
-		for i, answer := range answers {
-			lines := make([]string, 0)
-			variables.ForEach(func(name string, variable interface{}) {
-				v := variable.(*term.Variable)
-				val := answer.Resolve_(v)
-				line := fmt.Sprintf("%s = %s", name, val)
-				lines = append(lines, line)
-			})
-
-			warnf("%s", strings.Join(lines, "\n"))
-			if i == len(answers)-1 {
-				warnf("\t.\n\n")
-			} else {
-				warnf("\t;\n")
-			}
-		}
+		prove(m, goal, in, os.Stderr)
 	}
 }
 
@@ -92,8 +59,23 @@ func initMachine() golog.Machine {
 			warnf("Can't open file: %s\n", err)
 			os.Exit(1)
 		}
-		m = m.Consult(file)
+		m = consult(m, file)
 	}
 
 	return m
 }
+
+// consult is like m.Consult but exits after reporting a failed directive
+func consult(m golog.Machine, file *os.File) golog.Machine {
+	defer func() {
+		if x := recover(); x != nil {
+			e, ok := x.(*golog.DirectiveError)
+			if !ok {
+				panic(x)
+			}
+			warnf("%s\n", e)
+			os.Exit(1)
+		}
+	}()
+	return m.Consult(file)
+}
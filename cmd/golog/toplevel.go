@@ -0,0 +1,107 @@
+package main
+
+// Proving queries typed at the top level and describing their answers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+
+	"github.com/mndrix/golog"
+	"github.com/mndrix/golog/term"
+	"github.com/mndrix/golog/write"
+)
+
+// prove runs the user's query, writing each answer to out as soon as
+// it's found.  After each answer which might not be the last, the user
+// types ; to see the next one or just presses Enter to stop.  Ctrl-C
+// abandons a query that's taking too long.
+func prove(m golog.Machine, goal term.Term, in *bufio.Reader, out io.Writer) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	solutions := m.SolveContext(ctx, goal)
+	defer solutions.Close()
+	for next(solutions, cancel) {
+		answer := answerText(goal, solutions.Bindings())
+		if solutions.Last() {
+			fmt.Fprintf(out, "%s.\n\n", answer)
+			return
+		}
+		fmt.Fprintf(out, "%s ", answer)
+		if !wantMore(in) {
+			fmt.Fprintf(out, ".\n\n")
+			return
+		}
+	}
+
+	switch err := solutions.Err(); {
+	case err == context.Canceled:
+		fmt.Fprintf(out, "%% Execution aborted\n\n")
+	case err != nil:
+		fmt.Fprintf(out, "%s\n\n", err)
+	default:
+		fmt.Fprintf(out, "false.\n\n")
+	}
+}
+
+// next is like solutions.Next but calls cancel if the user presses
+// Ctrl-C while it's looking for a solution.  Ctrl-C at other times,
+// like while waiting for the user's response to an answer, keeps its
+// usual meaning.
+func next(solutions golog.Solutions, cancel context.CancelFunc) bool {
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-interrupt:
+			cancel()
+		case <-done:
+		}
+	}()
+	return solutions.Next()
+}
+
+// answerText describes the bindings of the named variables in goal.
+// Variables whose names start with an underscore aren't shown.
+func answerText(goal term.Term, answer term.Bindings) string {
+	var names []string
+	variables := map[string]*term.Variable{}
+	term.Variables(goal).ForEach(func(name string, v interface{}) {
+		if !strings.HasPrefix(name, "_") {
+			names = append(names, name)
+			variables[name] = v.(*term.Variable)
+		}
+	})
+	if len(names) == 0 {
+		return "true"
+	}
+	sort.Strings(names)
+
+	opts := &write.Options{Quoted: true, NumberVars: true}
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		val := answer.Resolve_(variables[name])
+		lines = append(lines, fmt.Sprintf("%s = %s", name, write.String(val, opts)))
+	}
+	return strings.Join(lines, ",\n")
+}
+
+// wantMore reads the user's response to an answer.  It returns true if
+// they asked for another answer.
+func wantMore(in *bufio.Reader) bool {
+	line, err := in.ReadString('\n')
+	if err != nil {
+		return false
+	}
+	return strings.TrimSpace(line) == ";"
+}
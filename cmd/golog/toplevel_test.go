@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/mndrix/golog"
+	"github.com/mndrix/golog/read"
+)
+
+func TestProve(t *testing.T) {
+	m := golog.NewMachine().Consult(`color(red). color(green).`)
+	tests := []struct {
+		goal, in, want string
+	}{
+		{`true.`, ``, "true.\n\n"},
+		{`fail.`, ``, "false.\n\n"},
+		{`X = 1.`, ``, "X = 1.\n\n"},
+		{`X = f(Y), Y = 'A'.`, ``, "X = f('A'),\nY = 'A'.\n\n"},
+		{`X = 1, _Hidden = 2.`, ``, "X = 1.\n\n"},
+		{`color(X).`, "\n", "X = red .\n\n"},
+		{`color(X).`, ";\n", "X = red X = green.\n\n"},
+		{`member(X, [a, b]).`, ";\n;\n", "X = a X = b false.\n\n"},
+		{`(X = 1 ; X = 2).`, ";\n", "X = 1 X = 2.\n\n"},
+		{`color(X).`, "", "X = red .\n\n"}, // end of input
+		{`throw(oops).`, ``, "Unhandled exception: oops\n\n"},
+	}
+	for _, test := range tests {
+		var out bytes.Buffer
+		in := bufio.NewReader(strings.NewReader(test.in))
+		prove(m, read.Term_(test.goal), in, &out)
+		if got := out.String(); got != test.want {
+			t.Errorf("%s gave %q instead of %q", test.goal, got, test.want)
+		}
+	}
+}
+
+// read/1 and the top level should share the user's input
+func TestProveRead(t *testing.T) {
+	in := bufio.NewReader(strings.NewReader("foo(bar).\nnext query\n"))
+	m := golog.NewMachine().AttachInput("user_input", in)
+
+	var out bytes.Buffer
+	prove(m, read.Term_(`read(X).`), in, &out)
+	if got := out.String(); got != "X = foo(bar).\n\n" {
+		t.Errorf("Wrong answer: %q", got)
+	}
+	if line, _ := in.ReadString('\n'); line != "next query\n" {
+		t.Errorf("read/1 took the next line: %q", line)
+	}
+}
+
+func TestWantMore(t *testing.T) {
+	tests := map[string]bool{
+		";\n":   true,
+		" ; \n": true,
+		"\n":    false,
+		"x\n":   false,
+		"":      false,
+		";":     false, // no newline before end of input
+	}
+	for text, want := range tests {
+		in := bufio.NewReader(strings.NewReader(text))
+		if got := wantMore(in); got != want {
+			t.Errorf("wantMore(%q) gave %t", text, got)
+		}
+	}
+}
@@ -0,0 +1,363 @@
+package golog
+
+// Loading Prolog source code into a machine.  This includes running
+// any directives found along the way.
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/mndrix/golog/lex"
+	"github.com/mndrix/golog/read"
+	. "github.com/mndrix/golog/term"
+	. "github.com/mndrix/golog/util"
+)
+
+// DirectiveFailed is the error inside a DirectiveError when the
+// directive's goal failed.
+var DirectiveFailed = fmt.Errorf("Directive failed")
+
+// DirectiveError describes a directive that failed or raised an exception
+// while consulting Prolog code.  Consult panics with a *DirectiveError
+// in that case.
+type DirectiveError struct {
+	Pos       lex.Position // where the directive starts
+	Directive Term         // the goal (without :-)
+	Err       error        // DirectiveFailed or an *Exception
+}
+
+func (self *DirectiveError) Error() string {
+	return fmt.Sprintf("%s: directive %s: %s", self.Pos, self.Directive, self.Err)
+}
+
+// Consult returns a new machine with the clauses from text added to its
+// database.  Directives (terms like `:- Goal`) are executed in the order
+// they appear.  An op/3 directive affects how the rest of text is read.
+// Grammar rules (Head --> Body) are translated into clauses.  Goals
+// from initialization/1 directives run after all of text has been
+// loaded.  If a directive fails or throws an exception, Consult panics
+// with a *DirectiveError.
+//
+// If text starts a module with a module/2 directive, the module's
+// exported predicates are imported into the user module.
+func (m *machine) Consult(text interface{}) Machine {
+	m1, name := m.consult(userModule, text)
+	if name != "" {
+		m1 = m1.importModule(userModule, name, nil)
+	}
+	return m1
+}
+
+// consult loads text into module ctx.  If text defines a module with
+// a module/2 directive, that module's name is returned too.
+func (m *machine) consult(ctx string, text interface{}) (*machine, string) {
+	r, err := read.NewTermReader(text)
+	MaybePanic(err)
+	r.SetOperators(m.ops)
+
+	m1 := m.clone()
+	var file string // absolute name of the file being loaded
+	if f, ok := text.(*os.File); ok {
+		if name, err := filepath.Abs(f.Name()); err == nil {
+			file = name
+			m1.loaded = m1.loaded.Set(file, "")
+		}
+	}
+
+	var defined string          // module started by module/2
+	var inits []*DirectiveError // initialization goals waiting to run
+	var goals []Term            // and their module qualified versions
+	for {
+		t, err := r.Next()
+		if err == read.NoMoreTerms {
+			break
+		}
+		MaybePanic(err)
+
+		if t.Indicator() == "-->/2" {
+			t, err = dcgTranslateRule(t)
+			MaybePanic(err)
+		}
+		if !IsDirective(t) {
+			m1 = m1.addClause(ctx, t)
+			continue
+		}
+
+		d := &DirectiveError{
+			Pos:       *r.Position(),
+			Directive: t.(*Compound).Arguments()[0],
+		}
+		switch d.Directive.Indicator() {
+		case "initialization/1":
+			goal := d.Directive.(Callable).Arguments()[0]
+			d.Directive = goal
+			inits = append(inits, d)
+			goals = append(goals, qualify(ctx, goal))
+			continue
+		case "module/2":
+			args := d.Directive.(Callable).Arguments()
+			m1, defined, d.Err = m1.defineModule(args)
+			if d.Err == nil {
+				ctx = defined
+				if file != "" {
+					m1.loaded = m1.loaded.Set(file, defined)
+				}
+			}
+		default:
+			m1, d.Err = m1.directive(d.Pos, ctx, d.Directive)
+		}
+		if d.Err != nil {
+			panic(d)
+		}
+		r.SetOperators(m1.ops) // in case the directive called op/3
+	}
+
+	for i, d := range inits {
+		m1, d.Err = m1.runDirective(goals[i])
+		if d.Err != nil {
+			panic(d)
+		}
+	}
+	return m1, defined
+}
+
+// addClause adds a clause to the database of module ctx.  A clause
+// whose head is module qualified goes into that module instead.
+func (m *machine) addClause(ctx string, t Term) *machine {
+	head, body := clauseParts(t)
+	ctx, head = stripModule(ctx, head)
+	if ctx != userModule {
+		t = head
+		if body.Indicator() != "true/0" {
+			t = NewCallable(":-", head, qualify(ctx, body))
+		}
+	}
+	db := m.moduleDatabase(ctx).Assertz(t)
+	return m.setModuleDatabase(ctx, db)
+}
+
+// directive executes a single directive found at pos while reading
+// terms into module ctx.  Returns the machine that results from
+// executing it.
+func (m *machine) directive(pos lex.Position, ctx string, goal Term) (*machine, error) {
+	if !IsCallable(goal) {
+		return m.runDirective(goal) // let call/1 complain
+	}
+	args := goal.(Callable).Arguments()
+
+	switch goal.Indicator() {
+	case "dynamic/1":
+		m1 := m
+		for _, pi := range predicateIndicators(args[0]) {
+			module, pi := stripModule(ctx, pi)
+			indicator, err := indicatorOf(pi)
+			if err != nil {
+				return m, err
+			}
+			db := m1.moduleDatabase(module).Declare(indicator)
+			m1 = m1.setModuleDatabase(module, db)
+		}
+		return m1, nil
+	case "discontiguous/1", "multifile/1":
+		// Golog doesn't care whether clauses are contiguous or
+		// spread across files, so just validate the arguments
+		for _, pi := range predicateIndicators(args[0]) {
+			_, pi := stripModule(ctx, pi)
+			if _, err := indicatorOf(pi); err != nil {
+				return m, err
+			}
+		}
+		return m, nil
+	case "ensure_loaded/1":
+		m1, name, err := m.loadFile(pos, args[0])
+		if err == nil && name != "" {
+			m1 = m1.importModule(ctx, name, nil)
+		}
+		return m1, err
+	case "export/1":
+		return m.export(ctx, args[0])
+	case "meta_predicate/1":
+		return m.metaPredicate(ctx, args[0])
+	case "op/3":
+		return m.defineOp(args)
+	case "use_module/1":
+		return m.useModule(pos, ctx, args[0], nil)
+	case "use_module/2":
+		return m.useModule(pos, ctx, args[0], args[1])
+	}
+	return m.runDirective(qualify(ctx, goal))
+}
+
+// runDirective proves goal once, keeping any changes it makes to the
+// database.  Returns DirectiveFailed if goal fails.
+func (m *machine) runDirective(goal Term) (*machine, error) {
+	var answer Bindings
+	var err error
+
+	call := NewCallable("call", goal)
+	var sub Machine = m.PushConj(call)
+	for {
+		sub, answer, err = sub.Step()
+		if err == MachineDone {
+			return m, DirectiveFailed
+		}
+		if err != nil {
+			return m, err
+		}
+		if answer != nil {
+			return m.withSideEffectsOf(sub), nil
+		}
+	}
+}
+
+// loadFile consults the file named by spec unless it's already been
+// loaded.  Relative names are resolved against the directory of the
+// file containing the directive at pos.  A missing ".pl" extension is
+// added if necessary.  If the file defines a module, its name is
+// returned too.
+func (m *machine) loadFile(pos lex.Position, spec Term) (*machine, string, error) {
+	if IsVariable(spec) {
+		return m, "", NewException(InstantiationError())
+	}
+	if !IsAtom(spec) {
+		return m, "", NewException(DomainError("source_sink", spec))
+	}
+
+	name := spec.(*Atom).Name()
+	if !filepath.IsAbs(name) && pos.Filename != "" {
+		name = filepath.Join(filepath.Dir(pos.Filename), name)
+	}
+	if _, err := os.Stat(name); err != nil && filepath.Ext(name) == "" {
+		name += ".pl"
+	}
+	name, err := filepath.Abs(name)
+	MaybePanic(err)
+	if module, ok := m.loaded.Lookup(name); ok {
+		return m, module.(string), nil
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return m, "", NewException(ExistenceError("source_sink", spec))
+	}
+	defer f.Close()
+	m1, module := m.consult(userModule, f)
+	return m1, module, nil
+}
+
+// defineOp implements op/3 by changing the machine's operator table.
+// The new table affects terms read and written afterwards.
+func (m *machine) defineOp(args []Term) (*machine, error) {
+	p, spec, ops := args[0], args[1], args[2]
+	if IsVariable(p) || IsVariable(spec) || IsVariable(ops) {
+		return m, NewException(InstantiationError())
+	}
+	if !IsInteger(p) {
+		return m, NewException(TypeError("integer", p))
+	}
+	if !IsAtom(spec) {
+		return m, NewException(TypeError("atom", spec))
+	}
+	if !isOperatorPriority(p) {
+		return m, NewException(DomainError("operator_priority", p))
+	}
+	priority := p.(*Integer).Value()
+	if !isOperatorSpecifier(spec) {
+		return m, NewException(DomainError("operator_specifier", spec))
+	}
+	infix := len(spec.(*Atom).Name()) == 3
+	postfix := spec.String() == "xf" || spec.String() == "yf"
+
+	var names []string
+	opList := []Term{ops}
+	if IsEmptyList(ops) || ops.Indicator() == "./2" {
+		if ret, ok := checkList(ops); !ok {
+			return m, NewException(ret.(*foreignThrow).ball)
+		}
+		opList = ListToSlice(ops)
+	}
+	for _, op := range opList {
+		if IsVariable(op) {
+			return m, NewException(InstantiationError())
+		}
+		if !IsAtom(op) {
+			return m, NewException(TypeError("atom", op))
+		}
+		name := op.(*Atom).Name()
+		switch name {
+		case ",":
+			return m, NewException(PermissionError("modify", "operator", op))
+		case "[]", "{}":
+			return m, NewException(PermissionError("create", "operator", op))
+		case "|":
+			// ISO Cor.2 allows | as an infix operator above 1000
+			ok := infix && (priority.Sign() == 0 || priority.Int64() >= 1001)
+			if !ok {
+				return m, NewException(PermissionError("create", "operator", op))
+			}
+		}
+
+		// ISO doesn't allow an infix and a postfix operator with
+		// the same name
+		if priority.Sign() > 0 {
+			if x, _ := m.ops.Postfix(name); infix && x > 0 {
+				return m, NewException(PermissionError("create", "operator", op))
+			}
+			if x, _ := m.ops.Infix(name); postfix && x > 0 {
+				return m, NewException(PermissionError("create", "operator", op))
+			}
+		}
+		names = append(names, name)
+	}
+
+	table, err := m.ops.Define(int(priority.Int64()), spec.(*Atom).Name(), names...)
+	if err != nil {
+		return m, NewException(DomainError("operator_specifier", spec))
+	}
+	m1 := m.clone()
+	m1.ops = table
+	return m1, nil
+}
+
+// isOperatorPriority returns true if t is an integer from 0 to 1200
+func isOperatorPriority(t Term) bool {
+	if !IsInteger(t) {
+		return false
+	}
+	n := t.(*Integer).Value()
+	return n.IsInt64() && n.Int64() >= 0 && n.Int64() <= 1200
+}
+
+// isOperatorSpecifier returns true if t is one of the atoms xfx, xfy,
+// yfx, fx, fy, xf or yf
+func isOperatorSpecifier(t Term) bool {
+	switch t.String() {
+	case "xfx", "xfy", "yfx", "fx", "fy", "xf", "yf":
+		return true
+	}
+	return false
+}
+
+// predicateIndicators flattens the argument of directives like dynamic/1
+// which accept a single predicate indicator, a conjunction of them or
+// a list of them.
+func predicateIndicators(t Term) []Term {
+	switch t.Indicator() {
+	case ",/2":
+		args := t.(Callable).Arguments()
+		return append(predicateIndicators(args[0]), predicateIndicators(args[1])...)
+	case "./2":
+		if _, ok := checkList(t); !ok {
+			return []Term{t}
+		}
+		var pis []Term
+		for _, pi := range ListToSlice(t) {
+			pis = append(pis, predicateIndicators(pi)...)
+		}
+		return pis
+	case "[]/0":
+		return nil
+	}
+	return []Term{t}
+}
@@ -0,0 +1,96 @@
+package golog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mndrix/golog/term"
+)
+
+func TestConsultOp(t *testing.T) {
+	m := NewMachine().Consult(`
+        :- op(700, xfx, ===>).
+        :- op(200, xf, [days, weeks]).
+        rule(a ===> b).
+        duration(2 weeks).
+    `)
+	if !m.CanProve(`rule(===>(a, b)).`) {
+		t.Errorf("op/3 directive didn't define an infix operator")
+	}
+	if !m.CanProve(`duration(weeks(2)).`) {
+		t.Errorf("op/3 directive didn't define a postfix operator")
+	}
+}
+
+func TestDirectiveError(t *testing.T) {
+	tests := map[string]string{
+		"foo.\n\n:- fail.\n":                   `Directive failed`,
+		"foo.\n\n:- op(1201, xfx, x).\n":       `domain_error(operator_priority, 1201)`,
+		"foo.\n\n:- dynamic(foo).\n":           `type_error(predicate_indicator, foo)`,
+		"foo.\n\n:- initialization(bar).\n":    `existence_error(procedure, /(bar, 0))`,
+		"foo.\n\n:- ensure_loaded(missing).\n": `existence_error(source_sink, missing)`,
+	}
+	for src, expected := range tests {
+		err := consultError(src)
+		if err == nil {
+			t.Errorf("No error consulting %q", src)
+			continue
+		}
+		if err.Pos.Line != 3 {
+			t.Errorf("Wrong line for %q: %d vs 3", src, err.Pos.Line)
+		}
+		got := err.Err.Error()
+		if e, ok := err.Err.(*term.Exception); ok {
+			got = e.Ball().(term.Callable).Arguments()[0].String()
+		}
+		if got != expected {
+			t.Errorf("Wrong error for %q: %s vs %s", src, got, expected)
+		}
+	}
+}
+
+func consultError(src string) (err *DirectiveError) {
+	defer func() {
+		if x := recover(); x != nil {
+			err = x.(*DirectiveError)
+		}
+	}()
+	NewMachine().Consult(src)
+	return nil
+}
+
+func TestEnsureLoaded(t *testing.T) {
+	dir, err := ioutil.TempDir("", "golog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"main.pl":  ":- ensure_loaded(lib).\n:- ensure_loaded('lib.pl').\nmain.\n",
+		"lib.pl":   ":- ensure_loaded(other).\nlib.\n",
+		"other.pl": ":- ensure_loaded(lib).\nother.\n",
+	}
+	for name, content := range files {
+		err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0644)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	f, err := os.Open(filepath.Join(dir, "main.pl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	m := NewMachine().Consult(f)
+
+	// each file is loaded exactly once
+	for _, goal := range []string{`main.`, `lib.`, `other.`} {
+		if n := len(m.ProveAll(goal)); n != 1 {
+			t.Errorf("Wrong number of solutions for %s: %d vs 1", goal, n)
+		}
+	}
+}
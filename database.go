@@ -25,6 +25,21 @@ type Database interface {
 	// terms with the same name and arity.
 	Assertz(Term) Database
 
+	// Retract removes the first clause which is a variant of the given
+	// term (see term.Variant).  If there's no such clause, the database
+	// is returned unchanged.
+	Retract(Term) Database
+
+	// Abolish removes all clauses for the predicate with the given
+	// indicator (like foo/2).  Afterwards, the predicate is undefined.
+	Abolish(string) Database
+
+	// Declare makes sure that a predicate with the given indicator
+	// exists, even if it has no clauses.  Calling a predicate without
+	// clauses fails instead of raising an existence error.  This is
+	// how dynamic/1 works.
+	Declare(string) Database
+
 	// Candidates() returns a list of clauses that might unify with a term.
 	// Returns error if no predicate with appropriate
 	// name and arity has been defined.
@@ -89,6 +104,52 @@ func (self *mapDb) assert(side rune, term Term) Database {
 	return &newMapDb
 }
 
+func (self *mapDb) Retract(t Term) Database {
+	indicator := t.Indicator()
+	if IsClause(t) {
+		indicator = Head(t).Indicator()
+	}
+
+	oldClauses, ok := self.predicates.Lookup(indicator)
+	if !ok {
+		return self
+	}
+	cs, ok := oldClauses.(*clauses).without(func(clause Term) bool {
+		return Variant(t, clause)
+	})
+	if !ok {
+		return self
+	}
+
+	var newMapDb mapDb
+	newMapDb.clauseCount = self.clauseCount - 1
+	newMapDb.predicates = self.predicates.Set(indicator, cs)
+	return &newMapDb
+}
+
+func (self *mapDb) Abolish(indicator string) Database {
+	cs, ok := self.predicates.Lookup(indicator)
+	if !ok {
+		return self
+	}
+
+	var newMapDb mapDb
+	newMapDb.clauseCount = self.clauseCount - int(cs.(*clauses).count())
+	newMapDb.predicates = self.predicates.Delete(indicator)
+	return &newMapDb
+}
+
+func (self *mapDb) Declare(indicator string) Database {
+	if _, ok := self.predicates.Lookup(indicator); ok {
+		return self
+	}
+
+	var newMapDb mapDb
+	newMapDb.clauseCount = self.clauseCount
+	newMapDb.predicates = self.predicates.Set(indicator, newClauses())
+	return &newMapDb
+}
+
 func (self *mapDb) Candidates_(t Term) []Term {
 	ts, err := self.Candidates(t)
 	if err != nil {
@@ -109,12 +170,14 @@ func (self *mapDb) Candidates(t Term) ([]Term, error) {
 		return cs.(*clauses).all(), nil
 	}
 
-	// ignore clauses that can't possibly unify with our term
+	// consult the first argument index, then ignore clauses that
+	// can't possibly unify with our term
 	candidates := make([]Term, 0)
-	cs.(*clauses).forEach(func(clause Term) {
+	arg := t.(*Compound).Arguments()[0]
+	for _, clause := range cs.(*clauses).matching(arg) {
 		if !IsCompound(clause) {
 			Debugf("    ... discarding. Not compound term\n")
-			return
+			continue
 		}
 		head := clause
 		if IsClause(clause) {
@@ -124,7 +187,7 @@ func (self *mapDb) Candidates(t Term) ([]Term, error) {
 			Debugf("    ... adding to candidates: %s\n", clause)
 			candidates = append(candidates, clause)
 		}
-	})
+	}
 	Debugf("  final candidates = %s\n", candidates)
 	return candidates, nil
 }
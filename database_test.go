@@ -62,3 +62,67 @@ func TestAsserta(t *testing.T) {
 		t.Errorf("db3: can't find foo/2")
 	}
 }
+
+func TestRetract(t *testing.T) {
+	db0 := NewDatabase().
+		Assertz(read.Term_(`foo(one).`)).
+		Assertz(read.Term_(`foo(X) :- bar(X).`)).
+		Assertz(read.Term_(`foo(two).`))
+
+	// retracting a rule needs only a variant of the rule
+	db1 := db0.Retract(read.Term_(`foo(Y) :- bar(Y).`))
+	if n := db1.ClauseCount(); n != 2 {
+		t.Errorf("db1: wrong number of clauses: %d", n)
+	}
+	if cs := db1.Candidates_(read.Term_(`foo(_).`)); len(cs) != 2 {
+		t.Errorf("db1: wrong number of candidates: %d", len(cs))
+	}
+
+	// retracting something that doesn't exist changes nothing
+	db2 := db1.Retract(read.Term_(`foo(three).`))
+	if db2 != db1 {
+		t.Errorf("db2: retracting a missing clause changed the database")
+	}
+
+	// retracting every clause leaves the predicate defined
+	db3 := db2.Retract(read.Term_(`foo(one).`)).Retract(read.Term_(`foo(two).`))
+	if n := db3.ClauseCount(); n != 0 {
+		t.Errorf("db3: wrong number of clauses: %d", n)
+	}
+	if cs, err := db3.Candidates(read.Term_(`foo(_).`)); err != nil || len(cs) != 0 {
+		t.Errorf("db3: foo/1 should exist without clauses")
+	}
+
+	// abolishing a predicate makes it undefined
+	db4 := db0.Abolish("foo/1")
+	if n := db4.ClauseCount(); n != 0 {
+		t.Errorf("db4: wrong number of clauses: %d", n)
+	}
+	if _, err := db4.Candidates(read.Term_(`foo(_).`)); err == nil {
+		t.Errorf("db4: shouldn't have found foo/1")
+	}
+
+	// the original database is untouched
+	if n := db0.ClauseCount(); n != 3 {
+		t.Errorf("db0: wrong number of clauses: %d", n)
+	}
+}
+
+func TestDeclare(t *testing.T) {
+	db0 := NewDatabase()
+	db1 := db0.Declare("foo/1")
+
+	term := read.Term_(`foo(X).`)
+	if _, err := db0.Candidates(term); err == nil {
+		t.Errorf("db0: shouldn't have found foo/1")
+	}
+	if cs, err := db1.Candidates(term); err != nil || len(cs) != 0 {
+		t.Errorf("db1: foo/1 should exist without clauses")
+	}
+
+	// declaring an existing predicate keeps its clauses
+	db2 := db1.Assertz(read.Term_(`foo(a).`)).Declare("foo/1")
+	if cs := db2.Candidates_(term); len(cs) != 1 {
+		t.Errorf("db2: wrong number of candidates: %d", len(cs))
+	}
+}
@@ -0,0 +1,150 @@
+package golog
+
+// Definite clause grammars.  Grammar rules (terms like Head --> Body)
+// are translated into ordinary clauses as they're consulted.  The
+// translation follows the draft DCG standard for ISO Prolog.  Each
+// non-terminal gets two extra arguments: the list being parsed (S0) and
+// the part of it that remains afterwards (S).
+
+import (
+	. "github.com/mndrix/golog/term"
+)
+
+// dcgTranslateRule translates a grammar rule into the equivalent clause.
+// Returns an *Exception if rule is malformed.
+func dcgTranslateRule(rule Term) (Term, error) {
+	if IsVariable(rule) {
+		return nil, NewException(InstantiationError())
+	}
+	if rule.Indicator() != "-->/2" {
+		return nil, NewException(TypeError("callable", rule))
+	}
+	args := rule.(*Compound).Arguments()
+	head, body := args[0], args[1]
+	s0, s := NewVar("_"), NewVar("_")
+
+	// Head, Pushback --> Body
+	if IsCompound(head) && head.Indicator() == ",/2" {
+		parts := head.(*Compound).Arguments()
+		nt, err := dcgNonTerminal(parts[0], s0, s)
+		if err != nil {
+			return nil, err
+		}
+		s1 := NewVar("_")
+		goal, err := dcgBody(body, s0, s1)
+		if err != nil {
+			return nil, err
+		}
+		pushback, err := dcgTerminals(parts[1], s, s1)
+		if err != nil {
+			return nil, err
+		}
+		return NewCallable(":-", nt, NewCallable(",", goal, pushback)), nil
+	}
+
+	nt, err := dcgNonTerminal(head, s0, s)
+	if err != nil {
+		return nil, err
+	}
+	goal, err := dcgBody(body, s0, s)
+	if err != nil {
+		return nil, err
+	}
+	return NewCallable(":-", nt, goal), nil
+}
+
+// dcgNonTerminal adds the arguments s0 and s to non-terminal t
+func dcgNonTerminal(t, s0, s Term) (Term, error) {
+	switch {
+	case IsVariable(t):
+		return nil, NewException(InstantiationError())
+	case IsAtom(t):
+		return NewCallable(t.(*Atom).Name(), s0, s), nil
+	case IsCompound(t):
+		x := t.(*Compound)
+		args := make([]Term, 0, x.Arity()+2)
+		args = append(args, x.Arguments()...)
+		return NewCallable(x.Name(), append(args, s0, s)...), nil
+	}
+	return nil, NewException(TypeError("callable", t))
+}
+
+// dcgTerminals builds a goal which is true if s0 is the list of
+// terminals followed by s
+func dcgTerminals(list, s0, s Term) (Term, error) {
+	if !IsList(list) {
+		return nil, NewException(TypeError("list", list))
+	}
+	return NewCallable("=", s0, listWithTail(ListToSlice(list), s)), nil
+}
+
+// dcgBody translates the body of a grammar rule into a goal which
+// parses from s0 leaving s
+func dcgBody(body, s0, s Term) (Term, error) {
+	if IsVariable(body) {
+		return NewCallable("phrase", body, s0, s), nil
+	}
+	if IsEmptyList(body) || body.Indicator() == "./2" {
+		return dcgTerminals(body, s0, s)
+	}
+	if !IsCallable(body) {
+		return nil, NewException(TypeError("callable", body))
+	}
+
+	args := body.(Callable).Arguments()
+	switch body.Indicator() {
+	case ",/2":
+		mid := NewVar("_")
+		left, err := dcgBody(args[0], s0, mid)
+		if err != nil {
+			return nil, err
+		}
+		right, err := dcgBody(args[1], mid, s)
+		if err != nil {
+			return nil, err
+		}
+		return NewCallable(",", left, right), nil
+	case ";/2", "|/2":
+		left, err := dcgBody(args[0], s0, s)
+		if err != nil {
+			return nil, err
+		}
+		right, err := dcgBody(args[1], s0, s)
+		if err != nil {
+			return nil, err
+		}
+		return NewCallable(";", left, right), nil
+	case "->/2":
+		mid := NewVar("_")
+		cond, err := dcgBody(args[0], s0, mid)
+		if err != nil {
+			return nil, err
+		}
+		then, err := dcgBody(args[1], mid, s)
+		if err != nil {
+			return nil, err
+		}
+		return NewCallable("->", cond, then), nil
+	case "\\+/1":
+		goal, err := dcgBody(args[0], s0, NewVar("_"))
+		if err != nil {
+			return nil, err
+		}
+		return NewCallable(",", NewCallable("\\+", goal), NewCallable("=", s0, s)), nil
+	case "!/0":
+		return NewCallable(",", body, NewCallable("=", s0, s)), nil
+	case "{}/1":
+		goal := args[0]
+		if IsVariable(goal) {
+			goal = NewCallable("call", goal)
+		}
+		return NewCallable(",", goal, NewCallable("=", s0, s)), nil
+	case ":/2":
+		goal, err := dcgBody(args[1], s0, s)
+		if err != nil {
+			return nil, err
+		}
+		return NewCallable(":", args[0], goal), nil
+	}
+	return dcgNonTerminal(body, s0, s) // including call//N
+}
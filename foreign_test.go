@@ -2,7 +2,14 @@ package golog
 
 // Tests for foreign predicates
 
-import "testing"
+import (
+	"context"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"testing"
+)
 import . "github.com/mndrix/golog/term"
 
 func TestDeterministic(t *testing.T) {
@@ -30,3 +37,328 @@ func TestDeterministic(t *testing.T) {
 		t.Errorf("x has the wrong value: %d vs 2", x)
 	}
 }
+
+// counter iterates over the integers below max, recording how it's used
+type counter struct {
+	x      Term
+	i, max int
+	nexts  int
+	closes int
+}
+
+func (c *counter) Next(m Machine) (ForeignReturn, bool) {
+	c.nexts++
+	if c.i >= c.max {
+		return nil, false
+	}
+	c.i++
+	return ForeignUnify(c.x, NewInt64(int64(c.i-1))), true
+}
+
+func (c *counter) Close() {
+	c.closes++
+}
+
+func TestNondeterministic(t *testing.T) {
+	var c *counter
+	m := NewMachine().RegisterForeign(map[string]ForeignPredicate{
+		"count/2": func(m Machine, args []Term) ForeignReturn {
+			max := args[0].(*Integer).Value().Int64()
+			c = &counter{x: args[1], max: int(max)}
+			return ForeignIterate(c)
+		},
+	})
+
+	// all solutions, in order
+	answers := m.ProveAll(`count(3, X).`)
+	if len(answers) != 3 {
+		t.Fatalf("Wrong number of answers: %d vs 3", len(answers))
+	}
+	for i, answer := range answers {
+		x := answer.ByName_("X").(*Integer).Value().Int64()
+		if x != int64(i) {
+			t.Errorf("Answer %d has the wrong value: %d", i, x)
+		}
+	}
+	if c.closes != 1 {
+		t.Errorf("Exhausted iterator closed %d times", c.closes)
+	}
+
+	// failing solutions are skipped
+	if !m.CanProve(`count(5, X), X > 3.`) {
+		t.Errorf("Can't find a solution greater than 3")
+	}
+	if !m.CanProve(`count(5, 3).`) {
+		t.Errorf("Can't prove count(5, 3)")
+	}
+	if m.CanProve(`count(0, _).`) {
+		t.Errorf("Empty iterator shouldn't succeed")
+	}
+
+	// solutions are produced lazily and abandoned by Close
+	solutions := m.Solve(`count(100, X).`)
+	solutions.Next()
+	solutions.Next()
+	if c.nexts != 2 {
+		t.Errorf("Iterator called too often: %d vs 2", c.nexts)
+	}
+	solutions.Close()
+	solutions.Close()
+	if c.closes != 1 {
+		t.Errorf("Closed Solutions closed iterator %d times", c.closes)
+	}
+
+	// cut, if-then-else and \+ abandon remaining solutions
+	tests := []string{
+		`count(10, X), !.`,
+		`call((count(10, X), !)).`,
+		`( count(10, X) -> true ; fail ).`,
+		`\+ \+ count(10, _).`,
+		`catch((count(10, X), throw(oops)), oops, true).`,
+	}
+	for _, test := range tests {
+		if !m.CanProve(test) {
+			t.Errorf("Can't prove %s", test)
+			continue
+		}
+		if c.nexts != 1 || c.closes != 1 {
+			t.Errorf("%s: %d calls to Next and %d to Close", test, c.nexts, c.closes)
+		}
+	}
+
+	// uncaught exceptions close iterators too
+	_, err := m.CanProveContext(context.Background(), `count(10, _), throw(oops).`)
+	if err == nil || c.closes != 1 {
+		t.Errorf("Uncaught exception: err %v with %d closes", err, c.closes)
+	}
+}
+
+func TestForeignChoices(t *testing.T) {
+	m := NewMachine().RegisterForeign(map[string]ForeignPredicate{
+		"colour/1": func(m Machine, args []Term) ForeignReturn {
+			return ForeignChoices(
+				ForeignUnify(args[0], NewAtom("red")),
+				ForeignUnify(args[0], NewAtom("green")),
+				ForeignThrow(NewAtom("out_of_colours")),
+			)
+		},
+	})
+
+	x := NewVar("X")
+	goal := NewCallable("findall", x, NewCallable("catch",
+		NewCallable("colour", x), x, NewCallable("true")), NewVar("Xs"))
+	answers := m.ProveAll(goal)
+	if len(answers) != 1 {
+		t.Fatalf("Wrong number of answers: %d", len(answers))
+	}
+	got := answers[0].ByName_("Xs").String()
+	if got != "[red,green,out_of_colours]" {
+		t.Errorf("Wrong colours: %s", got)
+	}
+}
+
+// stepToAnswer steps m until it finds an answer
+func stepToAnswer(t *testing.T, m Machine) (Machine, Bindings) {
+	for {
+		m1, answer, err := m.Step()
+		if err != nil {
+			t.Fatalf("No answer: %s", err)
+		}
+		if answer != nil {
+			return m1, answer
+		}
+		m = m1
+	}
+}
+
+// backtracking into a foreign predicate doesn't change the machine
+func TestForeignImmutable(t *testing.T) {
+	var c *counter
+	m := NewMachine().RegisterForeign(map[string]ForeignPredicate{
+		"abc/1": func(m Machine, args []Term) ForeignReturn {
+			return ForeignChoices(
+				ForeignUnify(args[0], NewAtom("a")),
+				ForeignUnify(args[0], NewAtom("b")),
+				ForeignUnify(args[0], NewAtom("c")),
+			)
+		},
+		"count/2": func(m Machine, args []Term) ForeignReturn {
+			max := args[0].(*Integer).Value().Int64()
+			c = &counter{x: args[1], max: int(max)}
+			return ForeignIterate(c)
+		},
+	})
+
+	x := NewVar("X")
+	tests := []struct {
+		goal   Callable
+		second string
+	}{
+		{NewCallable("abc", x), "b"},
+		{NewCallable("count", NewInt64(3), x), "1"},
+	}
+	for _, test := range tests {
+		first, _ := stepToAnswer(t, m.PushConj(test.goal))
+		for i := 0; i < 2; i++ {
+			_, answer := stepToAnswer(t, first)
+			if got := answer.Resolve_(x).String(); got != test.second {
+				t.Errorf("%s: second answer %d is %s, not %s", test.goal, i, got, test.second)
+			}
+		}
+	}
+	if c.nexts != 2 {
+		t.Errorf("Iterator called %d times, not 2", c.nexts)
+	}
+
+	// a cut in one machine doesn't affect another one
+	m = m.Consult(`first(X) :- abc(X), !.`)
+	first, _ := stepToAnswer(t, m.PushConj(NewCallable("abc", x)))
+	if !m.CanProve(`first(a).`) {
+		t.Errorf("Can't prove first(a)")
+	}
+	_, answer := stepToAnswer(t, first)
+	if got := answer.Resolve_(x).String(); got != "b" {
+		t.Errorf("Second answer after a cut elsewhere is %s, not b", got)
+	}
+}
+
+type Point struct {
+	X, Y   int
+	hidden bool
+}
+
+func TestRegisterFunc(t *testing.T) {
+	m := NewMachine().
+		RegisterFunc("add/3", func(a, b int64) int64 { return a + b }).
+		RegisterFunc("small/2", func(a int8) int8 { return a }).
+		RegisterFunc("half/2", func(a *big.Rat) *big.Rat {
+			return new(big.Rat).Quo(a, big.NewRat(2, 1))
+		}).
+		RegisterFunc("square/2", func(a *big.Int) *big.Int {
+			return new(big.Int).Mul(a, a)
+		}).
+		RegisterFunc("root/2", math.Sqrt).
+		RegisterFunc("greet/2", func(s string) string { return "hello " + s }).
+		RegisterFunc("negate/2", func(b bool) bool { return !b }).
+		RegisterFunc("sum/2", func(xs []int) (n int) {
+			for _, x := range xs {
+				n += x
+			}
+			return n
+		}).
+		RegisterFunc("midpoint/3", func(a, b Point) Point {
+			return Point{X: (a.X + b.X) / 2, Y: (a.Y + b.Y) / 2}
+		}).
+		RegisterFunc("parse/2", strconv.Atoi).
+		RegisterFunc("text/2", func(t Term) string { return t.String() }).
+		RegisterFunc("pair/3", func(s string) (string, int) { return s, len(s) }).
+		RegisterFunc("size/2", func(m map[string]int) int { return len(m) }).
+		RegisterFunc("options/2", func(o struct{ Verbose bool }) bool { return o.Verbose })
+
+	tests := []string{
+		`add(1, 2, 3).`,
+		`add(1, 2, X), X == 3.`,
+		`\+ add(1, 2, 4).`,
+		`small(-128, -128).`,
+		`half(3, X), X =:= 1.5.`,
+		`half(4, X), X == 2.`,
+		`square(12345678901, 152415787526596567801).`,
+		`root(16, X), X =:= 4.0.`,
+		`root(2.25, 1.5).`,
+		`greet(world, 'hello world').`,
+		`negate(true, false).`,
+		`sum([1, 2, 3], 6).`,
+		`sum([], 0).`,
+		`midpoint(point(0, 0), point(4, 6), point(2, 3)).`,
+		`parse('42', 42).`,
+		`text(f(x), 'f(x)').`,
+		`pair(abc, abc, 3).`,
+		`size([a-1, b-2], 2).`,
+		`options([verbose-true], true).`,
+		`catch(add(a, 2, _), E, true), nonvar(E), E = error(type_error(integer, a), _).`,
+		`catch(add(_, 2, _), E, true), nonvar(E), E = error(instantiation_error, _).`,
+		`catch(small(128, _), E, true), nonvar(E), E = error(representation_error(max_integer), _).`,
+		`catch(small(-129, _), E, true), nonvar(E), E = error(representation_error(min_integer), _).`,
+		`X is sqrt(2), catch(half(X, _), E, true), nonvar(E), E = error(type_error(rational, X), _).`,
+		`catch(greet(1, _), E, true), nonvar(E), E = error(type_error(atom, 1), _).`,
+		`catch(negate(maybe, _), E, true), nonvar(E), E = error(type_error(boolean, maybe), _).`,
+		`catch(sum([1|_], _), E, true), nonvar(E), E = error(instantiation_error, _).`,
+		`catch(sum(foo, _), E, true), nonvar(E), E = error(type_error(list, foo), _).`,
+		`catch(sum([a], _), E, true), nonvar(E), E = error(type_error(integer, a), _).`,
+		`catch(midpoint(f(1, 2), point(1, 2), _), E, true), nonvar(E), E = error(type_error(point, f(1, 2)), _).`,
+		`catch(parse(nope, _), E, true), nonvar(E), E = error(system_error(_), _).`,
+	}
+	for _, test := range tests {
+		if !m.CanProve(test) {
+			t.Errorf("Can't prove %s", test)
+		}
+	}
+
+	// functions which don't match the predicate indicator or whose
+	// values can't be converted
+	bad := map[string]interface{}{
+		"add/2":  func(a, b int64) int64 { return a + b },
+		"f/1":    42,
+		"g/1":    func(x map[int]string) {},
+		"h/1":    func(x struct{ C chan int }) {},
+		"i/1":    func() chan int { return nil },
+		"j/1":    func(x []func()) {},
+		"vary/1": func(xs ...int) {},
+	}
+	for indicator, f := range bad {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("RegisterFunc accepted %s", indicator)
+				}
+			}()
+			NewMachine().RegisterFunc(indicator, f)
+		}()
+	}
+}
+
+func TestBlob(t *testing.T) {
+	var buf, out strings.Builder
+	m := NewMachine().
+		AttachOutput("user_output", &out).
+		RegisterForeign(map[string]ForeignPredicate{
+			"buffer/1": func(m Machine, args []Term) ForeignReturn {
+				return ForeignUnify(args[0], NewBlob(&buf))
+			},
+		}).
+		RegisterFunc("emit/2", func(b *strings.Builder, s string) {
+			b.WriteString(s)
+		})
+
+	tests := []string{
+		`buffer(B), emit(B, hello), emit(B, ' world').`,
+		`buffer(B), atomic(B), ground(B), \+ atom(B), \+ callable(B).`,
+		`buffer(A), buffer(B), A \== B, A @< B, \+ A = B.`,
+		`buffer(B), B @> zzz, B @< f(a).`,
+		`buffer(B), assertz(held(B)), held(X), X == B.`,
+		`buffer(B), assertz(held(f(B))), held(f(X)), X == B.`,
+		`buffer(A), buffer(B), bagof(X, member(X, [B,A,B]), L), L == [B,A,B].`,
+		`buffer(A), buffer(B), setof(X, member(X, [B,A,B]), L), L == [A,B].`,
+		`buffer(B), format("~a", [B]).`,
+		`catch(emit(foo, x), E, true), nonvar(E), E = error(type_error(_, foo), _).`,
+		`buffer(B), catch(emit(B, 1), E, true), nonvar(E), E = error(type_error(atom, 1), _).`,
+		`X = thing, catch(emit(X, x), E, true), nonvar(E), E = error(type_error(_, thing), _).`,
+	}
+	for _, test := range tests {
+		if !m.CanProve(test) {
+			t.Errorf("Can't prove %s", test)
+		}
+	}
+	if buf.String() != "hello world" {
+		t.Errorf("Wrong buffer contents: %q", buf.String())
+	}
+	if !strings.HasPrefix(out.String(), "<blob>(") {
+		t.Errorf("Wrong text for a blob: %q", out.String())
+	}
+
+	// blobs of the wrong type
+	m = m.RegisterFunc("count/2", func(xs []int) int { return len(xs) })
+	if !m.CanProve(`buffer(B), catch(count(B, _), E, true), nonvar(E), E = error(type_error(_, B), _).`) {
+		t.Errorf("Blob of the wrong type didn't raise a type error")
+	}
+}
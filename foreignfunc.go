@@ -0,0 +1,96 @@
+package golog
+
+// Registering ordinary Go functions as foreign predicates.  Values are
+// converted between Go and Prolog with term.Marshal and term.Unmarshal,
+// so the function itself never sees a term.
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	. "github.com/mndrix/golog/term"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// RegisterFunc registers Go function f to implement the predicate with
+// the given indicator, like "add/3".  f's parameters correspond to the
+// predicate's first arguments, which are inputs.  f's results correspond
+// to the remaining arguments, which are unified with the results.  If
+// f's last result is an error, it isn't an argument.  When that error
+// isn't nil, the predicate throws it (see ForeignThrow).  For example,
+//
+//	m = m.RegisterFunc("add/3", func(a, b int64) int64 { return a + b })
+//
+// Parameters are converted from terms with term.Unmarshal and results
+// are converted to terms with term.Marshal.  For example, an int64 is
+// an integer, a string is an atom, a slice is a list and a struct named
+// Point is a compound term like point(X,Y).  A term.Term parameter
+// receives its argument unchanged, even if it's a variable.  Arguments
+// which don't fit the corresponding parameter raise a type error,
+// instantiation error or representation error as appropriate.
+//
+// RegisterFunc panics if f isn't a function, its signature doesn't
+// match the indicator or it has a parameter or result which can't be
+// converted (see term.CanMarshal).
+func (m *machine) RegisterFunc(indicator string, f interface{}) Machine {
+	fn := reflect.ValueOf(f)
+	ft := fn.Type()
+	if ft.Kind() != reflect.Func || ft.IsVariadic() {
+		panic(fmt.Sprintf("RegisterFunc needs a non-variadic function for %s, not %s", indicator, ft))
+	}
+
+	// which results are arguments?
+	outs := ft.NumOut()
+	hasError := outs > 0 && ft.Out(outs-1) == errorType
+	if hasError {
+		outs--
+	}
+	arity := indicator[strings.LastIndex(indicator, "/")+1:]
+	if arity != fmt.Sprintf("%d", ft.NumIn()+outs) {
+		panic(fmt.Sprintf("Function %s doesn't match %s", ft, indicator))
+	}
+	for i := 0; i < ft.NumIn(); i++ {
+		checkFuncType(indicator, ft.In(i))
+	}
+	for i := 0; i < outs; i++ {
+		checkFuncType(indicator, ft.Out(i))
+	}
+
+	pred := func(m Machine, args []Term) ForeignReturn {
+		env := m.Bindings()
+		in := make([]reflect.Value, ft.NumIn())
+		for i := range in {
+			v := reflect.New(ft.In(i))
+			err := Unmarshal(args[i].ReplaceVariables(env), v.Interface())
+			if err != nil {
+				return foreignError(err)
+			}
+			in[i] = v.Elem()
+		}
+
+		results := fn.Call(in)
+		if hasError && !results[outs].IsNil() {
+			return foreignError(results[outs].Interface().(error))
+		}
+
+		pairs := make([]Term, 0, 2*outs)
+		for i := 0; i < outs; i++ {
+			t, err := Marshal(results[i].Interface())
+			if err != nil {
+				return foreignError(err)
+			}
+			pairs = append(pairs, args[ft.NumIn()+i], t)
+		}
+		return ForeignUnify(pairs...)
+	}
+	return m.RegisterForeign(map[string]ForeignPredicate{indicator: pred})
+}
+
+// checkFuncType panics if RegisterFunc can't convert values of type t
+func checkFuncType(indicator string, t reflect.Type) {
+	if !CanMarshal(t) {
+		panic(fmt.Sprintf("Can't convert between terms and %s for %s", t, indicator))
+	}
+}
@@ -47,3 +47,109 @@ func ForeignUnify(ts ...term.Term) ForeignReturn {
 type foreignUnify []term.Term
 
 func (*foreignUnify) IsaForeignReturn() {}
+
+// ForeignThrow indicates a foreign predicate that raises an exception,
+// exactly as if it had called throw/1 with the given term.  Use one
+// of the error constructors in the term package (like term.TypeError)
+// to build ISO error terms.
+func ForeignThrow(ball term.Term) ForeignReturn {
+	return &foreignThrow{ball: ball}
+}
+
+type foreignThrow struct {
+	ball term.Term
+}
+
+func (*foreignThrow) IsaForeignReturn() {}
+
+// ForeignIterator produces the solutions of a nondeterministic foreign
+// predicate one at a time, as the machine backtracks into it.  It's
+// typically a thin wrapper around some Go iterator, like a database
+// cursor.  See ForeignIterate.
+//
+// Although the iterator itself is mutable, each solution it produces
+// is remembered.  A machine which is stepped more than once from the
+// same state sees the same solutions each time.
+type ForeignIterator interface {
+	// Next produces the next solution as any ForeignReturn value.  m
+	// is the machine proving the predicate.  A Machine derived from m
+	// keeps the iterator's remaining solutions.  If ok is false, there
+	// are no more solutions and ret is ignored.  If ret fails (like a
+	// ForeignUnify which doesn't unify), Next is called again.
+	Next(m Machine) (ret ForeignReturn, ok bool)
+
+	// Close releases any resources held by the iterator.  It's called
+	// exactly once: after Next reports that there are no more solutions
+	// or when the remaining solutions are abandoned by a cut, an
+	// exception or by closing Solutions.  Afterwards, backtracking into
+	// the predicate only finds the solutions produced before Close.
+	Close()
+}
+
+// ForeignIterate indicates a nondeterministic foreign predicate.  Its
+// solutions are produced lazily by it: one for the call itself, then
+// one more each time the machine backtracks into the predicate.
+func ForeignIterate(it ForeignIterator) ForeignReturn {
+	return &foreignIterate{it: it}
+}
+
+type foreignIterate struct {
+	it     ForeignIterator
+	closed bool
+}
+
+func (*foreignIterate) IsaForeignReturn() {}
+
+// close closes the iterator, unless that's already been done
+func (x *foreignIterate) close() {
+	if !x.closed {
+		x.closed = true
+		x.it.Close()
+	}
+}
+
+// foreignSolutions is an immutable list of the solutions produced by a
+// foreign iterator.  Like lex.List, it populates its tail as needed by
+// calling the iterator.  The list starts with a single, unproduced
+// element.
+type foreignSolutions struct {
+	iter     *foreignIterate
+	produced bool
+	m        Machine       // machine given to the iterator's Next
+	ret      ForeignReturn // nil at the end of the list
+	rest     *foreignSolutions
+}
+
+// produce calls the iterator to produce this element, unless that's
+// already been done.  m is the machine proving the predicate.
+func (s *foreignSolutions) produce(m Machine) {
+	if s.produced {
+		return
+	}
+	s.produced = true
+	if s.iter.closed {
+		return // abandoned before this solution was produced
+	}
+
+	// a machine derived from s.m keeps the remaining solutions
+	s.rest = &foreignSolutions{iter: s.iter}
+	s.m = m.PushDisj(&foreignCP{machine: m, solutions: s.rest})
+	ret, ok := s.iter.it.Next(s.m)
+	if !ok {
+		s.iter.close()
+		return
+	}
+	s.ret = ret
+}
+
+// ForeignChoices indicates a nondeterministic foreign predicate whose
+// solutions are rets, tried in order on backtracking.  For example,
+// ForeignChoices(ForeignUnify(x, a), ForeignUnify(x, b)) unifies x with
+// a and then with b.
+func ForeignChoices(rets ...ForeignReturn) ForeignReturn {
+	return (*foreignChoices)(&rets)
+}
+
+type foreignChoices []ForeignReturn
+
+func (*foreignChoices) IsaForeignReturn() {}
@@ -0,0 +1,408 @@
+package golog
+
+// Formatted output for format/1,2,3.  The directives are those of
+// SWI-Prolog (which mostly come from Quintus).  A directive looks like
+// ~Nc where c is a single character and N is an optional numeric
+// argument.  N can be a decimal number, * (take the number from the
+// argument list) or `x (the character code of x).
+//
+// Column alignment works on segments of text.  Each ~| or ~+ column stop
+// ends a segment and pads it to the requested column.  Padding goes in
+// the segment's fill points (~t), divided evenly among them.  Without
+// any fill points, text is left aligned.
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/mndrix/golog/read"
+	. "github.com/mndrix/golog/term"
+)
+
+// defaultColumnWidth is the width of a ~+ column without a numeric
+// argument
+const defaultColumnWidth = 8
+
+type formatter struct {
+	args []Term          // arguments not yet consumed
+	ops  *read.Operators // operators for writing terms
+
+	done    []rune // text before the current column segment
+	doneCol int    // column at the end of done
+
+	pending  []rune      // the current column segment
+	fills    []fillPoint // fill points within pending
+	lastStop int         // column of the most recent column stop
+}
+
+// fillPoint is a place in a column segment where ~t asked for padding
+type fillPoint struct {
+	offset int  // position within the segment
+	char   rune // character used for padding
+}
+
+// formatText populates the template f with arguments args as described
+// for format/2.  Terms are written using operator table ops.  On
+// failure, returns an exception term to throw.
+func formatText(f, args Term, ops *read.Operators) (string, Term) {
+	template, ok := textOf(f)
+	if !ok {
+		if IsVariable(f) {
+			return "", InstantiationError()
+		}
+		return "", TypeError("text", f)
+	}
+
+	fm := &formatter{ops: ops}
+	switch {
+	case IsVariable(args):
+		return "", InstantiationError()
+	case IsList(args):
+		fm.args = ListToSlice(args)
+	default:
+		fm.args = []Term{args}
+	}
+
+	rs := []rune(template)
+	for i := 0; i < len(rs); i++ {
+		if rs[i] != '~' {
+			fm.emit(string(rs[i]))
+			continue
+		}
+
+		// optional numeric argument
+		i++
+		num, hasNum := 0, false
+		switch {
+		case i >= len(rs):
+		case rs[i] == '*':
+			arg, ball := fm.next()
+			if ball != nil {
+				return "", ball
+			}
+			if !isNonNegativeInteger(arg) {
+				return "", formatError("~* expects a non-negative integer argument")
+			}
+			num, hasNum = int(arg.(*Integer).Value().Int64()), true
+			i++
+		case rs[i] == '`':
+			if i+1 >= len(rs) {
+				return "", formatError("truncated format directive")
+			}
+			num, hasNum = int(rs[i+1]), true
+			i += 2
+		case rs[i] >= '0' && rs[i] <= '9':
+			for i < len(rs) && rs[i] >= '0' && rs[i] <= '9' {
+				num = num*10 + int(rs[i]-'0')
+				i++
+			}
+			hasNum = true
+		}
+		if i >= len(rs) {
+			return "", formatError("truncated format directive")
+		}
+
+		if ball := fm.directive(rs[i], num, hasNum); ball != nil {
+			return "", ball
+		}
+	}
+
+	if len(fm.args) > 0 {
+		return "", formatError("too many arguments")
+	}
+	fm.done = append(fm.done, fm.pending...)
+	return string(fm.done), nil
+}
+
+// directive handles a single directive d with numeric argument num
+func (fm *formatter) directive(d rune, num int, hasNum bool) Term {
+	switch d {
+	case '~':
+		fm.emit("~")
+	case 'n':
+		if !hasNum {
+			num = 1
+		}
+		fm.emit(strings.Repeat("\n", num))
+	case 't':
+		fill := ' '
+		if hasNum {
+			fill = rune(num)
+		}
+		fm.fills = append(fm.fills, fillPoint{len(fm.pending), fill})
+	case '|':
+		target := fm.column()
+		if hasNum {
+			target = num
+		}
+		fm.columnStop(target)
+	case '+':
+		if !hasNum {
+			num = defaultColumnWidth
+		}
+		fm.columnStop(fm.lastStop + num)
+	default:
+		arg, ball := fm.next()
+		if ball != nil {
+			return ball
+		}
+		text, ball := fm.argument(d, arg, num, hasNum)
+		if ball != nil {
+			return ball
+		}
+		fm.emit(text)
+	}
+	return nil
+}
+
+// argument formats arg according to a directive that consumes exactly
+// one argument
+func (fm *formatter) argument(d rune, arg Term, num int, hasNum bool) (string, Term) {
+	if IsVariable(arg) && d != 'w' && d != 'p' && d != 'q' && d != 'i' {
+		return "", InstantiationError()
+	}
+
+	switch d {
+	case 'w':
+		return termText(arg, false, fm.ops), nil
+	case 'p', 'q':
+		return termText(arg, true, fm.ops), nil
+	case 'i':
+		return "", nil
+	case 'a':
+		if !IsAtom(arg) && !IsNumber(arg) && !IsBlob(arg) {
+			return "", TypeError("atomic", arg)
+		}
+		return termText(arg, false, fm.ops), nil
+	case 'c':
+		if !IsInteger(arg) || !isInCharacterCode(arg) {
+			return "", TypeError("character_code", arg)
+		}
+		if !hasNum {
+			num = 1
+		}
+		return strings.Repeat(string(arg.(*Integer).Code()), num), nil
+	case 's':
+		text, ok := textOf(arg)
+		if !ok || (IsAtom(arg) && !IsEmptyList(arg)) {
+			return "", TypeError("codes", arg)
+		}
+		return text, nil
+	case 'd', 'D':
+		if !IsInteger(arg) {
+			return "", TypeError("integer", arg)
+		}
+		return formatInteger(arg.(*Integer).Value(), num, d == 'D'), nil
+	case 'r', 'R':
+		if !IsInteger(arg) {
+			return "", TypeError("integer", arg)
+		}
+		if !hasNum || num < 2 || num > 36 {
+			return "", formatError("~r requires a radix between 2 and 36")
+		}
+		text := arg.(*Integer).Value().Text(num)
+		if d == 'R' {
+			text = strings.ToUpper(text)
+		}
+		return text, nil
+	case 'e', 'f', 'g':
+		if !IsNumber(arg) {
+			return "", TypeError("number", arg)
+		}
+		if !hasNum {
+			num = 6
+		}
+		n := arg.(Number)
+		if r, ok := n.LosslessRat(); ok && d == 'f' {
+			return r.FloatString(num), nil // exact, even for huge numbers
+		}
+		return fmt.Sprintf("%.*"+string(d), num, n.Float64()), nil
+	}
+	return "", formatError("unknown directive ~" + string(d))
+}
+
+// formatInteger implements ~Nd and ~ND.  A positive N inserts a decimal
+// point N digits from the right.  If group is true, digits left of the
+// decimal point are grouped in thousands with commas.
+func formatInteger(n *big.Int, point int, group bool) string {
+	digits := new(big.Int).Abs(n).String()
+	if len(digits) <= point {
+		digits = strings.Repeat("0", point-len(digits)+1) + digits
+	}
+	whole, fraction := digits[:len(digits)-point], digits[len(digits)-point:]
+
+	if group {
+		var b strings.Builder
+		for i, c := range whole {
+			if i > 0 && (len(whole)-i)%3 == 0 {
+				b.WriteByte(',')
+			}
+			b.WriteRune(c)
+		}
+		whole = b.String()
+	}
+
+	text := whole
+	if fraction != "" {
+		text += "." + fraction
+	}
+	if n.Sign() < 0 {
+		text = "-" + text
+	}
+	return text
+}
+
+// next consumes the next argument
+func (fm *formatter) next() (Term, Term) {
+	if len(fm.args) == 0 {
+		return nil, formatError("not enough arguments")
+	}
+	arg := fm.args[0]
+	fm.args = fm.args[1:]
+	return arg, nil
+}
+
+// emit adds text to the output.  A newline ends the current column
+// segment without padding it.
+func (fm *formatter) emit(text string) {
+	fm.pending = append(fm.pending, []rune(text)...)
+	if strings.ContainsRune(text, '\n') {
+		fm.flush()
+		fm.lastStop = 0
+	}
+}
+
+// column returns the current output column
+func (fm *formatter) column() int {
+	return fm.doneCol + len(fm.pending)
+}
+
+// columnStop pads the current column segment so that it ends at column
+// target.  Segments that are already too wide are left alone.
+func (fm *formatter) columnStop(target int) {
+	pad := target - fm.column()
+	if pad > 0 {
+		if len(fm.fills) == 0 {
+			fm.fills = []fillPoint{{len(fm.pending), ' '}}
+		}
+
+		// insert padding right to left so offsets stay valid
+		var padded []rune
+		rest := fm.pending
+		for i := len(fm.fills) - 1; i >= 0; i-- {
+			fill := fm.fills[i]
+			width := pad / len(fm.fills)
+			if i >= len(fm.fills)-pad%len(fm.fills) {
+				width++
+			}
+			tail := append([]rune(strings.Repeat(string(fill.char), width)), rest[fill.offset:]...)
+			padded = append(tail, padded...)
+			rest = rest[:fill.offset]
+		}
+		fm.pending = append(rest, padded...)
+	}
+	fm.flush()
+	fm.lastStop = fm.doneCol
+}
+
+// flush moves the current column segment into the finished output
+func (fm *formatter) flush() {
+	if i := lastIndexRune(fm.pending, '\n'); i >= 0 {
+		fm.doneCol = len(fm.pending) - i - 1
+	} else {
+		fm.doneCol += len(fm.pending)
+	}
+	fm.done = append(fm.done, fm.pending...)
+	fm.pending = nil
+	fm.fills = nil
+}
+
+func lastIndexRune(rs []rune, r rune) int {
+	for i := len(rs) - 1; i >= 0; i-- {
+		if rs[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// textOf returns the text represented by an atom, a list of character
+// codes or a list of characters
+func textOf(t Term) (string, bool) {
+	if IsAtom(t) && !IsEmptyList(t) {
+		return t.(*Atom).Name(), true
+	}
+	if !IsList(t) {
+		return "", false
+	}
+	var b strings.Builder
+	for _, x := range ListToSlice(t) {
+		switch {
+		case IsInteger(x) && isInCharacterCode(x):
+			b.WriteRune(x.(*Integer).Code())
+		case isCharacter(x):
+			b.WriteString(x.(*Atom).Name())
+		default:
+			return "", false
+		}
+	}
+	return b.String(), true
+}
+
+// isNonNegativeInteger returns true if t is an integer from 0 up to the
+// largest int
+func isNonNegativeInteger(t Term) bool {
+	if !IsInteger(t) {
+		return false
+	}
+	n := t.(*Integer).Value()
+	return n.Sign() >= 0 && n.IsInt64() && n.Int64() <= int64(^uint(0)>>1)
+}
+
+// formatError builds an error term for a malformed format template or
+// argument list.  This follows SWI-Prolog.
+func formatError(message string) Term {
+	return NewCallable("error", NewCallable("format", NewAtom(message)), NewVar("_"))
+}
+
+// formatSink handles the first argument of format/3 when it names
+// something other than a stream.  It returns the term to unify with the
+// formatted text or false if sink is a stream.
+func formatSink(sink Term, text string) (ForeignReturn, bool) {
+	if !IsCompound(sink) {
+		return nil, false
+	}
+	args := sink.(*Compound).Arguments()
+	tail := Term(NewAtom("[]"))
+	if len(args) == 2 {
+		tail = args[1]
+	}
+
+	switch sink.Indicator() {
+	case "atom/1":
+		return ForeignUnify(args[0], NewAtom(text)), true
+	case "codes/1", "codes/2", "string/1":
+		var codes []Term
+		for _, r := range text {
+			codes = append(codes, NewCode(r))
+		}
+		return ForeignUnify(args[0], listWithTail(codes, tail)), true
+	case "chars/1", "chars/2":
+		var chars []Term
+		for _, r := range text {
+			chars = append(chars, NewAtom(string(r)))
+		}
+		return ForeignUnify(args[0], listWithTail(chars, tail)), true
+	}
+	return nil, false
+}
+
+// listWithTail is like SliceToList but the list ends in tail instead of []
+func listWithTail(ts []Term, tail Term) Term {
+	list := tail
+	for i := len(ts) - 1; i >= 0; i-- {
+		list = NewCallable(".", ts[i], list)
+	}
+	return list
+}
@@ -60,43 +60,162 @@ matching regexp and prints them.`,
 		"->/2":   `Implication operator.`,
 		";/2":    `Disjunction operator.`,
 		"=/2":    `Unification operator.`,
+		"</2":    `Numeric less than operator.`,
+		"=</2":   `Numeric less than or equal operator.`,
 		"=:=/2":  `Numeric equality operator.`,
+		"=\\=/2": `Numeric inequality operator.`,
+		">/2":    `Numeric greater than operator.`,
+		">=/2":   `Numeric greater than or equal operator.`,
 		"==/2":   `Equality operator.`,
 		"\\==/2": `Equality negation operator.`,
 		"@</2":   `Less than operator.`,
 		"@=</2":  `Less than or equal operator`,
 		"@>/2":   `Greater than operator.`,
 		"@>=/2":  `Greater than or equal operator.`,
+		"=../2":  `Relates a term to a list of its name and arguments.`,
 		`\+/1`:   `Negation operator.`,
+		"abolish/1": `Removes all clauses of the predicate indicated by its
+argument.`,
+		"arg/3": `Third argument is the argument of the second argument at
+the position given by the first argument.`,
+		"assert/1":           `Same as assertz/1.`,
+		"asserta/1":          `Adds a clause to the start of the database.`,
+		"assertz/1":          `Adds a clause to the end of the database.`,
+		"at_end_of_stream/0": `True if the current input has no more content.`,
+		"at_end_of_stream/1": `True if the given stream has no more content.`,
+		"atom/1":             `Succeeds if the argument is an atom.`,
+		"atom_chars/2":       `Second argument is the list of characters of the atom.`,
 		"atom_codes/2": `Second argument is the list containing the character
 codes of the name of the first argument.`,
+		"atom_concat/3": `Third argument is the first two atoms joined together.
+On backtracking, finds every way of splitting the third argument.`,
+		"atom_length/2": `Second argument is the number of characters in the atom.`,
 		"atom_number/2": `Second argument is the number represented by the name
 of the first argument.`,
-		"call/1": `Evaluates its argument.`,
-		"call/2": `Constructs term from its arguments and evaluates it.`,
-		"call/3": `Constructs term from its arguments and evaluates it.`,
-		"call/4": `Constructs term from its arguments and evaluates it.`,
-		"call/5": `Constructs term from its arguments and evaluates it.`,
-		"call/6": `Constructs term from its arguments and evaluates it.`,
+		"atomic/1":             `Succeeds if the argument is an atom or a number.`,
+		"atomic_list_concat/2": `Joins a list of atoms and numbers into a single atom.`,
+		"atomic_list_concat/3": `Joins a list of atoms and numbers with a separator (second
+argument) between them.  Splits the third argument if the list is unbound.`,
+		"bagof/3": `Like findall/3 but groups solutions by the bindings of
+free variables in the second argument.  Fails if there are no solutions.`,
+		"call/1":     `Evaluates its argument.`,
+		"call/2":     `Constructs term from its arguments and evaluates it.`,
+		"call/3":     `Constructs term from its arguments and evaluates it.`,
+		"call/4":     `Constructs term from its arguments and evaluates it.`,
+		"call/5":     `Constructs term from its arguments and evaluates it.`,
+		"call/6":     `Constructs term from its arguments and evaluates it.`,
+		"call/7":     `Constructs term from its arguments and evaluates it.`,
+		"call/8":     `Constructs term from its arguments and evaluates it.`,
+		"callable/1": `Succeeds if the argument is an atom or a compound term.`,
+		"catch/3": `Evaluates its first argument.  If that throws an exception
+which unifies with the second argument, evaluates the third argument instead.`,
+		"char_code/2": `Second argument is the character code of the character.`,
+		"close/1":     `Closes the given stream.`,
+		"close/2": `Closes the given stream using the options in the second
+argument.`,
+		"compound/1": `Succeeds if the argument is a compound term.`,
+		"copy_term/2": `Second argument is a copy of the first argument with
+fresh variables.`,
+		"current_input/1": `Unifies its argument with the current input stream.`,
+		"current_op/3": `Enumerates the operator table as op(Priority, Specifier,
+Name).`,
+		"current_output/1": `Unifies its argument with the current output stream.`,
+		"dcg_translate_rule/2": `Second argument is the clause for the grammar
+rule in the first argument.`,
 		"downcase_atom/2": `Second argument is the atom with the name made up of
 all the same characters of the first atom, just in lower case`,
 		"fail/0": `Fail unconditionaly.`,
 		"findall/3": `Generate variables from template (first argument),
 bind them in the second argument, then collect the bindings in the third argument.`,
-		"ground/1": `Succeeds if the argument is ground.`,
+		"float/1":        `Succeeds if the argument is a floating point number.`,
+		"flush_output/0": `Flushes buffered output on the current output stream.`,
+		"flush_output/1": `Flushes buffered output on the given stream.`,
+		"format/1":       `Same as format/2 with no arguments.`,
+		"format/2": `Populates the template in the first argument with the
+arguments in the second argument and writes the result to the current output.
+Directives are like ~w, ~a, ~d, ~2f, ~s and ~t~20|.`,
+		"format/3": `Same as format/2 but writes to a stream or, given
+atom(A), codes(C) or chars(C), to a term.`,
+		"functor/3":  `Relates a term to its name and arity.`,
+		"get_byte/1": `Reads a byte from the current input.`,
+		"get_byte/2": `Reads a byte from the given stream.`,
+		"get_char/1": `Reads a character from the current input.`,
+		"get_char/2": `Reads a character from the given stream.`,
+		"get_code/1": `Reads a character code from the current input.`,
+		"get_code/2": `Reads a character code from the given stream.`,
+		"ground/1":   `Succeeds if the argument is ground.`,
+		"integer/1":  `Succeeds if the argument is an integer.`,
 		"is/2": `Succeeds if the numerical expressions on both sides
 evaluate to the same number.`,
-		"listing/0": `Prints all predicates known to this interpreter.`,
-		"msort/2":   `Sorts list.`,
-		"printf/1":  `Prints its first argument.`,
-		"printf/2": `Populates the template in the first argument with
-the printable representations of its second argument (which must be a list)
-and prints it.`,
-		"printf/3": `Same as printf/2, but prints into a stream given
-in the first argument.`,
+		"is_list/1":      `Succeeds if the argument is a proper list.`,
+		"listing/0":      `Prints all predicates known to this interpreter.`,
+		"msort/2":        `Sorts list.`,
+		"nl/0":           `Writes a newline to the current output.`,
+		"nl/1":           `Writes a newline to the given stream.`,
+		"nonvar/1":       `Succeeds if the argument is not an unbound variable.`,
+		"number/1":       `Succeeds if the argument is a number.`,
+		"number_chars/2": `Second argument is the list of characters of the number.`,
+		"number_codes/2": `Second argument is the list of character codes of the
+number.`,
+		"op/3": `Defines operators (third argument) with the given priority and
+specifier.  Priority 0 removes them.  Affects later reading and writing.`,
+		"open/3": `Opens a file (first argument) in the given mode, producing a
+stream.`,
+		"open/4":      `Same as open/3 but accepts a list of stream options.`,
+		"peek_byte/1": `Like get_byte/1 but leaves the byte on the stream.`,
+		"peek_byte/2": `Like get_byte/2 but leaves the byte on the stream.`,
+		"peek_char/1": `Like get_char/1 but leaves the character on the stream.`,
+		"peek_char/2": `Like get_char/2 but leaves the character on the stream.`,
+		"peek_code/1": `Like get_code/1 but leaves the character on the stream.`,
+		"peek_code/2": `Like get_code/2 but leaves the character on the stream.`,
+		"print/1":     `Same as writeq/1.`,
+		"print/2":     `Same as writeq/2.`,
+		"put_byte/1":  `Writes a byte to the current output.`,
+		"put_byte/2":  `Writes a byte to the given stream.`,
+		"put_char/1":  `Writes a character to the current output.`,
+		"put_char/2":  `Writes a character to the given stream.`,
+		"put_code/1":  `Writes a character code to the current output.`,
+		"put_code/2":  `Writes a character code to the given stream.`,
+		"rational/1": `Succeeds if the argument is an integer or an exactly
+represented float.`,
+		"read/1": `Reads a term from the current input.`,
+		"read/2": `Reads a term from the given stream.`,
+		"read_term/2": `Reads a term from the current input using the given
+options.`,
+		"read_term/3": `Reads a term from the given stream using the given options.`,
+		"retract/1": `Removes the first clause which unifies with its argument.
+On backtracking, removes the next one.`,
+		"retractall/1": `Removes all clauses whose head unifies with its
+argument.`,
+		"set_input/1":  `Makes its argument the current input stream.`,
+		"set_output/1": `Makes its argument the current output stream.`,
+		"setof/3": `Like bagof/3 but each group of solutions is sorted without
+duplicates.`,
+		"stream_property/2": `Relates a stream to its properties.`,
+		"sub_atom/5": `Fifth argument is part of the atom with the given number
+of characters before, in and after it.  Finds every part on backtracking.`,
 		"succ/2": `True if its second argument is one greater than its
 first argument.`,
-		"var/1": `True if its argument is a variable.`,
+		"term_to_atom/2": `Second argument is the text of the term.  Parses the
+text if the atom is given.`,
+		"term_variables/2": `Second argument is the list of variables in the
+first argument.`,
+		"throw/1": `Throws its argument as an exception.`,
+		"upcase_atom/2": `Second argument is the atom with the name made up of
+all the same characters of the first atom, just in upper case`,
+		"var/1":   `True if its argument is a variable.`,
+		"write/1": `Writes a term to the current output.`,
+		"write/2": `Writes a term to the given stream.`,
+		"write_canonical/1": `Writes a term to the current output, quoted and
+ignoring operators.`,
+		"write_canonical/2": `Writes a term to the given stream, quoted and
+ignoring operators.`,
+		"write_term/2": `Writes a term to the current output using the given
+options.`,
+		"write_term/3": `Writes a term to the given stream using the given options.`,
+		"writeq/1": `Writes a term to the current output, quoting atoms where
+needed.`,
+		"writeq/2": `Writes a term to the given stream, quoting atoms where needed.`,
 	}
 }
 
@@ -39,24 +39,44 @@ type Eme struct {
 func Scan(src io.Reader) <-chan *Eme {
 	ch := make(chan *Eme)
 	go func() {
-		s := new(Scanner).Init(src)
-		p := s.Pos()
-		tok := s.Scan()
-		for tok != EOF {
-			l := &Eme{
-				Type:    tok,
-				Content: s.TokenText(),
-				Pos:     &p,
-			}
+		next := lexemes(src)
+		for l := next(); l.Type != EOF; l = next() {
 			ch <- l
-			p = s.Pos()
-			tok = s.Scan()
 		}
 		close(ch)
 	}()
 	return ch
 }
 
+// lexemes returns a function which scans the next lexeme from src each
+// time it's called.  At the end of src, it keeps returning an EOF
+// lexeme without reading src again.
+func lexemes(src io.Reader) func() *Eme {
+	s := new(Scanner).Init(src)
+	if f, ok := src.(interface {
+		Name() string
+	}); ok { // like *os.File
+		s.Filename = f.Name()
+	}
+	done := false
+	return func() *Eme {
+		if done {
+			return &Eme{Type: EOF}
+		}
+		tok := s.Scan()
+		if tok == EOF {
+			done = true
+			return &Eme{Type: EOF}
+		}
+		p := s.Position // where this token starts
+		return &Eme{
+			Type:    tok,
+			Content: s.TokenText(),
+			Pos:     &p,
+		}
+	}
+}
+
 // A source position is represented by a Position value.
 // A position is valid if Line > 0.
 type Position struct {
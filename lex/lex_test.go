@@ -611,3 +611,29 @@ func TestAcid(t *testing.T) {
 
 	checkScanPos(t, s, 336, 16, 1, EOF, "")
 }
+
+// lexemes produced by Scan should know where their token starts
+func TestScanLexemePosition(t *testing.T) {
+	lexemes := Scan(bytes.NewBufferString("foo.\n  bar(X).\n"))
+	expected := []Position{
+		{Offset: 0, Line: 1, Column: 1},  // foo
+		{Offset: 3, Line: 1, Column: 4},  // .
+		{Offset: 7, Line: 2, Column: 3},  // bar
+		{Offset: 10, Line: 2, Column: 6}, // (
+		{Offset: 11, Line: 2, Column: 7}, // X
+		{Offset: 12, Line: 2, Column: 8}, // )
+		{Offset: 13, Line: 2, Column: 9}, // .
+	}
+	i := 0
+	for lexeme := range lexemes {
+		if i >= len(expected) {
+			t.Errorf("Too many lexemes: %s", lexeme.Content)
+			continue
+		}
+		checkPos(t, *lexeme.Pos, expected[i])
+		i++
+	}
+	if i != len(expected) {
+		t.Errorf("Wrong number of lexemes: %d vs %d", i, len(expected))
+	}
+}
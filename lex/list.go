@@ -1,33 +1,49 @@
 package lex
 
-// An immutable list of lexemes which populates its tail by reading
-// lexemes from a channel, such as that provided by Scan()
+import "io"
+
+// An immutable list of lexemes which populates its tail as needed by
+// reading lexemes from a channel, such as that provided by Scan(), or
+// by scanning them directly from an io.Reader
 type List struct {
 	Value *Eme
 	next  *List
-	src   <-chan *Eme
+	src   func() *Eme // produces the next lexeme
 }
 
 // NewLexemList returns a new lexeme list which pulls lexemes from
 // the given source channel.  Creating a new list consumes one lexeme
 // from the source channel.
 func NewList(src <-chan *Eme) *List {
-	lexeme, ok := <-src
-	if !ok {
-		lexeme = &Eme{Type: EOF}
-	}
+	return newList(func() *Eme {
+		lexeme, ok := <-src
+		if !ok {
+			lexeme = &Eme{Type: EOF}
+		}
+		return lexeme
+	})
+}
+
+// NewScannerList returns a new lexeme list which scans lexemes from src
+// as they're needed.  Unlike a list built from Scan(), it doesn't read
+// ahead in a separate goroutine.  Creating a new list scans one lexeme.
+func NewScannerList(src io.Reader) *List {
+	return newList(lexemes(src))
+}
+
+func newList(src func() *Eme) *List {
 	return &List{
-		Value: lexeme,
+		Value: src(),
 		next:  nil,
 		src:   src,
 	}
 }
 
 // Next returns the next element in the lexeme list, pulling a lexeme
-// from the source channel, if necessary
+// from the source, if necessary
 func (self *List) Next() *List {
 	if self.next == nil {
-		next := NewList(self.src)
+		next := newList(self.src)
 		self.next = next
 	}
 	return self.next
@@ -1,6 +1,10 @@
 package lex
 
-import "testing"
+import (
+	"strings"
+	"testing"
+	"testing/iotest"
+)
 
 func TestLLBasic(t *testing.T) {
 	ch := make(chan *Eme)
@@ -36,3 +40,25 @@ func TestLLBasic(t *testing.T) {
 		t.Errorf("Backing channel still not closed")
 	}
 }
+
+func TestScannerList(t *testing.T) {
+	r := strings.NewReader("foo(X). bar.")
+	l := NewScannerList(iotest.OneByteReader(r))
+	var got []string
+	for ; l.Value.Type != FullStop; l = l.Next() {
+		got = append(got, l.Value.Content)
+	}
+	if strings.Join(got, " ") != "foo ( X )" {
+		t.Errorf("Wrong lexemes: %q", got)
+	}
+	if r.Len() != len("bar.") { // only one character of lookahead
+		t.Errorf("Read too far ahead: %d bytes left", r.Len())
+	}
+
+	for l.Value.Type != EOF {
+		l = l.Next()
+	}
+	if l.Next().Value.Type != EOF {
+		t.Errorf("Lexemes after EOF")
+	}
+}
@@ -58,7 +58,9 @@ import . "github.com/mndrix/golog/util"
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
 	"strings"
@@ -105,6 +107,48 @@ type Machine interface {
 	Consult(interface{}) Machine
 	ProveAll(interface{}) []Bindings
 
+	// Solve returns an iterator over the solutions to a goal.  Unlike
+	// ProveAll, solutions are found one at a time as they're requested.
+	// This works for goals with infinitely many solutions too.
+	Solve(interface{}) Solutions
+
+	// Prepare reads a goal once so that it can be proven many times
+	// with different parameters.  See Query.
+	Prepare(string) (Query, error)
+
+	// These are like CanProve, ProveAll and Solve but stop looking for
+	// solutions once the context is done.  Errors are returned instead
+	// of causing panics.
+	CanProveContext(context.Context, interface{}) (bool, error)
+	ProveAllContext(context.Context, interface{}) ([]Bindings, error)
+	SolveContext(context.Context, interface{}) Solutions
+
+	// SetMaxSteps returns a machine like this one which throws
+	// resource_error(steps) when proving a goal takes more than the
+	// given number of steps.  0 means no limit.
+	SetMaxSteps(int64) Machine
+
+	// SetMaxDepth returns a machine like this one which throws
+	// resource_error(depth) when its conjunction or disjunction stack
+	// grows deeper than the given size.  0 means no limit.
+	SetMaxDepth(int) Machine
+
+	// ConsultModule is like Consult but loads code into the named
+	// module instead of the user module.  Goals can call the module's
+	// predicates as Name:Goal or after use_module(Name).
+	ConsultModule(string, interface{}) Machine
+
+	// AttachInput returns a machine like this one with an extra input
+	// stream which reads from r.  Prolog code refers to the stream by
+	// alias.  If another stream already has that alias, the new stream
+	// replaces it.  For example, AttachInput("user_input", r) makes
+	// Prolog read from r instead of standard input.
+	AttachInput(alias string, r io.Reader) Machine
+
+	// AttachOutput is like AttachInput but for an output stream which
+	// writes to w.  It's handy for capturing output in a bytes.Buffer.
+	AttachOutput(alias string, w io.Writer) Machine
+
 	String() string
 
 	// Bindings returns the machine's most current variable bindings.
@@ -116,6 +160,15 @@ type Machine interface {
 	// bindings
 	SetBindings(Bindings) Machine
 
+	// Database returns the database of Prolog clauses in the
+	// machine's user module.
+	Database() Database
+
+	// SetDatabase returns a new machine like this one but with the
+	// given database for the user module.  Changes to the database
+	// survive backtracking.
+	SetDatabase(Database) Machine
+
 	// PushConj returns a machine like this one but with an extra term
 	// on front of the conjunction stack
 	PushConj(Callable) Machine
@@ -166,28 +219,45 @@ type Machine interface {
 	// been registered replaces the predicate implementation.
 	RegisterForeign(map[string]ForeignPredicate) Machine
 
+	// RegisterFunc registers an ordinary Go function to implement a
+	// Golog predicate.  Arguments and results are converted between
+	// Go values and terms automatically.  See machine.RegisterFunc.
+	RegisterFunc(string, interface{}) Machine
+
 	// Step advances the machine one "step" (implementation dependent).
 	// It produces a new machine which can take the next step.  It might
 	// produce a proof by giving some variable bindings.  When the machine
-	// has done as much work as it can do, it returns err=MachineDone
+	// has done as much work as it can do, it returns err=MachineDone.
+	// If the goal throws an exception which isn't caught, it returns
+	// an *Exception error.
 	Step() (Machine, Bindings, error)
 }
 
 // Golog allows Prolog predicates to be defined in Go.  The foreign predicate
 // mechanism is implemented via functions whose type is ForeignPredicate.
+// A foreign predicate with more than one solution can return
+// ForeignChoices or produce its solutions lazily with ForeignIterate.
 type ForeignPredicate func(Machine, []Term) ForeignReturn
 
 const smallThreshold = 4
 
 type machine struct {
-	db    Database
-	env   Bindings
-	disjs ps.List // of ChoicePoint
-	conjs ps.List // of Term
+	modules ps.Map // module name => *module
+	env     Bindings
+	disjs   ps.List // of ChoicePoint
+	conjs   ps.List // of Term
 
 	smallForeign [smallThreshold]ps.Map // arity => functor => ForeignPredicate
 	largeForeign ps.Map                 // predicate indicator => ForeignPredicate
 
+	loaded  ps.Map          // absolute file name => module it defines (or "")
+	streams *streams        // open streams, current input and output
+	ops     *read.Operators // operator table for reading and writing terms
+
+	maxSteps int64 // 0 means no limit
+	maxDepth int   // 0 means no limit
+	run      *run  // resources used by the current proof, if tracked
+
 	help map[string]string
 }
 
@@ -200,40 +270,138 @@ func NewMachine() Machine {
 	return NewBlankMachine().
 		Consult(prelude.Prelude).
 		RegisterForeign(map[string]ForeignPredicate{
-			"!/0":             BuiltinCut,
-			"$cut_to/1":       BuiltinCutTo,
-			",/2":             BuiltinComma,
-			"->/2":            BuiltinIfThen,
-			";/2":             BuiltinSemicolon,
-			"=/2":             BuiltinUnify,
-			"=:=/2":           BuiltinNumericEquals,
-			"==/2":            BuiltinTermEquals,
-			"\\==/2":          BuiltinTermNotEquals,
-			"@</2":            BuiltinTermLess,
-			"@=</2":           BuiltinTermLessEquals,
-			"@>/2":            BuiltinTermGreater,
-			"@>=/2":           BuiltinTermGreaterEquals,
-			`\+/1`:            BuiltinNot,
-			"atom_codes/2":    BuiltinAtomCodes2,
-			"atom_number/2":   BuiltinAtomNumber2,
-			"call/1":          BuiltinCall,
-			"call/2":          BuiltinCall,
-			"call/3":          BuiltinCall,
-			"call/4":          BuiltinCall,
-			"call/5":          BuiltinCall,
-			"call/6":          BuiltinCall,
-			"downcase_atom/2": BuiltinDowncaseAtom2,
-			"fail/0":          BuiltinFail,
-			"findall/3":       BuiltinFindall3,
-			"ground/1":        BuiltinGround,
-			"is/2":            BuiltinIs,
-			"listing/0":       BuiltinListing0,
-			"msort/2":         BuiltinMsort2,
-			"printf/1":        BuiltinPrintf,
-			"printf/2":        BuiltinPrintf,
-			"printf/3":        BuiltinPrintf,
-			"succ/2":          BuiltinSucc2,
-			"var/1":           BuiltinVar1,
+			"!/0":                  BuiltinCut,
+			"$cut_to/1":            BuiltinCutTo,
+			"$dcg_call/3":          BuiltinDcgCall,
+			"$erase/1":             BuiltinErase,
+			",/2":                  BuiltinComma,
+			"->/2":                 BuiltinIfThen,
+			";/2":                  BuiltinSemicolon,
+			"=/2":                  BuiltinUnify,
+			"=../2":                BuiltinUniv,
+			"</2":                  BuiltinNumericLess,
+			"=</2":                 BuiltinNumericLessEquals,
+			"=:=/2":                BuiltinNumericEquals,
+			"=\\=/2":               BuiltinNumericNotEquals,
+			">/2":                  BuiltinNumericGreater,
+			">=/2":                 BuiltinNumericGreaterEquals,
+			"==/2":                 BuiltinTermEquals,
+			"\\==/2":               BuiltinTermNotEquals,
+			"@</2":                 BuiltinTermLess,
+			"@=</2":                BuiltinTermLessEquals,
+			"@>/2":                 BuiltinTermGreater,
+			"@>=/2":                BuiltinTermGreaterEquals,
+			`\+/1`:                 BuiltinNot,
+			"abolish/1":            BuiltinAbolish1,
+			"arg/3":                BuiltinArg3,
+			"assert/1":             BuiltinAssertz1,
+			"asserta/1":            BuiltinAsserta1,
+			"assertz/1":            BuiltinAssertz1,
+			"at_end_of_stream/0":   BuiltinAtEndOfStream,
+			"at_end_of_stream/1":   BuiltinAtEndOfStream,
+			"atom/1":               BuiltinAtom1,
+			"atom_chars/2":         BuiltinAtomChars2,
+			"atom_codes/2":         BuiltinAtomCodes2,
+			"atom_concat/3":        BuiltinAtomConcat3,
+			"atom_length/2":        BuiltinAtomLength2,
+			"atom_number/2":        BuiltinAtomNumber2,
+			"atomic/1":             BuiltinAtomic1,
+			"atomic_list_concat/2": BuiltinAtomicListConcat,
+			"atomic_list_concat/3": BuiltinAtomicListConcat,
+			"bagof/3":              BuiltinBagof3,
+			"$catch_exit/1":        BuiltinCatchExit,
+			"call/1":               BuiltinCall,
+			"call/2":               BuiltinCall,
+			"call/3":               BuiltinCall,
+			"call/4":               BuiltinCall,
+			"call/5":               BuiltinCall,
+			"call/6":               BuiltinCall,
+			"call/7":               BuiltinCall,
+			"call/8":               BuiltinCall,
+			"callable/1":           BuiltinCallable1,
+			"catch/3":              BuiltinCatch3,
+			"char_code/2":          BuiltinCharCode2,
+			"close/1":              BuiltinClose,
+			"close/2":              BuiltinClose,
+			"compound/1":           BuiltinCompound1,
+			"copy_term/2":          BuiltinCopyTerm2,
+			"current_input/1":      BuiltinCurrentInput1,
+			"current_op/3":         BuiltinCurrentOp3,
+			"current_output/1":     BuiltinCurrentOutput1,
+			"dcg_translate_rule/2": BuiltinDcgTranslateRule2,
+			"downcase_atom/2":      BuiltinDowncaseAtom2,
+			"fail/0":               BuiltinFail,
+			"findall/3":            BuiltinFindall3,
+			"float/1":              BuiltinFloat1,
+			"flush_output/0":       BuiltinFlushOutput,
+			"flush_output/1":       BuiltinFlushOutput,
+			"format/1":             BuiltinFormat,
+			"format/2":             BuiltinFormat,
+			"format/3":             BuiltinFormat,
+			"functor/3":            BuiltinFunctor3,
+			"get_byte/1":           BuiltinGetByte,
+			"get_byte/2":           BuiltinGetByte,
+			"get_char/1":           BuiltinGetChar,
+			"get_char/2":           BuiltinGetChar,
+			"get_code/1":           BuiltinGetCode,
+			"get_code/2":           BuiltinGetCode,
+			"ground/1":             BuiltinGround,
+			"integer/1":            BuiltinInteger1,
+			"is/2":                 BuiltinIs,
+			"is_list/1":            BuiltinIsList1,
+			"listing/0":            BuiltinListing0,
+			"msort/2":              BuiltinMsort2,
+			"nl/0":                 BuiltinNl,
+			"nl/1":                 BuiltinNl,
+			"nonvar/1":             BuiltinNonvar1,
+			"number/1":             BuiltinNumber1,
+			"number_chars/2":       BuiltinNumberChars2,
+			"number_codes/2":       BuiltinNumberCodes2,
+			"op/3":                 BuiltinOp3,
+			"open/3":               BuiltinOpen,
+			"open/4":               BuiltinOpen,
+			"peek_byte/1":          BuiltinPeekByte,
+			"peek_byte/2":          BuiltinPeekByte,
+			"peek_char/1":          BuiltinPeekChar,
+			"peek_char/2":          BuiltinPeekChar,
+			"peek_code/1":          BuiltinPeekCode,
+			"peek_code/2":          BuiltinPeekCode,
+			"print/1":              BuiltinWriteq,
+			"print/2":              BuiltinWriteq,
+			"put_byte/1":           BuiltinPutByte,
+			"put_byte/2":           BuiltinPutByte,
+			"put_char/1":           BuiltinPutChar,
+			"put_char/2":           BuiltinPutChar,
+			"put_code/1":           BuiltinPutCode,
+			"put_code/2":           BuiltinPutCode,
+			"rational/1":           BuiltinRational1,
+			"read/1":               BuiltinRead,
+			"read/2":               BuiltinRead,
+			"read_term/2":          BuiltinReadTerm,
+			"read_term/3":          BuiltinReadTerm,
+			"retract/1":            BuiltinRetract1,
+			"retractall/1":         BuiltinRetractall1,
+			"set_input/1":          BuiltinSetInput1,
+			"set_output/1":         BuiltinSetOutput1,
+			"setof/3":              BuiltinSetof3,
+			"stream_property/2":    BuiltinStreamProperty2,
+			"sub_atom/5":           BuiltinSubAtom5,
+			"succ/2":               BuiltinSucc2,
+			"term_to_atom/2":       BuiltinTermToAtom2,
+			"term_variables/2":     BuiltinTermVariables2,
+			"throw/1":              BuiltinThrow1,
+			"upcase_atom/2":        BuiltinUpcaseAtom2,
+			"use_module/1":         BuiltinUseModule,
+			"use_module/2":         BuiltinUseModule,
+			"var/1":                BuiltinVar1,
+			"write/1":              BuiltinWrite,
+			"write/2":              BuiltinWrite,
+			"write_canonical/1":    BuiltinWriteCanonical,
+			"write_canonical/2":    BuiltinWriteCanonical,
+			"write_term/2":         BuiltinWriteTerm,
+			"write_term/3":         BuiltinWriteTerm,
+			"writeq/1":             BuiltinWriteq,
+			"writeq/2":             BuiltinWriteq,
 		})
 }
 
@@ -241,10 +409,13 @@ func NewMachine() Machine {
 // standard library (prelude)
 func NewBlankMachine() Machine {
 	var m machine
-	m.db = NewDatabase()
+	m.modules = ps.NewMap().Set(userModule, newModule(userModule))
 	m.env = NewBindings()
 	m.disjs = ps.NewList()
 	m.conjs = ps.NewList()
+	m.loaded = ps.NewMap()
+	m.streams = standardStreams()
+	m.ops = read.DefaultOperators()
 
 	for i := 0; i < smallThreshold; i++ {
 		m.smallForeign[i] = ps.NewMap()
@@ -258,19 +429,6 @@ func (m *machine) clone() *machine {
 	return &m1
 }
 
-func (m *machine) Consult(text interface{}) Machine {
-	terms := read.TermAll_(text)
-	m1 := m.clone()
-	for _, t := range terms {
-		if IsDirective(t) {
-			// ignore all directives, for now
-			continue
-		}
-		m1.db = m1.db.Assertz(t)
-	}
-	return m1
-}
-
 func (m *machine) RegisterForeign(fs map[string]ForeignPredicate) Machine {
 	m1 := m.clone()
 	for indicator, f := range fs {
@@ -306,42 +464,25 @@ func (m *machine) String() string {
 // in the database.  Once a solution is found, it abandons other
 // solutions (like once/1).
 func (self *machine) CanProve(goal interface{}) bool {
-	var answer Bindings
-	var err error
+	solutions := self.Solve(goal)
+	defer solutions.Close()
 
-	goalTerm := self.toGoal(goal)
-	m := self.PushConj(goalTerm)
-	for {
-		m, answer, err = m.Step()
-		if err == MachineDone {
-			return answer != nil
-		}
-		MaybePanic(err)
-		if answer != nil {
-			return true
-		}
-	}
+	found := solutions.Next()
+	MaybePanic(solutions.Err())
+	return found
 }
 
+// ProveAll returns all solutions to goal.  If proving goal throws an
+// exception which isn't caught, ProveAll (like CanProve) panics with
+// an *Exception error describing it.
 func (self *machine) ProveAll(goal interface{}) []Bindings {
-	var answer Bindings
-	var err error
 	answers := make([]Bindings, 0)
 
-	goalTerm := self.toGoal(goal)
-	vars := Variables(goalTerm) // preserve incoming human-readable names
-	m := self.PushConj(goalTerm)
-	for {
-		m, answer, err = m.Step()
-		if err == MachineDone {
-			break
-		}
-		MaybePanic(err)
-		if answer != nil {
-			answers = append(answers, answer.WithNames(vars))
-		}
+	solutions := self.Solve(goal)
+	for solutions.Next() {
+		answers = append(answers, solutions.Bindings())
 	}
-
+	MaybePanic(solutions.Err())
 	return answers
 }
 
@@ -355,6 +496,16 @@ func (self *machine) Step() (Machine, Bindings, error) {
 	var cp ChoicePoint
 
 	//Debugf("stepping...\n%s\n", self)
+	if self.run != nil || self.maxDepth > 0 {
+		ball, err := self.checkLimits()
+		if err != nil {
+			return nil, nil, err
+		}
+		if ball != nil {
+			m, err = self.throw(ball)
+			return m, nil, err
+		}
+	}
 	if false { // for debugging. commenting out needs import changes
 		_, _ = bufio.NewReader(os.Stdin).ReadString('\n')
 	}
@@ -377,39 +528,52 @@ func (self *machine) Step() (Machine, Bindings, error) {
 		functor = goal.Name()
 	}
 
+	// which module should prove this goal?
+	ctx := userModule
+	if goal.Indicator() == ":/2" {
+		var ball Term
+		goal = goal.ReplaceVariables(m.Bindings()).(Callable)
+		ctx, goal, ball = unqualify(goal)
+		if ball != nil {
+			m, err = m.(*machine).throw(ball)
+			return m, nil, err
+		}
+		switch goal.Indicator() {
+		case "true/0":
+			return m, nil, nil
+		case ",/2", ";/2", "->/2":
+			return m.PushConj(qualify(ctx, goal).(Callable)), nil, nil
+		}
+	}
+
 	// are we proving a foreign predicate?
 	f, ok := m.(*machine).lookupForeign(goal)
 	if ok { // foreign predicate
+		if ctx != userModule { // foreign meta predicates are declared in user
+			spec, ok := m.(*machine).module(userModule).metaSpec(goal)
+			if ok {
+				goal = qualifyMeta(ctx, goal, spec)
+			}
+		}
 		args := m.(*machine).resolveAllArguments(goal)
 		Debugf("  running foreign predicate %s with %s\n", goal, args)
-		ret := f(m, args)
-		switch x := ret.(type) {
-		case *foreignTrue:
-			return m, nil, nil
-		case *foreignFail:
-			// do nothing. continue to iterate disjunctions below
-		case *machine:
-			return x, nil, nil
-		case *foreignUnify:
-			terms := []Term(*x) // guaranteed even number of elements
-			env := m.Bindings()
-			for i := 0; i < len(terms); i += 2 {
-				env, err = terms[i].Unify(env, terms[i+1])
-				if err == CantUnify {
-					env = nil
-					break
-				}
-				MaybePanic(err)
-			}
-			if env != nil {
-				return m.SetBindings(env), nil, nil
-			}
+		m1, err := m.(*machine).foreignResult(f(m, args))
+		if err != CantUnify {
+			return m1, nil, err
 		}
 	} else { // user-defined predicate, push all its disjunctions
 		goal = goal.ReplaceVariables(m.Bindings()).(Callable)
 		Debugf("  running user-defined predicate %s\n", goal)
-		clauses, err := m.(*machine).db.Candidates(goal)
-		MaybePanic(err)
+		mod, clauses, err := m.(*machine).lookupPredicate(ctx, goal)
+		if err != nil {
+			m, err = m.(*machine).throw(existenceError(ctx, goal))
+			return m, nil, err
+		}
+		if mod.name != ctx { // meta arguments belong to the caller
+			if spec, ok := mod.metaSpec(goal); ok {
+				goal = qualifyMeta(ctx, goal, spec)
+			}
+		}
 		m = m.DemandCutBarrier()
 		for i := len(clauses) - 1; i >= 0; i-- {
 			clause := clauses[i]
@@ -433,27 +597,145 @@ func (self *machine) Step() (Machine, Bindings, error) {
 		switch err {
 		case nil:
 			Debugf("  ... followed\n")
-			return mTmp, nil, nil
+			// database changes aren't undone by backtracking
+			return mTmp.(*machine).withSideEffectsOf(m), nil, nil
 		case CantUnify:
 			Debugf("  ... couldn't unify\n")
 			continue
 		case CutBarrierFails:
 			Debugf("  ... skipping over cut barrier\n")
 			continue
+		case CatchChoicePointFails:
+			Debugf("  ... skipping over catch/3\n")
+			continue
+		}
+		if _, ok := err.(*Exception); ok { // thrown by a foreign iterator
+			return nil, nil, err
 		}
 		MaybePanic(err)
 	}
 }
 
+// foreignResult returns a machine updated according to the value
+// returned by a foreign predicate.  The error is CantUnify if the
+// predicate failed.
+func (m *machine) foreignResult(ret ForeignReturn) (Machine, error) {
+	switch x := ret.(type) {
+	case *foreignTrue:
+		return m, nil
+	case *foreignFail:
+		return nil, CantUnify
+	case *machine:
+		return x, nil
+	case *foreignUnify:
+		terms := []Term(*x) // guaranteed even number of elements
+		env := m.Bindings()
+		for i := 0; i < len(terms); i += 2 {
+			var err error
+			env, err = terms[i].Unify(env, terms[i+1])
+			if err == CantUnify {
+				return nil, err
+			}
+			MaybePanic(err)
+		}
+		return m.SetBindings(env), nil
+	case *foreignThrow:
+		return m.throw(x.ball)
+	case *foreignIterate:
+		cp := &foreignCP{machine: m, solutions: &foreignSolutions{iter: x}}
+		return cp.Follow()
+	case *foreignChoices:
+		cp := &choicesCP{machine: m, rets: *x}
+		return cp.Follow()
+	}
+	panic(fmt.Sprintf("Unexpected foreign predicate return value: %#v", ret))
+}
+
+// throw unwinds the machine to the most recent, active catch/3 whose
+// catcher unifies with ball.  If no such catch/3 exists, the exception
+// is returned as an *Exception error.
+func (m *machine) throw(ball Term) (Machine, error) {
+	ball = RenameVariables(ball)
+	Debugf("  throwing %s\n", ball)
+
+	// a catch/3 is active while its goal is still on the conjunction stack
+	active := make(map[int64]bool)
+	m.conjs.ForEach(func(v interface{}) {
+		if id, ok := catchExitID(v.(Callable)); ok {
+			active[id] = true
+		}
+	})
+
+	// search for the innermost catch/3 that can handle this ball
+	ds := m.disjs
+	for !ds.IsNil() {
+		cp, ok := ds.Head().(*catchCP)
+		if ok && active[cp.id] {
+			if m1, ok := cp.recover(ball); ok {
+				closeForeign(m.disjs, ds)
+				// database changes aren't undone by exceptions
+				return m1.(*machine).withSideEffectsOf(m), nil
+			}
+		}
+		ds = ds.Tail()
+	}
+
+	m.closeIterators()
+	return nil, NewException(ball)
+}
+
+// closeIterators closes the iterators of all foreign predicate choice
+// points in m.  Call it when m is abandoned before finding all its
+// solutions.
+func (m *machine) closeIterators() {
+	closeForeign(m.disjs, ps.NewList())
+}
+
+// closeForeign closes the iterators of foreign predicate choice points
+// in ds which are stacked above stop.  Call it when those choice points
+// are abandoned.
+func closeForeign(ds, stop ps.List) {
+	for ; !ds.IsNil() && ds != stop; ds = ds.Tail() {
+		if cp, ok := ds.Head().(*foreignCP); ok {
+			cp.solutions.iter.close()
+		}
+	}
+}
+
+// catchExitID returns the catch/3 identifier if goal marks the end
+// of a catch/3 goal.
+func catchExitID(goal Callable) (int64, bool) {
+	if goal.Arity() == 1 && goal.Name() == "$catch_exit" {
+		return goal.Arguments()[0].(*Integer).Value().Int64(), true
+	}
+	return 0, false
+}
+
+// existenceError builds the exception raised when calling an
+// undefined predicate from module ctx
+func existenceError(ctx string, goal Callable) Term {
+	name := NewAtom(goal.Name())
+	arity := NewInt64(int64(goal.Arity()))
+	var pi Term = NewCallable("/", name, arity)
+	if ctx != userModule {
+		pi = NewCallable(":", NewAtom(ctx), pi)
+	}
+	return ExistenceError("procedure", pi)
+}
+
 func (m *machine) lookupForeign(goal Callable) (ForeignPredicate, bool) {
+	return m.lookupForeignIndicator(goal.Name(), goal.Arity())
+}
+
+func (m *machine) lookupForeignIndicator(name string, arity int) (ForeignPredicate, bool) {
 	var f interface{}
 	var ok bool
 
-	arity := goal.Arity()
 	if arity < smallThreshold {
-		f, ok = m.smallForeign[arity].Lookup(goal.Name())
+		f, ok = m.smallForeign[arity].Lookup(name)
 	} else {
-		f, ok = m.largeForeign.Lookup(goal.Indicator())
+		indicator := fmt.Sprintf("%s/%d", name, arity)
+		f, ok = m.largeForeign.Lookup(indicator)
 	}
 
 	if ok {
@@ -496,7 +778,10 @@ func (m *machine) resolveAllArguments(goal Callable) []Term {
 }
 
 func (m *machine) readTerm(src interface{}) Term {
-	return read.Term_(src)
+	r, err := read.NewTermReader(src)
+	MaybePanic(err)
+	r.SetOperators(m.ops)
+	return r.Next_()
 }
 
 func (m *machine) Bindings() Bindings {
@@ -509,6 +794,14 @@ func (m *machine) SetBindings(env Bindings) Machine {
 	return m1
 }
 
+func (m *machine) Database() Database {
+	return m.moduleDatabase(userModule)
+}
+
+func (m *machine) SetDatabase(db Database) Machine {
+	return m.setModuleDatabase(userModule, db)
+}
+
 func (m *machine) PushConj(t Callable) Machine {
 	// change all !/0 goals into '$cut_to'(RecentBarrierId) goals
 	barrierID, err := m.MostRecentCutBarrier()
@@ -561,6 +854,20 @@ func (m *machine) PopDisj() (ChoicePoint, Machine, error) {
 	return cp, m1, nil
 }
 
+// hasChoices returns true if backtracking into m might find another
+// solution.  Backtracking into a cut barrier or a catch choice point
+// just fails, so they don't count.
+func (m *machine) hasChoices() bool {
+	for ds := m.disjs; !ds.IsNil(); ds = ds.Tail() {
+		switch ds.Head().(type) {
+		case *barrierCP, *catchCP:
+			continue
+		}
+		return true
+	}
+	return false
+}
+
 func (m *machine) DemandCutBarrier() Machine {
 	// is the top choice point already a cut barrier?
 	if !m.disjs.IsNil() {
@@ -602,6 +909,7 @@ func (m *machine) CutTo(want int64) Machine {
 
 		found, ok := BarrierId(ds.Head().(ChoicePoint))
 		if ok && found == want {
+			closeForeign(m.disjs, ds)
 			m1 := m.clone()
 			m1.disjs = ds
 			return m1
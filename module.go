@@ -0,0 +1,388 @@
+package golog
+
+// Golog's module system.  Each module has its own database of clauses.
+// A goal can be qualified with a module name (like lists:append(A,B,C))
+// to say which module should prove it.  Unqualified goals belong to the
+// user module.
+//
+// Clause bodies in modules other than user are qualified while they're
+// loaded so that their goals are proven in the right module.  When a goal
+// calls a meta-predicate from another module, its meta arguments are
+// qualified with the caller's module.  See qualify and qualifyMeta.
+
+import (
+	"fmt"
+
+	"github.com/mndrix/golog/lex"
+	. "github.com/mndrix/golog/term"
+	"github.com/mndrix/ps"
+)
+
+// userModule is the name of the module in which unqualified goals
+// are proven and into which Consult loads code
+const userModule = "user"
+
+// module is a named collection of predicates.  Like everything else in
+// a machine, modules are immutable.
+type module struct {
+	name    string
+	db      Database
+	exports ps.Map // predicate indicator => true
+	imports ps.Map // predicate indicator => name of the exporting module
+	metas   ps.Map // predicate indicator => meta_predicate specification
+}
+
+func newModule(name string) *module {
+	return &module{
+		name:    name,
+		db:      NewDatabase(),
+		exports: ps.NewMap(),
+		imports: ps.NewMap(),
+		metas:   ps.NewMap(),
+	}
+}
+
+func (mod *module) clone() *module {
+	mod1 := *mod
+	return &mod1
+}
+
+// module returns the module with the given name.  If it doesn't exist
+// yet, an empty module is returned.
+func (m *machine) module(name string) *module {
+	if mod, ok := m.modules.Lookup(name); ok {
+		return mod.(*module)
+	}
+	return newModule(name)
+}
+
+// setModule returns a new machine with mod replacing any module of the
+// same name
+func (m *machine) setModule(mod *module) *machine {
+	m1 := m.clone()
+	m1.modules = m.modules.Set(mod.name, mod)
+	return m1
+}
+
+// moduleDatabase returns the database of the named module
+func (m *machine) moduleDatabase(name string) Database {
+	return m.module(name).db
+}
+
+// setModuleDatabase returns a new machine in which the named module
+// has the given database
+func (m *machine) setModuleDatabase(name string, db Database) *machine {
+	mod := m.module(name).clone()
+	mod.db = db
+	return m.setModule(mod)
+}
+
+// withSideEffectsOf returns a new machine like m but with the modules
+// (and therefore databases) and streams of other.  It's how database
+// changes and opened or closed streams survive backtracking.
+func (m *machine) withSideEffectsOf(other Machine) *machine {
+	m1 := m.clone()
+	m1.modules = other.(*machine).modules
+	m1.streams = other.(*machine).streams
+	m1.ops = other.(*machine).ops
+	return m1
+}
+
+// lookupPredicate finds the module which defines the predicate called
+// by goal when goal is proven in module ctx.  It searches ctx, then
+// predicates that ctx imported, then the user module.  Returns the
+// defining module and the clauses which might match goal.
+func (m *machine) lookupPredicate(ctx string, goal Callable) (*module, []Term, error) {
+	mod := m.module(ctx)
+	if clauses, err := mod.db.Candidates(goal); err == nil {
+		return mod, clauses, nil
+	}
+	if from, ok := mod.imports.Lookup(goal.Indicator()); ok {
+		mod := m.module(from.(string))
+		clauses, err := mod.db.Candidates(goal)
+		return mod, clauses, err
+	}
+	if ctx != userModule {
+		return m.lookupPredicate(userModule, goal)
+	}
+	return nil, nil, fmt.Errorf("Undefined predicate: %s", goal.Indicator())
+}
+
+// importModule makes predicates exported by module from visible in
+// module into.  If only is not nil, it lists the predicate indicators
+// to import.  Otherwise, all exported predicates are imported.
+func (m *machine) importModule(into, from string, only []string) *machine {
+	mod := m.module(into).clone()
+	if only == nil {
+		m.module(from).exports.ForEach(func(indicator string, _ interface{}) {
+			mod.imports = mod.imports.Set(indicator, from)
+		})
+	}
+	for _, indicator := range only {
+		mod.imports = mod.imports.Set(indicator, from)
+	}
+	return m.setModule(mod)
+}
+
+// metaSpec returns the meta_predicate specification for goal, if the
+// predicate it calls was declared in module mod.
+func (mod *module) metaSpec(goal Callable) (Callable, bool) {
+	if mod.metas.IsNil() {
+		return nil, false
+	}
+	spec, ok := mod.metas.Lookup(goal.Indicator())
+	if !ok {
+		return nil, false
+	}
+	return spec.(Callable), true
+}
+
+// qualify attaches module name ctx to goal so that it's proven in that
+// module.  Control constructs are qualified argument by argument so that
+// cuts inside them keep working.
+func qualify(ctx string, goal Term) Term {
+	if IsVariable(goal) {
+		return NewCallable(":", NewAtom(ctx), goal)
+	}
+	if !IsCallable(goal) {
+		return goal // call/1 will complain later
+	}
+
+	x := goal.(Callable)
+	switch x.Indicator() {
+	case ",/2", ";/2", "->/2":
+		args := x.Arguments()
+		return NewCallable(x.Name(), qualify(ctx, args[0]), qualify(ctx, args[1]))
+	case "!/0", "true/0", ":/2":
+		return goal
+	}
+	return NewCallable(":", NewAtom(ctx), goal)
+}
+
+// qualifyMeta qualifies the meta arguments of goal with module ctx.
+// Which arguments are meta arguments is described by spec, a
+// meta_predicate specification like maplist(2, ?, ?).
+func qualifyMeta(ctx string, goal Callable, spec Callable) Callable {
+	module := NewAtom(ctx)
+	specArgs := spec.Arguments()
+	args := make([]Term, goal.Arity())
+	for i, arg := range goal.Arguments() {
+		if isMetaArgument(specArgs[i]) {
+			arg = NewCallable(":", module, arg)
+		}
+		args[i] = arg
+	}
+	return NewCallable(goal.Name(), args...)
+}
+
+// isMetaArgument returns true if t is a meta_predicate argument
+// specifier which needs module qualification
+func isMetaArgument(t Term) bool {
+	if IsInteger(t) {
+		return true
+	}
+	if IsAtom(t) {
+		switch t.(*Atom).Name() {
+		case ":", "^", "//":
+			return true
+		}
+	}
+	return false
+}
+
+// stripModule removes module qualification from t.  It returns the
+// innermost module name (or ctx if t isn't qualified) and the
+// unqualified term.
+func stripModule(ctx string, t Term) (string, Term) {
+	for t.Indicator() == ":/2" {
+		args := t.(Callable).Arguments()
+		if !IsAtom(args[0]) {
+			break
+		}
+		ctx = args[0].(*Atom).Name()
+		t = args[1]
+	}
+	return ctx, t
+}
+
+// unqualify removes module qualification from a goal before proving
+// it.  It returns the module in which to prove the goal.  If the
+// qualification is malformed, it returns an ISO error term instead.
+func unqualify(goal Callable) (string, Callable, Term) {
+	ctx, inner := stripModule(userModule, goal)
+	if IsVariable(inner) {
+		return "", nil, InstantiationError()
+	}
+	if !IsCallable(inner) {
+		return "", nil, TypeError("callable", inner)
+	}
+	if inner.Indicator() == ":/2" { // module name isn't an atom
+		module := inner.(Callable).Arguments()[0]
+		if IsVariable(module) {
+			return "", nil, InstantiationError()
+		}
+		return "", nil, TypeError("atom", module)
+	}
+	return ctx, inner.(Callable), nil
+}
+
+// addArguments returns a goal like t but with extra arguments appended.
+// If t is module qualified, the arguments are added to the qualified goal.
+func addArguments(t Callable, extra ...Term) Callable {
+	if len(extra) == 0 {
+		return t
+	}
+	if t.Indicator() == ":/2" {
+		args := t.Arguments()
+		if IsCallable(args[1]) {
+			inner := addArguments(args[1].(Callable), extra...)
+			return NewCallable(":", args[0], inner)
+		}
+	}
+	args := make([]Term, 0, t.Arity()+len(extra))
+	args = append(args, t.Arguments()...)
+	args = append(args, extra...)
+	return NewCallable(t.Name(), args...)
+}
+
+func (m *machine) ConsultModule(name string, text interface{}) Machine {
+	m1 := m.setModule(m.module(name))
+	m1, _ = m1.consult(name, text)
+	return m1
+}
+
+// defineModule implements the module/2 directive.  It starts a module
+// with the given name and export list.  Besides predicate indicators,
+// the export list may contain op/3 terms which are defined immediately.
+func (m *machine) defineModule(args []Term) (*machine, string, error) {
+	name, exports := args[0], args[1]
+	if IsVariable(name) {
+		return m, "", NewException(InstantiationError())
+	}
+	if !IsAtom(name) {
+		return m, "", NewException(TypeError("atom", name))
+	}
+	if ret, ok := checkList(exports); !ok {
+		return m, "", NewException(ret.(*foreignThrow).ball)
+	}
+
+	m1 := m
+	mod := m.module(name.(*Atom).Name()).clone()
+	for _, x := range ListToSlice(exports) {
+		if x.Indicator() == "op/3" {
+			var err error
+			m1, err = m1.defineOp(x.(Callable).Arguments())
+			if err != nil {
+				return m, "", err
+			}
+			continue
+		}
+		indicator, err := indicatorOf(x)
+		if err != nil {
+			return m, "", err
+		}
+		mod.exports = mod.exports.Set(indicator, true)
+	}
+	return m1.setModule(mod), mod.name, nil
+}
+
+// export implements the export/1 directive which adds predicates to the
+// export list of module ctx
+func (m *machine) export(ctx string, pis Term) (*machine, error) {
+	mod := m.module(ctx).clone()
+	for _, pi := range predicateIndicators(pis) {
+		indicator, err := indicatorOf(pi)
+		if err != nil {
+			return m, err
+		}
+		mod.exports = mod.exports.Set(indicator, true)
+	}
+	return m.setModule(mod), nil
+}
+
+// metaPredicate implements the meta_predicate/1 directive.  Each
+// specification is stored with the module defining the predicate.
+func (m *machine) metaPredicate(ctx string, specs Term) (*machine, error) {
+	m1 := m
+	for _, spec := range predicateIndicators(specs) {
+		module, spec := stripModule(ctx, spec)
+		if IsVariable(spec) {
+			return m, NewException(InstantiationError())
+		}
+		if !IsCompound(spec) {
+			return m, NewException(TypeError("compound", spec))
+		}
+		mod := m1.module(module).clone()
+		mod.metas = mod.metas.Set(spec.Indicator(), spec)
+		m1 = m1.setModule(mod)
+	}
+	return m1, nil
+}
+
+// useModule implements use_module/1 and use_module/2.  spec names a
+// module that's already loaded (like one added with ConsultModule),
+// library(Name) or a module file.  The module's exported predicates
+// (or just those listed in imports, if it's not nil) become visible
+// in module ctx.
+func (m *machine) useModule(pos lex.Position, ctx string, spec, imports Term) (*machine, error) {
+	var only []string
+	if imports != nil {
+		if ret, ok := checkList(imports); !ok {
+			return m, NewException(ret.(*foreignThrow).ball)
+		}
+		only = make([]string, 0)
+		for _, pi := range ListToSlice(imports) {
+			indicator, err := indicatorOf(pi)
+			if err != nil {
+				return m, err
+			}
+			only = append(only, indicator)
+		}
+	}
+
+	m1 := m
+	var name string
+	switch {
+	case IsVariable(spec):
+		return m, NewException(InstantiationError())
+	case spec.Indicator() == "library/1":
+		lib := spec.(Callable).Arguments()[0]
+		if !IsAtom(lib) {
+			return m, NewException(DomainError("source_sink", spec))
+		}
+		name = lib.(*Atom).Name()
+		if !m.hasModule(name) {
+			// many libraries from other Prologs are builtin to Golog,
+			// so there's nothing to import
+			return m, nil
+		}
+	case IsAtom(spec) && m.hasModule(spec.(*Atom).Name()):
+		name = spec.(*Atom).Name()
+	default:
+		var err error
+		m1, name, err = m.loadFile(pos, spec)
+		if err != nil {
+			return m, err
+		}
+		if name == "" {
+			return m, NewException(ExistenceError("module", spec))
+		}
+	}
+	return m1.importModule(ctx, name, only), nil
+}
+
+// hasModule returns true if the machine has a module with the given name
+func (m *machine) hasModule(name string) bool {
+	_, ok := m.modules.Lookup(name)
+	return ok
+}
+
+// indicatorOf validates a predicate indicator term and returns it
+// as a string like foo/2
+func indicatorOf(pi Term) (string, error) {
+	name, arity, ball := predicateIndicator(pi)
+	if ball != nil {
+		return "", NewException(ball)
+	}
+	return fmt.Sprintf("%s/%d", name, arity), nil
+}
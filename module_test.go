@@ -0,0 +1,191 @@
+package golog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mndrix/golog/term"
+)
+
+func TestConsultModule(t *testing.T) {
+	m := NewMachine().
+		ConsultModule("colors", `
+            :- export(color/1).
+            color(X) :- hue(X).
+            hue(red).
+            hue(green).
+        `).
+		ConsultModule("fruit", `
+            hue(yellow).  % doesn't collide with colors:hue/1
+        `).
+		Consult(`hue(blue).`)
+
+	tests := map[string]int{
+		`colors:color(X).`: 2,
+		`colors:hue(X).`:   2,
+		`fruit:hue(X).`:    1,
+		`hue(X).`:          1,
+	}
+	for goal, n := range tests {
+		if got := len(m.ProveAll(goal)); got != n {
+			t.Errorf("Wrong number of solutions for %s: %d vs %d", goal, got, n)
+		}
+	}
+
+	// exported predicates aren't visible until they're imported
+	if m.CanProve(`catch(color(_), _, fail).`) {
+		t.Errorf("color/1 visible without use_module")
+	}
+	m = m.Consult(`:- use_module(colors).`)
+	if !m.CanProve(`color(green).`) {
+		t.Errorf("color/1 not imported by use_module/1")
+	}
+	if m.CanProve(`catch(fruit:nothing, _, fail).`) {
+		t.Errorf("undefined predicate in fruit module was proven")
+	}
+
+	// use_module/1,2 work as goals too
+	m = m.ConsultModule("shapes", `
+        :- export([shape/1, side/1]).
+        shape(square).
+        side(4).
+    `)
+	if !m.CanProve(`use_module(shapes, [shape/1]), shape(square).`) {
+		t.Errorf("shape/1 not imported by calling use_module/2")
+	}
+	if !m.CanProve(`use_module(library(lists)), use_module(shapes), side(4).`) {
+		t.Errorf("side/1 not imported by calling use_module/1")
+	}
+	if !m.CanProve(`catch(use_module(_), E, true), nonvar(E), E = error(instantiation_error, _).`) {
+		t.Errorf("use_module/1 with a variable didn't raise an instantiation error")
+	}
+}
+
+func TestModuleFile(t *testing.T) {
+	m := NewMachine().Consult(`
+        :- module(stack, [push/3, pop/3]).
+        push(X, S, [X|S]).
+        pop(X, [X|S], S) :- nonempty([X|S]).
+        nonempty([_|_]).
+    `)
+	if !m.CanProve(`push(a, [], S), pop(X, S, []), X == a.`) {
+		t.Errorf("Exported predicates weren't imported into user")
+	}
+	if !m.CanProve(`catch(nonempty(_), error(existence_error(_, _), _), true).`) {
+		t.Errorf("Private predicate visible in user")
+	}
+	if !m.CanProve(`stack:nonempty([a]).`) {
+		t.Errorf("Qualified goal couldn't call a private predicate")
+	}
+}
+
+func TestMetaPredicate(t *testing.T) {
+	m := NewMachine().
+		ConsultModule("apply", `
+            :- export(twice/2).
+            :- meta_predicate twice(1, ?).
+            twice(G, X) :- call(G, X), call(G, X).
+            ok(_) :- fail.  % must not be found by twice/2
+        `).
+		Consult(`
+            :- use_module(apply, [twice/2]).
+            ok(X) :- assertz(seen(X)).
+        `)
+	if !m.CanProve(`twice(ok, a), findall(X, seen(X), [a,a]).`) {
+		t.Errorf("Meta argument called in the wrong module")
+	}
+
+	// goals inside a module find the module's own predicates
+	m = m.ConsultModule("local", `
+        :- export(run/1).
+        run(Xs) :- findall(X, item(X), Xs).
+        item(1).
+        item(2).
+    `)
+	if !m.CanProve(`local:run([1,2]).`) {
+		t.Errorf("findall/3 goal not proven in the module")
+	}
+
+	// existential quantification survives module qualification
+	m = m.ConsultModule("groups", `
+        :- export(all/1).
+        all(Xs) :- setof(X, K^pair(K, X), Xs).
+        pair(a, 2).
+        pair(b, 1).
+    `)
+	if !m.CanProve(`groups:all([1,2]).`) {
+		t.Errorf("setof/3 goal not proven in the module")
+	}
+}
+
+func TestModuleAssert(t *testing.T) {
+	m := NewMachine().ConsultModule("counter", `
+        :- dynamic(count/1).
+        bump :- retract(count(N0)), N is N0 + 1, assertz(count(N)).
+        count(0).
+    `)
+	solutions := m.ProveAll(`counter:bump, counter:bump, counter:count(N).`)
+	if len(solutions) != 1 || solutions[0].ByName_("N").String() != "2" {
+		t.Errorf("Wrong count: %v", solutions)
+	}
+	if m.CanProve(`catch(count(_), _, fail).`) {
+		t.Errorf("count/1 leaked into the user module")
+	}
+	if !m.CanProve(`assertz(counter:count(7)), counter:count(7).`) {
+		t.Errorf("Couldn't assert into a qualified module")
+	}
+}
+
+func TestModuleExistenceError(t *testing.T) {
+	m := NewMachine()
+	goal := `catch(nowhere:foo(1), error(existence_error(procedure, PI), _), true).`
+	solutions := m.ProveAll(goal)
+	if len(solutions) != 1 {
+		t.Fatalf("Expected an existence error")
+	}
+	pi := solutions[0].ByName_("PI")
+	if pi.String() != `:(nowhere, /(foo, 1))` {
+		t.Errorf("Wrong predicate indicator: %s", pi)
+	}
+
+	goal = `catch(M:foo, error(instantiation_error, _), true).`
+	if !m.CanProve(goal) {
+		t.Errorf("Unbound module didn't throw instantiation_error")
+	}
+}
+
+func TestUseModuleFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "golog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	src := ":- module(greeting, [hello/1]).\nhello(X) :- name(X).\nname(world).\n"
+	err = ioutil.WriteFile(filepath.Join(dir, "greeting.pl"), []byte(src), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src = ":- use_module(greeting).\nname(user).\nmain(X) :- hello(X).\n"
+	err = ioutil.WriteFile(filepath.Join(dir, "main.pl"), []byte(src), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(filepath.Join(dir, "main.pl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	m := NewMachine().Consult(f)
+
+	solutions := m.ProveAll(`main(X).`)
+	if len(solutions) != 1 {
+		t.Fatalf("Wrong number of solutions: %d", len(solutions))
+	}
+	if x := solutions[0].ByName_("X"); !term.IsAtom(x) || x.String() != "world" {
+		t.Errorf("Wrong greeting: %s", x)
+	}
+}
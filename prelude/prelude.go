@@ -13,31 +13,157 @@ var Prelude string
 
 func init() {
 	Prelude = strings.Join([]string{
+		MetaPredicates,
+		AggregateAll3,
+		Caret2,
 		Ignore1,
 		Length2,
 		Memberchk2,
 		Phrase2,
 		Phrase3,
 		Sort2,
+		Lists, // starts a module, so it must come last
 	}, "\n\n")
 }
 
+// MetaPredicates declares which arguments of builtin predicates are
+// goals.  When a builtin is called from a module, those arguments are
+// qualified with the module name so they're proven in the right place.
+var MetaPredicates = `
+:- meta_predicate
+	abolish(:),
+	assert(:),
+	asserta(:),
+	assertz(:),
+	bagof(?, ^, -),
+	call(0),
+	call(1, ?),
+	call(2, ?, ?),
+	call(3, ?, ?, ?),
+	call(4, ?, ?, ?, ?),
+	call(5, ?, ?, ?, ?, ?),
+	call(6, ?, ?, ?, ?, ?, ?),
+	call(7, ?, ?, ?, ?, ?, ?, ?),
+	catch(0, ?, 0),
+	findall(?, 0, -),
+	retract(:),
+	retractall(:),
+	setof(?, ^, -),
+	\+(0).
+`
+
+// aggregate_all(+Spec, :Goal, -Result) is semidet.
+//
+// Aggregates all solutions of Goal.  Spec is one of count, sum(Expr),
+// max(Expr), min(Expr), bag(Template) or set(Template).  max and min
+// compare numbers (including the values of compound arithmetic
+// expressions) arithmetically and other terms in the standard order.
+// They fail if Goal has no solutions.
+var AggregateAll3 = `
+:- meta_predicate aggregate_all(?, 0, -).
+aggregate_all(count, Goal, Count) :-
+	!,
+	findall(x, Goal, Xs),
+	length(Xs, Count).
+aggregate_all(sum(Expr), Goal, Sum) :-
+	!,
+	findall(Expr, Goal, Exprs),
+	'$aggregate_sum'(Exprs, 0, Sum).
+aggregate_all(max(Expr), Goal, Max) :-
+	!,
+	findall(Expr, Goal, [E|Exprs]),
+	'$aggregate_value'(E, X),
+	'$aggregate_max'(Exprs, X, Max).
+aggregate_all(min(Expr), Goal, Min) :-
+	!,
+	findall(Expr, Goal, [E|Exprs]),
+	'$aggregate_value'(E, X),
+	'$aggregate_min'(Exprs, X, Min).
+aggregate_all(bag(Template), Goal, Bag) :-
+	!,
+	findall(Template, Goal, Bag).
+aggregate_all(set(Template), Goal, Set) :-
+	!,
+	findall(Template, Goal, Bag),
+	sort(Bag, Set).
+aggregate_all(Spec, _, _) :-
+	throw(error(domain_error(aggregate_spec, Spec), aggregate_all/3)).
+
+'$aggregate_sum'([], Sum, Sum).
+'$aggregate_sum'([E|Es], Sum0, Sum) :-
+	Sum1 is Sum0 + E,
+	'$aggregate_sum'(Es, Sum1, Sum).
+
+'$aggregate_max'([], Max, Max).
+'$aggregate_max'([E|Es], Max0, Max) :-
+	'$aggregate_value'(E, X),
+	( '$aggregate_greater'(X, Max0) -> Max1 = X ; Max1 = Max0 ),
+	'$aggregate_max'(Es, Max1, Max).
+
+'$aggregate_min'([], Min, Min).
+'$aggregate_min'([E|Es], Min0, Min) :-
+	'$aggregate_value'(E, X),
+	( '$aggregate_greater'(Min0, X) -> Min1 = X ; Min1 = Min0 ),
+	'$aggregate_min'(Es, Min1, Min).
+
+'$aggregate_value'(E, X) :-
+	( compound(E), catch(X0 is E, error(type_error(evaluable, _), _), fail) ->
+		X = X0
+	; X = E
+	).
+
+'$aggregate_greater'(X, Y) :-
+	( number(X), number(Y) -> X > Y ; X @> Y ).
+`
+
+// Var^Goal is nondet.
+//
+// Outside of bagof/3 and setof/3, existential quantification just
+// proves Goal.
+var Caret2 = `
+:- meta_predicate ^(?, 0).
+_ ^ Goal :-
+	call(Goal).
+`
+
 var Ignore1 = `
+:- meta_predicate ignore(0).
 ignore(A) :-
 	call(A),
 	!.
 ignore(_).
 `
 
+// length(?List, ?Length) is nondet.
+//
+// True if List has Length elements.  If both are unbound, generates
+// lists of increasing length.
 var Length2 = `
-length(Xs, N) :-
-	length(Xs, 0, N).
-
-length([], N, N) :- !.
-length([_|T], N0, N) :-
-	% N0 \= N
-	succ(N0, N1),
-	length(T, N1, N).
+length(List, N) :-
+	var(N),
+	!,
+	'$length_gen'(List, 0, N).
+length(List, N) :-
+	integer(N),
+	!,
+	( N < 0 ->
+		throw(error(domain_error(not_less_than_zero, N), length/2))
+	; % otherwise ->
+		'$length_det'(List, N)
+	).
+length(_, N) :-
+	throw(error(type_error(integer, N), length/2)).
+
+'$length_gen'([], N, N).
+'$length_gen'([_|T], N0, N) :-
+	N1 is N0 + 1,
+	'$length_gen'(T, N1, N).
+
+'$length_det'([], 0) :- !.
+'$length_det'([_|T], N) :-
+	N > 0,
+	N1 is N - 1,
+	'$length_det'(T, N1).
 `
 
 var Memberchk2 = `
@@ -50,16 +176,18 @@ memberchk(X,[_|T]) :-
 //
 // True when DCGBody applies to the difference List/Rest.
 var Phrase3 = `
+:- meta_predicate phrase(//, ?, ?).
 phrase(Dcg, Head, Tail) :-
-    call(Dcg, Head, Tail).
+    '$dcg_call'(Dcg, Head, Tail).
 `
 
 // phrase(:DCGBody, ?List) is nondet.
 //
 // Like phrase(DCG,List,[]).
 var Phrase2 = `
+:- meta_predicate phrase(//, ?).
 phrase(Dcg, List) :-
-    call(Dcg, List, []).
+    '$dcg_call'(Dcg, List, []).
 `
 
 // sort(+List, -Sorted) is det.
@@ -74,10 +202,498 @@ sort(List, Sorted) :-
 '$consolidate'([], []).
 '$consolidate'([X], [X]).
 '$consolidate'([X,Y|Rest], Result) :-
-	( X = Y ->
+	( X == Y ->
 		'$consolidate'([Y|Rest], Result)
 	; % otherwise ->
 		'$consolidate'([Y|Rest], Tail),
 		Result = [X|Tail]
 	).
 `
+
+// Lists is a module compatible with library(lists) from SWI-Prolog.
+// Its predicates are imported into the user module, but a user's own
+// definition of a predicate with the same name takes precedence.
+var Lists = `
+:- module(lists, [
+	append/3,
+	between/3,
+	delete/3,
+	exclude/3,
+	foldl/4,
+	foldl/5,
+	foldl/6,
+	include/3,
+	last/2,
+	list_to_set/2,
+	maplist/2,
+	maplist/3,
+	maplist/4,
+	maplist/5,
+	maplist/6,
+	maplist/7,
+	max_list/2,
+	member/2,
+	min_list/2,
+	nth0/3,
+	nth1/3,
+	numlist/3,
+	partition/4,
+	permutation/2,
+	predsort/3,
+	reverse/2,
+	select/3,
+	sort/4,
+	sum_list/2
+]).
+
+% append(?List1, ?List2, ?List1AndList2) is nondet.
+append([], L, L).
+append([H|T], L, [H|R]) :-
+	append(T, L, R).
+
+% between(+Low, +High, ?Value) is nondet.
+%
+% Low =< Value =< High.  High can be inf or infinite.
+between(Low, High, X) :-
+	must_be_integer(Low, between/3),
+	( High == inf -> true
+	; High == infinite -> true
+	; must_be_integer(High, between/3)
+	),
+	( integer(X) ->
+		X >= Low,
+		( integer(High) -> X =< High ; true )
+	; var(X) ->
+		( integer(High) ->
+			Low =< High,
+			between_(Low, High, X)
+		; % otherwise ->
+			between_inf(Low, X)
+		)
+	; % otherwise ->
+		throw(error(type_error(integer, X), between/3))
+	).
+
+between_(Low, High, X) :-
+	( Low =:= High ->
+		X = Low
+	; % otherwise ->
+		( X = Low
+		; Low1 is Low + 1,
+		  between_(Low1, High, X)
+		)
+	).
+
+between_inf(Low, X) :-
+	( X = Low
+	; Low1 is Low + 1,
+	  between_inf(Low1, X)
+	).
+
+% delete(+List, @Elem, -Rest) is det.
+%
+% Rest is List without the elements which unify with Elem.
+delete([], _, []).
+delete([H|T], X, Rest) :-
+	( \+ H = X ->
+		Rest = [H|Rest1]
+	; % otherwise ->
+		Rest = Rest1
+	),
+	delete(T, X, Rest1).
+
+% exclude(:Goal, +List, -Excluded) is det.
+%
+% Excluded has the elements of List for which Goal fails.
+:- meta_predicate exclude(1, ?, ?).
+exclude(_, [], []).
+exclude(Goal, [X|Xs], Excluded) :-
+	( call(Goal, X) ->
+		Excluded = Excluded1
+	; % otherwise ->
+		Excluded = [X|Excluded1]
+	),
+	exclude(Goal, Xs, Excluded1).
+
+% foldl(:Goal, ?List1, ..., +V0, -V) is nondet.
+%
+% Folds the lists from the left, calling Goal(Elem1, ..., V0, V1)
+% for each set of elements.
+:- meta_predicate foldl(3, ?, +, -).
+foldl(Goal, Xs, V0, V) :-
+	foldl_(Xs, Goal, V0, V).
+
+foldl_([], _, V, V).
+foldl_([X|Xs], Goal, V0, V) :-
+	call(Goal, X, V0, V1),
+	foldl_(Xs, Goal, V1, V).
+
+:- meta_predicate foldl(4, ?, ?, +, -).
+foldl(Goal, Xs, Ys, V0, V) :-
+	foldl_(Xs, Ys, Goal, V0, V).
+
+foldl_([], [], _, V, V).
+foldl_([X|Xs], [Y|Ys], Goal, V0, V) :-
+	call(Goal, X, Y, V0, V1),
+	foldl_(Xs, Ys, Goal, V1, V).
+
+:- meta_predicate foldl(5, ?, ?, ?, +, -).
+foldl(Goal, Xs, Ys, Zs, V0, V) :-
+	foldl_(Xs, Ys, Zs, Goal, V0, V).
+
+foldl_([], [], [], _, V, V).
+foldl_([X|Xs], [Y|Ys], [Z|Zs], Goal, V0, V) :-
+	call(Goal, X, Y, Z, V0, V1),
+	foldl_(Xs, Ys, Zs, Goal, V1, V).
+
+% include(:Goal, +List, -Included) is det.
+%
+% Included has the elements of List for which Goal succeeds.
+:- meta_predicate include(1, ?, ?).
+include(_, [], []).
+include(Goal, [X|Xs], Included) :-
+	( call(Goal, X) ->
+		Included = [X|Included1]
+	; % otherwise ->
+		Included = Included1
+	),
+	include(Goal, Xs, Included1).
+
+% last(?List, ?Last) is semidet.
+last([X|Xs], Last) :-
+	last_(Xs, X, Last).
+
+last_([], Last, Last).
+last_([X|Xs], _, Last) :-
+	last_(Xs, X, Last).
+
+% list_to_set(+List, -Set) is det.
+%
+% Set has the elements of List without duplicates (according to ==/2),
+% keeping the first occurrence of each one.
+list_to_set(List, Set) :-
+	list_to_set_(List, [], Set).
+
+list_to_set_([], _, []).
+list_to_set_([X|Xs], Seen, Set) :-
+	( memberchk_eq(X, Seen) ->
+		Set = Set1
+	; % otherwise ->
+		Set = [X|Set1]
+	),
+	list_to_set_(Xs, [X|Seen], Set1).
+
+memberchk_eq(X, [Y|Ys]) :-
+	( X == Y ->
+		true
+	; % otherwise ->
+		memberchk_eq(X, Ys)
+	).
+
+% maplist(:Goal, ?List1, ..., ?ListN) is nondet.
+%
+% Calls Goal on corresponding elements of the lists, which have the
+% same length.
+:- meta_predicate maplist(1, ?).
+maplist(Goal, L1) :-
+	maplist_(L1, Goal).
+
+maplist_([], _).
+maplist_([E1|Es1], Goal) :-
+	call(Goal, E1),
+	maplist_(Es1, Goal).
+
+:- meta_predicate maplist(2, ?, ?).
+maplist(Goal, L1, L2) :-
+	maplist_(L1, L2, Goal).
+
+maplist_([], [], _).
+maplist_([E1|Es1], [E2|Es2], Goal) :-
+	call(Goal, E1, E2),
+	maplist_(Es1, Es2, Goal).
+
+:- meta_predicate maplist(3, ?, ?, ?).
+maplist(Goal, L1, L2, L3) :-
+	maplist_(L1, L2, L3, Goal).
+
+maplist_([], [], [], _).
+maplist_([E1|Es1], [E2|Es2], [E3|Es3], Goal) :-
+	call(Goal, E1, E2, E3),
+	maplist_(Es1, Es2, Es3, Goal).
+
+:- meta_predicate maplist(4, ?, ?, ?, ?).
+maplist(Goal, L1, L2, L3, L4) :-
+	maplist_(L1, L2, L3, L4, Goal).
+
+maplist_([], [], [], [], _).
+maplist_([E1|Es1], [E2|Es2], [E3|Es3], [E4|Es4], Goal) :-
+	call(Goal, E1, E2, E3, E4),
+	maplist_(Es1, Es2, Es3, Es4, Goal).
+
+:- meta_predicate maplist(5, ?, ?, ?, ?, ?).
+maplist(Goal, L1, L2, L3, L4, L5) :-
+	maplist_(L1, L2, L3, L4, L5, Goal).
+
+maplist_([], [], [], [], [], _).
+maplist_([E1|Es1], [E2|Es2], [E3|Es3], [E4|Es4], [E5|Es5], Goal) :-
+	call(Goal, E1, E2, E3, E4, E5),
+	maplist_(Es1, Es2, Es3, Es4, Es5, Goal).
+
+:- meta_predicate maplist(6, ?, ?, ?, ?, ?, ?).
+maplist(Goal, L1, L2, L3, L4, L5, L6) :-
+	maplist_(L1, L2, L3, L4, L5, L6, Goal).
+
+maplist_([], [], [], [], [], [], _).
+maplist_([E1|Es1], [E2|Es2], [E3|Es3], [E4|Es4], [E5|Es5], [E6|Es6], Goal) :-
+	call(Goal, E1, E2, E3, E4, E5, E6),
+	maplist_(Es1, Es2, Es3, Es4, Es5, Es6, Goal).
+
+% max_list(+List, -Max) is semidet.
+%
+% Max is the largest number in List.  Fails if List is empty.
+max_list([X|Xs], Max) :-
+	foldl(max_, Xs, X, Max).
+
+max_(X, Max0, Max) :-
+	Max is max(X, Max0).
+
+% member(?Elem, ?List) is nondet.
+member(X, [X|_]).
+member(X, [_|T]) :-
+	member(X, T).
+
+% min_list(+List, -Min) is semidet.
+%
+% Min is the smallest number in List.  Fails if List is empty.
+min_list([X|Xs], Min) :-
+	foldl(min_, Xs, X, Min).
+
+min_(X, Min0, Min) :-
+	Min is min(X, Min0).
+
+% nth0(?Index, ?List, ?Elem) is nondet.
+%
+% Elem is at position Index of List, counting from 0.
+nth0(Index, List, Elem) :-
+	integer(Index),
+	!,
+	Index >= 0,
+	nth_det(Index, List, Elem).
+nth0(Index, List, Elem) :-
+	var(Index),
+	!,
+	nth_gen(List, Elem, 0, Index).
+nth0(Index, _, _) :-
+	throw(error(type_error(integer, Index), nth0/3)).
+
+% nth1(?Index, ?List, ?Elem) is nondet.
+%
+% Like nth0/3 but counting from 1.
+nth1(Index, List, Elem) :-
+	integer(Index),
+	!,
+	Index0 is Index - 1,
+	nth0(Index0, List, Elem).
+nth1(Index, List, Elem) :-
+	var(Index),
+	!,
+	nth_gen(List, Elem, 1, Index).
+nth1(Index, _, _) :-
+	throw(error(type_error(integer, Index), nth1/3)).
+
+nth_det(0, [Elem|_], Elem) :- !.
+nth_det(N, [_|T], Elem) :-
+	N1 is N - 1,
+	nth_det(N1, T, Elem).
+
+nth_gen([Elem|_], Elem, Base, Base).
+nth_gen([_|T], Elem, N0, N) :-
+	N1 is N0 + 1,
+	nth_gen(T, Elem, N1, N).
+
+% numlist(+Low, +High, -List) is semidet.
+%
+% List is [Low, Low+1, ..., High].  Fails if High < Low.
+numlist(Low, High, List) :-
+	must_be_integer(Low, numlist/3),
+	must_be_integer(High, numlist/3),
+	Low =< High,
+	numlist_(Low, High, List).
+
+numlist_(High, High, [High]) :- !.
+numlist_(Low, High, [Low|T]) :-
+	Low1 is Low + 1,
+	numlist_(Low1, High, T).
+
+% partition(:Pred, +List, -Included, -Excluded) is det.
+%
+% Like include/3 and exclude/3 at the same time.
+:- meta_predicate partition(1, ?, ?, ?).
+partition(_, [], [], []).
+partition(Pred, [X|Xs], Included, Excluded) :-
+	( call(Pred, X) ->
+		Included = [X|Included1],
+		Excluded = Excluded1
+	; % otherwise ->
+		Included = Included1,
+		Excluded = [X|Excluded1]
+	),
+	partition(Pred, Xs, Included1, Excluded1).
+
+% permutation(?Xs, ?Ys) is nondet.
+%
+% Ys is a permutation of Xs.  At least one of them must be a proper
+% list.
+permutation(Xs, Ys) :-
+	( is_list(Xs) ->
+		length(Xs, N),
+		length(Ys, N)
+	; is_list(Ys) ->
+		length(Ys, N),
+		length(Xs, N)
+	; % otherwise ->
+		true
+	),
+	permutation_(Xs, Ys).
+
+permutation_([], []).
+permutation_(Xs, [Y|Ys]) :-
+	select(Y, Xs, Rest),
+	permutation_(Rest, Ys).
+
+% predsort(:Pred, +List, -Sorted) is det.
+%
+% Sorts List with merge sort, comparing elements with
+% call(Pred, Order, A, B) where Order is one of <, = or >.  Elements
+% for which Order is = are removed, like sort/2 does.
+:- meta_predicate predsort(3, +, -).
+predsort(Pred, List, Sorted) :-
+	length(List, N),
+	predsort(Pred, N, List, _, Sorted1),
+	!,
+	Sorted = Sorted1.
+
+predsort(Pred, 2, [X1,X2|L], L, R) :-
+	!,
+	call(Pred, Delta, X1, X2),
+	sort2(Delta, X1, X2, R).
+predsort(_, 1, [X|L], L, [X]) :- !.
+predsort(_, 0, L, L, []) :- !.
+predsort(Pred, N, L1, L3, R) :-
+	N1 is N // 2,
+	N2 is N - N1,
+	predsort(Pred, N1, L1, L2, R1),
+	predsort(Pred, N2, L2, L3, R2),
+	predmerge(Pred, R1, R2, R).
+
+sort2(<, X1, X2, [X1,X2]).
+sort2(=, X1, _, [X1]).
+sort2(>, X1, X2, [X2,X1]).
+
+predmerge(_, [], R, R) :- !.
+predmerge(_, R, [], R) :- !.
+predmerge(Pred, [H1|T1], [H2|T2], Result) :-
+	call(Pred, Delta, H1, H2),
+	!,
+	predmerge_(Delta, Pred, H1, H2, T1, T2, Result).
+
+predmerge_(<, Pred, H1, H2, T1, T2, [H1|R]) :-
+	predmerge(Pred, T1, [H2|T2], R).
+predmerge_(=, Pred, H1, _, T1, T2, [H1|R]) :-
+	predmerge(Pred, T1, T2, R).
+predmerge_(>, Pred, H1, H2, T1, T2, [H2|R]) :-
+	predmerge(Pred, [H1|T1], T2, R).
+
+% reverse(?List, ?Reversed) is semidet.
+reverse(Xs, Ys) :-
+	reverse_(Xs, [], Ys).
+
+reverse_([], Ys, Ys).
+reverse_([X|Xs], Acc, Ys) :-
+	reverse_(Xs, [X|Acc], Ys).
+
+% select(?Elem, ?List, ?Rest) is nondet.
+%
+% Rest is List with one occurrence of Elem removed.
+select(X, [X|T], T).
+select(X, [H|T], [H|R]) :-
+	select(X, T, R).
+
+% sort(+Key, +Order, +List, -Sorted) is det.
+%
+% Sorts List by the Key-th argument of each element (or the whole
+% element if Key is 0).  Order is @< or @> to remove elements with
+% equal keys (keeping the first) or @=< or @>= to keep them.  The
+% sort is stable.
+sort(Key, Order, List, Sorted) :-
+	must_be_integer(Key, sort/4),
+	( Key < 0 ->
+		throw(error(domain_error(not_less_than_zero, Key), sort/4))
+	; true
+	),
+	( var(Order) ->
+		throw(error(instantiation_error, sort/4))
+	; sort_order(Order, Direction, Unique) ->
+		true
+	; % otherwise ->
+		throw(error(domain_error(order, Order), sort/4))
+	),
+	sort_keys(List, Key, Direction, 0, Keyed),
+	msort(Keyed, Sorted0),
+	( Direction = descending ->
+		reverse(Sorted0, Sorted1)
+	; % otherwise ->
+		Sorted1 = Sorted0
+	),
+	( Unique = true ->
+		sort_unique(Sorted1, Sorted2)
+	; % otherwise ->
+		Sorted2 = Sorted1
+	),
+	sort_values(Sorted2, Sorted).
+
+sort_order(@<, ascending, true).
+sort_order(@=<, ascending, false).
+sort_order(@>, descending, true).
+sort_order(@>=, descending, false).
+
+% decorate each element as Key-Position-Elem.  For a descending sort,
+% positions are negated so that reversing keeps equal keys in order.
+sort_keys([], _, _, _, []).
+sort_keys([X|Xs], Key, Direction, I, [K-P-X|Rest]) :-
+	( Key =:= 0 -> K = X ; arg(Key, X, K) ),
+	( Direction = ascending -> P = I ; P is -I ),
+	I1 is I + 1,
+	sort_keys(Xs, Key, Direction, I1, Rest).
+
+sort_unique([], []).
+sort_unique([X], [X]) :- !.
+sort_unique([K1-P1-X1, K2-P2-X2|Rest], Unique) :-
+	( K1 == K2 ->
+		sort_unique([K1-P1-X1|Rest], Unique)
+	; % otherwise ->
+		Unique = [K1-P1-X1|Unique1],
+		sort_unique([K2-P2-X2|Rest], Unique1)
+	).
+
+sort_values([], []).
+sort_values([_-_-X|Xs], [X|Values]) :-
+	sort_values(Xs, Values).
+
+% sum_list(+List, -Sum) is det.
+sum_list(Xs, Sum) :-
+	foldl(plus_, Xs, 0, Sum).
+
+plus_(X, Sum0, Sum) :-
+	Sum is Sum0 + X.
+
+must_be_integer(X, _) :-
+	integer(X),
+	!.
+must_be_integer(X, Context) :-
+	var(X),
+	!,
+	throw(error(instantiation_error, Context)).
+must_be_integer(X, Context) :-
+	throw(error(type_error(integer, X), Context)).
+`
@@ -59,6 +59,13 @@ func TestPureProlog(t *testing.T) {
 		for _, test := range tests {
 			x := test.(term.Callable)
 			//t.Logf("proving: %s", test)
+			if x.Arity() > 0 && x.Arguments()[0].Indicator() == "throws/1" {
+				expected := x.Arguments()[0].(term.Callable).Arguments()[0]
+				if !m.CanProve(throws(test, expected)) {
+					t.Errorf("%s: %s should throw %s", name, test, expected)
+				}
+				continue
+			}
 			canProve := m.CanProve(test)
 			if x.Arity() > 0 && x.Arguments()[0].String() == "fail" {
 				if canProve {
@@ -72,3 +79,17 @@ func TestPureProlog(t *testing.T) {
 		}
 	}
 }
+
+// throws builds a goal which succeeds if test throws an exception
+// matching expected.  Like library(tap), expected can describe either
+// the entire exception or just the formal part of an ISO error term.
+func throws(test, expected term.Term) term.Term {
+	ball := term.NewVar("Ball")
+	iso := term.NewCallable("error", expected, term.NewVar("_"))
+	matches := term.NewCallable(";",
+		term.NewCallable("=", ball, iso),
+		term.NewCallable("=", ball, expected),
+	)
+	goal := term.NewCallable(",", test, term.NewAtom("fail"))
+	return term.NewCallable("catch", goal, ball, matches)
+}
@@ -308,3 +308,22 @@ func TestCanProveOnce(t *testing.T) {
 		t.Errorf("CanProve found multiple solutions")
 	}
 }
+
+// uncaught exceptions arrive in Go as *term.Exception
+func TestUncaughtException(t *testing.T) {
+	m := NewMachine()
+
+	defer func() {
+		x := recover()
+		e, ok := x.(*term.Exception)
+		if !ok {
+			t.Errorf("Wrong panic value: %#v", x)
+			return
+		}
+		if ball := e.Ball().String(); ball != "oops(1)" {
+			t.Errorf("Wrong exception: %s vs oops(1)", ball)
+		}
+	}()
+	m.ProveAll(`X = 1, throw(oops(X)).`)
+	t.Errorf("ProveAll didn't panic")
+}
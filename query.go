@@ -0,0 +1,215 @@
+package golog
+
+// Prepared queries.  A goal is read once and proven many times with
+// different parameters.
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/mndrix/golog/lex"
+	"github.com/mndrix/golog/read"
+	. "github.com/mndrix/golog/term"
+	. "github.com/mndrix/golog/util"
+)
+
+// Query is a goal which has been read and checked once so it can be
+// proven many times.  Each unquoted ? in the goal is a placeholder for
+// a parameter, given as a Go value when the query is proven.
+// Parameters are converted to terms with term.Marshal.  For example,
+//
+//	q, err := m.Prepare(`price(?, Amount)`)
+//	...
+//	var amount int
+//	found, err := q.One(&amount, "apple")
+//
+// A Query proves its goal against the machine that prepared it.  Like
+// a machine, a Query is immutable and safe to share.
+type Query interface {
+	// Solve returns an iterator over the solutions of the query with
+	// its placeholders replaced by args.  If args don't fit the query,
+	// the iterator has no solutions and its Err method describes the
+	// problem.
+	Solve(args ...interface{}) Solutions
+
+	// One stores the first solution in the value pointed to by v.
+	// Returns false if there are no solutions.  See All for how a
+	// solution is stored.
+	One(v interface{}, args ...interface{}) (bool, error)
+
+	// All appends every solution to the slice pointed to by v.  A
+	// struct holds a solution's variables as described for
+	// Bindings.Decode.  Any other type holds the value of the query's
+	// only named variable, converted with term.Unmarshal.
+	All(v interface{}, args ...interface{}) error
+}
+
+type query struct {
+	m      *machine
+	goal   Callable
+	params []*Variable // in the order they appear in goal
+	names  []string    // named variables in goal, sorted
+}
+
+// Prepare reads goal (the trailing full stop is optional) and returns
+// a Query which proves it.  Each unquoted ? atom in goal is a
+// placeholder parameter.  Since ? is a symbol character, it must be
+// separated from other symbol characters, including a full stop: write
+// X - ? rather than X-?, which reads as the atom -? instead.  A quoted
+// '?' is an ordinary atom.  Returns an error if goal can't be read, isn't callable or has
+// more than one term.
+func (m *machine) Prepare(goal string) (q Query, err error) {
+	defer func() { // the reader panics on some malformed text
+		if x := recover(); x != nil {
+			q, err = nil, fmt.Errorf("Can't read goal %q: %v", goal, x)
+		}
+	}()
+
+	text, params, err := placeholders(goal)
+	if err != nil {
+		return nil, err
+	}
+	r, err := read.NewTermReader(text)
+	if err != nil {
+		return nil, err
+	}
+	r.SetOperators(m.ops)
+	t, err := r.Next()
+	if err != nil {
+		return nil, fmt.Errorf("Can't read goal %q: %s", goal, err)
+	}
+	if !IsCallable(t) {
+		return nil, fmt.Errorf("Goal %q isn't callable", goal)
+	}
+
+	pq := &query{m: m, goal: t.(Callable)}
+	vars := Variables(pq.goal)
+	for _, name := range params {
+		v, _ := vars.Lookup(name)
+		pq.params = append(pq.params, v.(*Variable))
+	}
+	vars.ForEach(func(name string, _ interface{}) {
+		if !strings.HasPrefix(name, "_") {
+			pq.names = append(pq.names, name)
+		}
+	})
+	sort.Strings(pq.names)
+	return pq, nil
+}
+
+// placeholders returns the text of goal with each unquoted ? atom
+// replaced by a variable, along with the names of those variables in
+// order.  The text ends with exactly one full stop.
+func placeholders(goal string) (string, []string, error) {
+	var lexemes []*lex.Eme
+	used := make(map[string]bool) // variable names in goal
+	for ll := lex.NewScannerList(strings.NewReader(goal)); ll.Value.Type != lex.EOF; ll = ll.Next() {
+		switch ll.Value.Type {
+		case lex.Comment:
+			continue
+		case lex.Variable:
+			used[ll.Value.Content] = true
+		}
+		lexemes = append(lexemes, ll.Value)
+	}
+
+	var text []byte
+	var params []string
+	end := 0 // end of the goal text copied so far
+	for i, l := range lexemes {
+		if l.Type == lex.FullStop && i < len(lexemes)-1 {
+			return "", nil, fmt.Errorf("Goal %q has more than one term", goal)
+		}
+		if l.Type != lex.Atom || l.Content != "?" {
+			continue
+		}
+		name := fmt.Sprintf("_Param%d", len(params)+1)
+		for used[name] {
+			name = "_" + name
+		}
+		text = append(text, goal[end:l.Pos.Offset]...)
+		text = append(text, name...)
+		end = l.Pos.Offset + len(l.Content)
+		params = append(params, name)
+	}
+	text = append(text, goal[end:]...)
+	if len(lexemes) == 0 || lexemes[len(lexemes)-1].Type != lex.FullStop {
+		text = append(text, " ."...)
+	}
+	return string(text), params, nil
+}
+
+// bind returns q's goal with its parameters replaced by args
+func (q *query) bind(args []interface{}) (Callable, error) {
+	if len(args) != len(q.params) {
+		return nil, fmt.Errorf("Query needs %d arguments, got %d", len(q.params), len(args))
+	}
+	env := NewBindings()
+	for i, arg := range args {
+		t, err := Marshal(arg)
+		if err != nil {
+			return nil, err
+		}
+		env, err = env.Bind(q.params[i], t)
+		MaybePanic(err)
+	}
+	return q.goal.ReplaceVariables(env).(Callable), nil
+}
+
+func (q *query) Solve(args ...interface{}) Solutions {
+	goal, err := q.bind(args)
+	if err != nil {
+		return &solutions{err: err}
+	}
+	return q.m.Solve(goal)
+}
+
+func (q *query) One(v interface{}, args ...interface{}) (bool, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return false, fmt.Errorf("One needs a non-nil pointer, not %T", v)
+	}
+
+	solutions := q.Solve(args...)
+	defer solutions.Close()
+	if !solutions.Next() {
+		return false, solutions.Err()
+	}
+	return true, q.decode(solutions.Bindings(), rv.Elem())
+}
+
+func (q *query) All(v interface{}, args ...interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("All needs a pointer to a slice, not %T", v)
+	}
+	slice := rv.Elem()
+
+	solutions := q.Solve(args...)
+	defer solutions.Close()
+	for solutions.Next() {
+		x := reflect.New(slice.Type().Elem()).Elem()
+		if err := q.decode(solutions.Bindings(), x); err != nil {
+			return err
+		}
+		slice.Set(reflect.Append(slice, x))
+	}
+	return solutions.Err()
+}
+
+// decode stores a solution in v, as described for All
+func (q *query) decode(b Bindings, v reflect.Value) error {
+	if v.Kind() == reflect.Struct {
+		return b.Decode(v.Addr().Interface())
+	}
+	if len(q.names) != 1 {
+		return fmt.Errorf("Can't store %d variables in %s", len(q.names), v.Type())
+	}
+	t, err := b.ByName(q.names[0])
+	if err != nil || IsVariable(t) {
+		return nil // leave unbound variables alone
+	}
+	return Unmarshal(t, v.Addr().Interface())
+}
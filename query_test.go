@@ -0,0 +1,123 @@
+package golog
+
+import "testing"
+
+func TestPrepare(t *testing.T) {
+	m := NewMachine().Consult(`
+        price(apple, 3).
+        price(pear, 5).
+        price(plum, 5).
+        stock(Fruit, N) :- price(Fruit, P), N is P * 10.
+    `)
+
+	// a single variable
+	q, err := m.Prepare(`price(?, Amount)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for fruit, want := range map[string]int{"apple": 3, "pear": 5} {
+		var amount int
+		found, err := q.One(&amount, fruit)
+		if err != nil || !found || amount != want {
+			t.Errorf("Price of %s: %d, %v, %v", fruit, amount, found, err)
+		}
+	}
+	var amount int
+	if found, err := q.One(&amount, "kiwi"); found || err != nil {
+		t.Errorf("Found the price of a kiwi: %v, %v", found, err)
+	}
+
+	// all solutions as structs
+	q, err = m.Prepare(`price(Fruit, ?), stock(Fruit, N).`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stock []struct {
+		Fruit string
+		Count int `golog:"N"`
+	}
+	if err := q.All(&stock, 5); err != nil {
+		t.Fatal(err)
+	}
+	if len(stock) != 2 || stock[0].Fruit != "pear" || stock[1].Count != 50 {
+		t.Errorf("Wrong stock: %+v", stock)
+	}
+
+	// all solutions as values
+	q, err = m.Prepare(`price(Fruit, ?)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fruits []string
+	if err := q.All(&fruits, 5); err != nil {
+		t.Fatal(err)
+	}
+	if len(fruits) != 2 || fruits[0] != "pear" || fruits[1] != "plum" {
+		t.Errorf("Wrong fruits: %v", fruits)
+	}
+
+	// solutions one at a time
+	solutions := q.Solve(3)
+	if !solutions.Next() || solutions.Bindings().ByName_("Fruit").String() != "apple" {
+		t.Errorf("Wrong solution for a price of 3")
+	}
+	if solutions.Next() {
+		t.Errorf("Too many solutions for a price of 3")
+	}
+
+	// parameters inside compound terms
+	q, err = m.Prepare(`X = f(?, [?, b])`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solutions = q.Solve(1, "a")
+	if !solutions.Next() || solutions.Bindings().ByName_("X").String() != "f(1, [a,b])" {
+		t.Errorf("Wrong compound: %v", solutions.Bindings())
+	}
+
+	// bad arguments
+	for _, args := range [][]interface{}{{}, {1, 2, 3}, {make(chan int), 1}} {
+		solutions := q.Solve(args...)
+		if solutions.Next() || solutions.Err() == nil {
+			t.Errorf("Solved with bad arguments %v", args)
+		}
+	}
+	if err := q.All(&fruits, 1, "a"); err == nil {
+		t.Errorf("Stored 1 variable in a slice of strings: %v", fruits)
+	}
+
+	// quoted '?' is an atom and spaces separate ? from symbol characters
+	q, err = m.Prepare(`X = '?', Y = a - ?, ? > 1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	solutions = q.Solve("b", 2)
+	if !solutions.Next() {
+		t.Fatalf("No solution with a quoted '?'")
+	}
+	b := solutions.Bindings()
+	if b.ByName_("X").String() != "?" || b.ByName_("Y").String() != "-(a, b)" {
+		t.Errorf("Wrong bindings: %v", b)
+	}
+
+	// bad goals
+	bad := []string{`foo)`, `42`, `X`, `a. b.`, `f(a b)`, `foo(`, `foo. bar`}
+	for _, goal := range bad {
+		if _, err := m.Prepare(goal); err == nil {
+			t.Errorf("Prepared a bad goal: %s", goal)
+		}
+	}
+
+	// uncaught exceptions
+	q, err = m.Prepare(`X is ? + 1`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var x int
+	if _, err := q.One(&x, "a"); err == nil {
+		t.Errorf("No exception for a + 1")
+	}
+	if _, err := q.One(&x, 41); err != nil || x != 42 {
+		t.Errorf("Wrong sum: %d, %v", x, err)
+	}
+}
@@ -0,0 +1,169 @@
+package read
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/mndrix/ps"
+)
+
+// Operators is an operator table as described in ISO §6.3.4.4.  Tables
+// are immutable.  Defining an operator produces a new table, leaving
+// the original untouched, so a table can be safely shared between
+// readers, writers and machines.
+type Operators struct {
+	table ps.Map // operator name => [7]priority indexed by specifier
+}
+
+// Operator is a single operator definition, like op(700, xfx, =)
+type Operator struct {
+	Priority  int
+	Specifier string // like "xfy"
+	Name      string
+}
+
+var defaultOperators *Operators
+
+func init() {
+	o := &Operators{table: ps.NewMap()}
+	o = o.op(1200, xfx, `:-`, `-->`)
+	o = o.op(1200, fx, `:-`, `?-`)
+	o = o.op(1150, fx, `meta_predicate`) // SWI, YAP, etc. extension
+	o = o.op(1150, fx, `dynamic`, `discontiguous`, `initialization`, `multifile`)
+	o = o.op(1100, xfy, `;`)
+	o = o.op(1050, xfy, `->`)
+	o = o.op(1000, xfy, `,`)
+	o = o.op(900, fy, `\+`)
+	o = o.op(700, xfx, `=`, `\=`)
+	o = o.op(700, xfx, `==`, `\==`, `@<`, `@=<`, `@>`, `@>=`)
+	o = o.op(700, xfx, `=..`)
+	o = o.op(700, xfx, `is`, `=:=`, `=\=`, `<`, `=<`, `>`, `>=`)
+	o = o.op(500, yfx, `+`, `-`, `/\`, `\/`, `xor`) // syntax highlighter `
+	o = o.op(400, yfx, `*`, `/`, `//`, `rem`, `mod`, `div`, `<<`, `>>`)
+	o = o.op(200, xfx, `**`)
+	o = o.op(200, xfy, `^`)
+	o = o.op(200, xfy, `:`)          // module qualification
+	o = o.op(200, fy, `-`, `+`, `\`) // syntax highlighter `
+	defaultOperators = o
+}
+
+// DefaultOperators returns the default operator table specified in ISO
+// §6.3.4.4, table 7 plus a few common extensions
+func DefaultOperators() *Operators {
+	return defaultOperators
+}
+
+// op returns a new table with operators os defined with priority p and
+// specifier s.  Other definitions of those operators are unchanged.
+func (o *Operators) op(p priority, s specifier, os ...string) *Operators {
+	table := o.table
+	for _, name := range os {
+		var priorities [7]priority
+		if x, ok := table.Lookup(name); ok {
+			priorities = x.([7]priority)
+		}
+		priorities[s] = p
+		table = table.Set(name, priorities)
+	}
+	return &Operators{table: table}
+}
+
+// Define returns a new table in which operators names are created,
+// changed or (with priority 0) removed the same way op/3 does.  The
+// specifier is given by name (like "xfy").  Defining an operator replaces
+// any other operator with the same name and class (prefix, infix or
+// postfix).  Returns an error if the priority or specifier is invalid.
+func (o *Operators) Define(p int, spec string, names ...string) (*Operators, error) {
+	if p < 0 || p > 1200 {
+		return nil, fmt.Errorf("Invalid operator priority: %d", p)
+	}
+	s, ok := specifiers[spec]
+	if !ok {
+		return nil, fmt.Errorf("Invalid operator specifier: %s", spec)
+	}
+
+	for _, name := range names {
+		for _, c := range class(s) {
+			o = o.op(0, c, name)
+		}
+		o = o.op(priority(p), s, name)
+	}
+	return o, nil
+}
+
+// class returns the specifiers in the same class as s
+func class(s specifier) []specifier {
+	switch s {
+	case fx, fy:
+		return []specifier{fx, fy}
+	case xfx, xfy, yfx:
+		return []specifier{xfx, xfy, yfx}
+	}
+	return []specifier{xf, yf}
+}
+
+// priorities returns the priority of each specifier for operator name
+func (o *Operators) priorities(name string) ([7]priority, bool) {
+	x, ok := o.table.Lookup(name)
+	if !ok {
+		return [7]priority{}, false
+	}
+	return x.([7]priority), true
+}
+
+// find returns the priority and specifier name of the first of
+// specifiers ss defined for operator name.  The priority is 0 if
+// there's no such operator.
+func (o *Operators) find(name string, ss ...specifier) (int, string) {
+	priorities, _ := o.priorities(name)
+	for _, s := range ss {
+		if priorities[s] > 0 {
+			return int(priorities[s]), specifierNames[s]
+		}
+	}
+	return 0, ""
+}
+
+// Prefix returns the priority and specifier (fx or fy) of operator name
+// as a prefix operator.  The priority is 0 if name isn't one.
+func (o *Operators) Prefix(name string) (int, string) {
+	return o.find(name, fx, fy)
+}
+
+// Infix returns the priority and specifier (xfx, xfy or yfx) of
+// operator name as an infix operator.  The priority is 0 if name
+// isn't one.
+func (o *Operators) Infix(name string) (int, string) {
+	return o.find(name, yfx, xfy, xfx)
+}
+
+// Postfix returns the priority and specifier (xf or yf) of operator
+// name as a postfix operator.  The priority is 0 if name isn't one.
+func (o *Operators) Postfix(name string) (int, string) {
+	return o.find(name, xf, yf)
+}
+
+// IsOperator returns true if name is an operator of any class
+func (o *Operators) IsOperator(name string) bool {
+	p, _ := o.Prefix(name)
+	i, _ := o.Infix(name)
+	s, _ := o.Postfix(name)
+	return p > 0 || i > 0 || s > 0
+}
+
+// All returns every operator definition in the table, ordered by name
+func (o *Operators) All() []Operator {
+	names := o.table.Keys()
+	sort.Strings(names)
+
+	var all []Operator
+	for _, name := range names {
+		priorities, _ := o.priorities(name)
+		for s, p := range priorities {
+			if p > 0 {
+				all = append(all, Operator{int(p), specifierNames[s], name})
+			}
+		}
+	}
+	return all
+}
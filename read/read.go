@@ -87,8 +87,10 @@ func toReader(src interface{}) (io.Reader, error) {
 }
 
 type TermReader struct {
-	operators map[string]*[7]priority
-	ll        *lex.List
+	ops     *Operators
+	ll      *lex.List
+	pos     *lex.Position // where the most recent term started
+	stopped bool          // is ll the full stop of the previous term?
 }
 
 func NewTermReader(src interface{}) (*TermReader, error) {
@@ -97,8 +99,7 @@ func NewTermReader(src interface{}) (*TermReader, error) {
 		return nil, err
 	}
 
-	tokens := lex.Scan(ioReader)
-	r := TermReader{ll: lex.NewList(tokens)}
+	r := TermReader{ll: lex.NewScannerList(ioReader)}
 	r.ResetOperatorTable()
 	return &r, nil
 }
@@ -108,17 +109,54 @@ func NewTermReader(src interface{}) (*TermReader, error) {
 func (r *TermReader) Next() (term.Term, error) {
 	var t term.Term
 	var ll *lex.List
-	if r.readTerm(1200, r.ll, &ll, &t) {
-		if term.IsError(t) {
-			return nil, fmt.Errorf("%s", t.String())
-		}
+	if r.stopped {
+		// only now look beyond the previous term, which might mean
+		// waiting for more input
+		r.ll = r.ll.Next()
+		r.stopped = false
+	}
+	for r.ll.Value.Type == lex.Comment {
+		r.ll = r.ll.Next()
+	}
+	r.pos = r.ll.Value.Pos
+	ok := r.readTerm(1200, r.ll, &ll, &t)
+	if t != nil && term.IsError(t) {
+		r.SkipTerm() // so the next call starts after the bad term
+		return nil, fmt.Errorf("%s", t.String())
+	}
+	if ok {
 		r.ll = ll
+		r.stopped = true
 		return term.RenameVariables(t), nil
 	}
 
 	return nil, NoMoreTerms
 }
 
+// SkipTerm discards tokens through the next full stop, so the next
+// call to Next() starts after a term which couldn't be read.
+func (r *TermReader) SkipTerm() {
+	for r.ll.Value.Type != lex.FullStop && r.ll.Value.Type != lex.EOF {
+		r.ll = r.ll.Next()
+	}
+	if r.ll.Value.Type == lex.FullStop {
+		r.stopped = true
+	}
+}
+
+// Position returns the source position where the term most recently
+// returned by Next() started.  Returns nil if Next() hasn't been called.
+func (r *TermReader) Position() *lex.Position {
+	return r.pos
+}
+
+// Next_ is like Next but panics instead of returning an error.
+func (r *TermReader) Next_() term.Term {
+	t, err := r.Next()
+	maybePanic(err)
+	return t
+}
+
 // all returns a slice of all terms available from this reader
 func (r *TermReader) all() ([]term.Term, error) {
 	terms := make([]term.Term, 0)
@@ -138,36 +176,56 @@ func (r *TermReader) all() ([]term.Term, error) {
 // ResetOperatorTable replaces the reader's current operator table
 // with the default table specified in ISO Prolog §6.3.4.4, table 7
 func (r *TermReader) ResetOperatorTable() {
-	r.operators = make(map[string]*[7]priority)
-	r.Op(1200, xfx, `:-`, `-->`)
-	r.Op(1200, fx, `:-`, `?-`)
-	r.Op(1150, fx, `meta_predicate`) // SWI, YAP, etc. extension
-	r.Op(1100, xfy, `;`)
-	r.Op(1050, xfy, `->`)
-	r.Op(1000, xfy, `,`)
-	r.Op(900, fy, `\+`)
-	r.Op(700, xfx, `=`, `\=`)
-	r.Op(700, xfx, `==`, `\==`, `@<`, `@=<`, `@>`, `@>=`)
-	r.Op(700, xfx, `=..`)
-	r.Op(700, xfx, `is`, `=:=`, `=\=`, `<`, `=<`, `>`, `>=`)
-	r.Op(500, yfx, `+`, `-`, `/\`, `\/`) // syntax highlighter `
-	r.Op(400, yfx, `*`, `/`, `//`, `rem`, `mod`, `<<`, `<<`)
-	r.Op(200, xfx, `**`)
-	r.Op(200, xfy, `^`)
-	r.Op(200, fy, `-`, `\`) // syntax highlighter `
+	r.ops = DefaultOperators()
+}
+
+// Operators returns the reader's current operator table
+func (r *TermReader) Operators() *Operators {
+	return r.ops
+}
+
+// SetOperators replaces the reader's current operator table
+func (r *TermReader) SetOperators(ops *Operators) {
+	r.ops = ops
 }
 
 // Op creates or changes the parsing behavior of a Prolog operator.
 // It's equivalent to op/3
 func (r *TermReader) Op(p priority, s specifier, os ...string) {
-	for _, o := range os {
-		priorities, ok := r.operators[o]
-		if !ok {
-			priorities = new([7]priority)
-			r.operators[o] = priorities
-		}
-		priorities[s] = p
+	r.ops = r.ops.op(p, s, os...)
+}
+
+// specifiers maps the names used by op/3 to operator specifiers
+var specifiers = map[string]specifier{
+	"fx":  fx,
+	"fy":  fy,
+	"xfx": xfx,
+	"xfy": xfy,
+	"yfx": yfx,
+	"xf":  xf,
+	"yf":  yf,
+}
+
+// specifierNames is the inverse of specifiers
+var specifierNames = [...]string{
+	fx:  "fx",
+	fy:  "fy",
+	xfx: "xfx",
+	xfy: "xfy",
+	yfx: "yfx",
+	xf:  "xf",
+	yf:  "yf",
+}
+
+// DefineOp creates, changes or (with priority 0) removes an operator
+// the same way op/3 does.  See Operators.Define
+func (r *TermReader) DefineOp(p int, spec string, names ...string) error {
+	ops, err := r.ops.Define(p, spec, names...)
+	if err != nil {
+		return err
 	}
+	r.ops = ops
+	return nil
 }
 
 // parse a single functor
@@ -219,8 +277,8 @@ func (r *TermReader) readTerm(p priority, i *lex.List, o **lex.List, t *term.Ter
 	}
 
 	if r.term(p, i, o, t) {
-		if r.tok(lex.FullStop, *o, o) {
-			return true
+		if (*o).Value.Type == lex.FullStop {
+			return true // leaving *o at the full stop
 		} else {
 			msg := fmt.Sprintf("expected full stop after `%s` but got `%s`", *t, (*o).Value.Content)
 			*t = term.NewError(msg, (*o).Value)
@@ -240,6 +298,11 @@ func (r *TermReader) term(p priority, i *lex.List, o **lex.List, t *term.Term) b
 	var opP, argP priority
 	//  fmt.Printf("seeking term with %s\n", i.Value.Content)
 
+	// negative numeric literal §6.3.4.1
+	if n, ok := r.negativeNumber(i, o); ok {
+		return r.restTerm(0, p, *o, o, n, t)
+	}
+
 	// prefix operator
 	if r.prefix(&op, &opP, &argP, i, o) && opP <= p && r.term(argP, *o, o, &t0) {
 		opT := term.NewCallable(op, t0)
@@ -256,6 +319,16 @@ func (r *TermReader) term(p priority, i *lex.List, o **lex.List, t *term.Term) b
 		return r.restTerm(0, p, *o, o, list, t)
 	}
 
+	// curly bracketed terms §6.3.6
+	if r.tok('{', i, o) && r.tok('}', *o, o) {
+		curly := term.NewAtom("{}")
+		return r.restTerm(0, p, *o, o, curly, t)
+	}
+	if r.tok('{', i, o) && r.term(1200, *o, o, &t0) && r.tok('}', *o, o) {
+		curly := term.NewCallable("{}", t0)
+		return r.restTerm(0, p, *o, o, curly, t)
+	}
+
 	// parenthesized terms
 	if r.tok('(', i, o) && r.term(1200, *o, o, &t0) && r.tok(')', *o, o) {
 		//      fmt.Printf("open paren %s close paren\n", t0)
@@ -296,7 +369,11 @@ func (r *TermReader) term(p priority, i *lex.List, o **lex.List, t *term.Term) b
 	if r.functor(i, o, &f) && r.tok('(', *o, o) {
 		var args []term.Term
 		var arg term.Term
-		for r.term(999, *o, o, &arg) { // 999 priority per §6.3.3.1
+		for {
+			if !r.term(999, *o, o, &arg) { // 999 priority per §6.3.3.1
+				*t = term.NewError("Expected an argument", (*o).Value)
+				return false
+			}
 			args = append(args, arg)
 			if r.tok(')', *o, o) {
 				break
@@ -341,14 +418,14 @@ func (r *TermReader) restTerm(leftP, p priority, i *lex.List, o **lex.List, left
 func (r *TermReader) infix(op *string, opP, lap, rap *priority, i *lex.List, o **lex.List) bool {
 	//  fmt.Printf("seeking infix with %s\n", i.Value.Content)
 	typ := i.Value.Type
-	if typ != lex.Atom && typ != lex.Functor && typ != ',' {
+	if typ != lex.Atom && typ != lex.Functor && typ != ',' && typ != '|' {
 		//      fmt.Printf("  type mismatch: %s\n", lex.TokenString(i.Value.Type))
 		return false
 	}
 
 	// is this an operator at all?
 	name := i.Value.Content
-	priorities, ok := r.operators[name]
+	priorities, ok := r.ops.priorities(name)
 	if !ok {
 		//      fmt.Printf("  no operator %s found\n", name)
 		return false
@@ -379,6 +456,32 @@ func (r *TermReader) infix(op *string, opP, lap, rap *priority, i *lex.List, o *
 	return true
 }
 
+// consume a "-" immediately followed by a numeric literal, producing
+// a negative number
+func (r *TermReader) negativeNumber(i *lex.List, o **lex.List) (term.Term, bool) {
+	if i.Value.Type != lex.Atom || i.Value.Content != "-" {
+		return nil, false
+	}
+	next := i.Next()
+	if next.Value.Type != lex.Int && next.Value.Type != lex.Float {
+		return nil, false
+	}
+	minus, num := i.Value.Pos, next.Value.Pos
+	if minus == nil || num == nil || num.Offset != minus.Offset+1 {
+		return nil, false // layout text between them
+	}
+
+	var n term.Number
+	if next.Value.Type == lex.Int {
+		n = term.NewInt(next.Value.Content)
+	} else {
+		n = term.NewFloat(next.Value.Content)
+	}
+	n, _ = term.ArithmeticNegate(n)
+	*o = next.Next()
+	return n, true
+}
+
 // consume a prefix operator. indicate which one it was along with its priority
 func (r *TermReader) prefix(op *string, opP, argP *priority, i *lex.List, o **lex.List) bool {
 	if i.Value.Type != lex.Atom {
@@ -387,7 +490,7 @@ func (r *TermReader) prefix(op *string, opP, argP *priority, i *lex.List, o **le
 
 	// is this an operator at all?
 	name := i.Value.Content
-	priorities, ok := r.operators[name]
+	priorities, ok := r.ops.priorities(name)
 	if !ok {
 		return false
 	}
@@ -417,7 +520,7 @@ func (r *TermReader) postfix(op *string, opP, argP *priority, i *lex.List, o **l
 
 	// is this an operator at all?
 	name := i.Value.Content
-	priorities, ok := r.operators[name]
+	priorities, ok := r.ops.priorities(name)
 	if !ok {
 		return false
 	}
@@ -1,6 +1,10 @@
 package read
 
-import "testing"
+import (
+	"io"
+	"strings"
+	"testing"
+)
 
 func TestBasic(t *testing.T) {
 
@@ -39,6 +43,17 @@ func TestBasic(t *testing.T) {
 	single[`(true->(true)).`] = `->(true, true)`
 	single[`(if->then;else).`] = `;(->(if, then), else)`
 	single[`A = 3.`] = `=(A, 3)`
+	single[`-3.`] = `-3`    // negative numeric literal §6.3.4.1
+	single[`- 3.`] = `-(3)` // ... but not with layout text
+	single[`-2.5.`] = `-2.5`
+	single[`3 -1.`] = `-(3, 1)` // infix minus
+	single[`1 div 2.`] = `div(1, 2)`
+	single[`1 >> 2.`] = `>>(1, 2)`
+	single[`{}.`] = `{}` // curly bracketed terms §6.3.6
+	single[`{a, b}.`] = `{}(','(a, b))`
+	single[`'a\\b'.`] = `'a\\b'` // escape sequences §6.4.2.1
+	single[`'\x41'.`] = `'A'`
+	single[`"a\n".`] = "\"a\n\""
 	for test, wanted := range single {
 		got, err := Term(test)
 		maybePanic(err)
@@ -92,3 +107,77 @@ func TestEolComment(t *testing.T) {
 		t.Errorf("Expected `two` in %#v", terms)
 	}
 }
+
+func TestDefineOp(t *testing.T) {
+	r, err := NewTermReader(`a x b x c. a x b x c. x a. x a.`)
+	maybePanic(err)
+
+	maybePanic(r.DefineOp(400, "xfy", "x"))
+	if got := r.Next_().String(); got != `x(a, x(b, c))` {
+		t.Errorf("Wrong xfy operator: %s", got)
+	}
+
+	// replaces the previous infix definition
+	maybePanic(r.DefineOp(400, "yfx", "x"))
+	if got := r.Next_().String(); got != `x(x(a, b), c)` {
+		t.Errorf("Wrong yfx operator: %s", got)
+	}
+
+	// prefix and infix operators coexist
+	maybePanic(r.DefineOp(200, "fy", "x"))
+	if got := r.Next_().String(); got != `x(a)` {
+		t.Errorf("Wrong prefix operator: %s", got)
+	}
+
+	// priority 0 removes an operator
+	maybePanic(r.DefineOp(0, "fy", "x"))
+	if _, err := r.Next(); err == nil {
+		t.Errorf("Removed operator still parsed")
+	}
+
+	if err := r.DefineOp(1201, "xfx", "y"); err == nil {
+		t.Errorf("Accepted an invalid priority")
+	}
+	if err := r.DefineOp(100, "xyz", "y"); err == nil {
+		t.Errorf("Accepted an invalid specifier")
+	}
+}
+
+func TestPosition(t *testing.T) {
+	r, err := NewTermReader("one.\n% comment\n  two(\n  three).\n")
+	maybePanic(err)
+
+	r.Next_()
+	if line := r.Position().Line; line != 1 {
+		t.Errorf("Wrong line for first term: %d vs 1", line)
+	}
+	r.Next_()
+	if pos := r.Position(); pos.Line != 3 || pos.Column != 3 {
+		t.Errorf("Wrong position for second term: %s vs 3:3", pos)
+	}
+}
+
+// blockingReader fails the test if it's ever read
+type blockingReader struct{ t *testing.T }
+
+func (r blockingReader) Read(p []byte) (int, error) {
+	r.t.Fatalf("Read beyond the end of the term")
+	return 0, io.EOF
+}
+
+// reading a term shouldn't wait for input after its full stop, like
+// the next line typed at a terminal
+func TestNoReadAhead(t *testing.T) {
+	src := io.MultiReader(strings.NewReader("foo(bar).\n"), blockingReader{t})
+	r, err := NewTermReader(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	x, err := r.Next()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x.String() != "foo(bar)" {
+		t.Errorf("Wrong term: %s", x)
+	}
+}
@@ -0,0 +1,211 @@
+package golog
+
+// Iterating over the solutions to a goal one at a time.
+
+import (
+	"context"
+
+	. "github.com/mndrix/golog/term"
+	"github.com/mndrix/ps"
+)
+
+// Solutions iterates over the solutions to a goal.  Each call to Next
+// steps the machine until it finds another solution, so only as many
+// solutions are computed as the caller asks for.  Typical usage looks
+// something like this:
+//
+//      m := NewMachine().Consult(`nat(0). nat(N) :- nat(M), succ(M, N).`)
+//      solutions := m.Solve(`nat(X).`)
+//      defer solutions.Close()
+//      for i := 0; i < 10 && solutions.Next(); i++ {
+//          fmt.Printf("X = %s\n", solutions.Bindings().ByName_("X"))
+//      }
+//      if err := solutions.Err(); err != nil {
+//          return err
+//      }
+type Solutions interface {
+	// Next finds the next solution, returning false if there are no
+	// more solutions or if an error occurred.  Check Err afterwards
+	// to tell the difference.
+	Next() bool
+
+	// Bindings returns the variable bindings of the solution found by
+	// the most recent call to Next.
+	Bindings() Bindings
+
+	// Last returns true if the solution found by the most recent call
+	// to Next is certainly the last one.  It returns false if there
+	// might be more, which only another call to Next can tell for sure.
+	// Top levels use this to stop asking whether to look for more.
+	Last() bool
+
+	// Err returns the error, if any, which stopped iteration.  An
+	// uncaught Prolog exception is returned as an *Exception.
+	Err() error
+
+	// Close abandons any remaining solutions.  Calling Next after
+	// Close returns false.  It's safe to call Close more than once.
+	Close()
+}
+
+type solutions struct {
+	m      Machine  // nil once all solutions have been found
+	vars   ps.Map   // human-readable variable names from the goal
+	answer Bindings // most recent solution
+	err    error
+}
+
+// Solve returns an iterator over the solutions to goal.  See Solutions.
+func (self *machine) Solve(goal interface{}) Solutions {
+	var m Machine = self
+	if self.maxSteps > 0 {
+		m = self.withRun(&run{})
+	}
+
+	goalTerm := self.toGoal(goal)
+	return &solutions{
+		m:    m.PushConj(goalTerm),
+		vars: Variables(goalTerm), // preserve incoming human-readable names
+	}
+}
+
+// SolveContext is like Solve but stops looking for solutions when ctx
+// is done.  In that case, Err returns ctx.Err().
+func (self *machine) SolveContext(ctx context.Context, goal interface{}) Solutions {
+	goalTerm := self.toGoal(goal)
+	m := self.withRun(&run{ctx: ctx})
+	return &solutions{
+		m:    m.PushConj(goalTerm),
+		vars: Variables(goalTerm),
+	}
+}
+
+// ProveAllContext is like ProveAll but stops when ctx is done.  Instead
+// of panicking, it returns an error if ctx is done or if proving goal
+// throws an uncaught exception.
+func (self *machine) ProveAllContext(ctx context.Context, goal interface{}) ([]Bindings, error) {
+	answers := make([]Bindings, 0)
+
+	solutions := self.SolveContext(ctx, goal)
+	for solutions.Next() {
+		answers = append(answers, solutions.Bindings())
+	}
+	return answers, solutions.Err()
+}
+
+// CanProveContext is like CanProve but stops when ctx is done.  Instead
+// of panicking, it returns an error if ctx is done or if proving goal
+// throws an uncaught exception.
+func (self *machine) CanProveContext(ctx context.Context, goal interface{}) (bool, error) {
+	solutions := self.SolveContext(ctx, goal)
+	defer solutions.Close()
+
+	found := solutions.Next()
+	return found, solutions.Err()
+}
+
+func (s *solutions) Next() bool {
+	var answer Bindings
+	var err error
+
+	s.answer = nil
+	for s.m != nil {
+		prev := s.m
+		s.m, answer, err = s.m.Step()
+		if err == MachineDone {
+			s.m = nil
+			break
+		}
+		if err != nil {
+			prev.(*machine).closeIterators()
+			s.m = nil
+			s.err = err
+			break
+		}
+		if answer != nil {
+			s.answer = answer.WithNames(s.vars)
+			return true
+		}
+	}
+	return false
+}
+
+func (s *solutions) Bindings() Bindings {
+	return s.answer
+}
+
+func (s *solutions) Last() bool {
+	return s.m == nil || !s.m.(*machine).hasChoices()
+}
+
+func (s *solutions) Err() error {
+	return s.err
+}
+
+func (s *solutions) Close() {
+	if s.m != nil {
+		s.m.(*machine).closeIterators()
+	}
+	s.m = nil
+	s.answer = nil
+}
+
+// run tracks resources used while finding solutions to a single goal.
+// Unlike the rest of a machine, a run is mutable.  All machines derived
+// from the one returned by Solve share it.
+type run struct {
+	ctx   context.Context // nil if there's no context
+	steps int64           // steps taken so far
+}
+
+// withRun returns a machine whose steps are counted by r
+func (m *machine) withRun(r *run) *machine {
+	m1 := m.clone()
+	m1.run = r
+	return m1
+}
+
+// SetMaxSteps returns a new machine which gives up after taking n steps
+// to solve a goal.  It throws resource_error(steps) instead.
+// A limit of 0 means no limit.
+func (m *machine) SetMaxSteps(n int64) Machine {
+	m1 := m.clone()
+	m1.maxSteps = n
+	return m1
+}
+
+// SetMaxDepth returns a new machine which throws resource_error(depth)
+// if either its conjunction or disjunction stack grows beyond n
+// elements.  This typically happens because of runaway recursion.
+// A limit of 0 means no limit.
+func (m *machine) SetMaxDepth(n int) Machine {
+	m1 := m.clone()
+	m1.maxDepth = n
+	return m1
+}
+
+// checkLimits makes sure that taking another step won't exceed the
+// machine's limits.  It returns a ball to throw if a limit has been
+// reached or an error if the machine should stop altogether.
+func (m *machine) checkLimits() (Term, error) {
+	if r := m.run; r != nil {
+		if r.ctx != nil {
+			select {
+			case <-r.ctx.Done():
+				return nil, r.ctx.Err()
+			default:
+			}
+		}
+		r.steps++
+		if m.maxSteps > 0 && r.steps > m.maxSteps {
+			return ResourceError("steps"), nil
+		}
+	}
+
+	if m.maxDepth > 0 {
+		if m.conjs.Size() > m.maxDepth || m.disjs.Size() > m.maxDepth {
+			return ResourceError("depth"), nil
+		}
+	}
+	return nil, nil
+}
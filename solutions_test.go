@@ -0,0 +1,205 @@
+package golog
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/mndrix/golog/term"
+)
+
+func TestSolveInfinite(t *testing.T) {
+	m := NewMachine().Consult(`
+        nat(0).
+        nat(N) :- nat(M), succ(M, N).
+    `)
+
+	solutions := m.Solve(`nat(X).`)
+	defer solutions.Close()
+	for i := 0; i < 5; i++ {
+		if !solutions.Next() {
+			t.Fatalf("Ran out of solutions after %d", i)
+		}
+		x := solutions.Bindings().ByName_("X")
+		if x.String() != term.NewInt64(int64(i)).String() {
+			t.Errorf("Wrong solution %d: %s", i, x)
+		}
+	}
+
+	solutions.Close()
+	if solutions.Next() {
+		t.Errorf("Found a solution after Close")
+	}
+	if solutions.Err() != nil {
+		t.Errorf("Unexpected error: %s", solutions.Err())
+	}
+}
+
+func TestSolveFinite(t *testing.T) {
+	m := NewMachine().Consult(`color(red). color(green).`)
+
+	solutions := m.Solve(`color(X).`)
+	n := 0
+	for solutions.Next() {
+		n++
+	}
+	if n != 2 {
+		t.Errorf("Wrong number of solutions: %d vs 2", n)
+	}
+	if solutions.Next() {
+		t.Errorf("Found a solution after the last one")
+	}
+	if solutions.Err() != nil {
+		t.Errorf("Unexpected error: %s", solutions.Err())
+	}
+}
+
+func TestSolveLast(t *testing.T) {
+	m := NewMachine().Consult(`color(red). color(green).`)
+	tests := map[string][]bool{
+		`true.`:                      {true},
+		`X = 1.`:                     {true},
+		`catch(X = 1, _, true).`:     {true},
+		`color(X).`:                  {false, true},
+		`color(X), !.`:               {true},
+		`member(X, [a, b]).`:         {false, false},
+		`(X = 1 ; X = 2).`:           {false, true},
+		`atom_concat(X, Y, ab).`:     {false, false, false},
+		`sub_atom(abc, 1, 1, _, S).`: {true},
+	}
+	for goal, want := range tests {
+		solutions := m.Solve(goal)
+		for i, last := range want {
+			if !solutions.Next() {
+				t.Errorf("%s: ran out of solutions after %d", goal, i)
+				break
+			}
+			if solutions.Last() != last {
+				t.Errorf("%s: solution %d has Last() = %t", goal, i, !last)
+			}
+		}
+		solutions.Close()
+	}
+}
+
+func TestSolveException(t *testing.T) {
+	m := NewMachine().Consult(`color(red). color(X) :- throw(oops(X)).`)
+
+	solutions := m.Solve(`color(X).`)
+	if !solutions.Next() {
+		t.Fatalf("Missing first solution")
+	}
+	if solutions.Next() {
+		t.Errorf("Found a solution after the exception")
+	}
+	e, ok := solutions.Err().(*term.Exception)
+	if !ok {
+		t.Fatalf("Expected an exception, got %#v", solutions.Err())
+	}
+	if e.Ball().Indicator() != "oops/1" {
+		t.Errorf("Wrong exception: %s", e.Ball())
+	}
+}
+
+func TestSolveContext(t *testing.T) {
+	m := NewMachine().Consult(`loop :- loop.`)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := m.ProveAllContext(ctx, `loop.`)
+	if err != context.DeadlineExceeded {
+		t.Errorf("Wrong error: %v", err)
+	}
+
+	// an uncaught exception is returned as an error
+	ok, err := m.CanProveContext(context.Background(), `throw(oops).`)
+	if ok {
+		t.Errorf("Proved a goal which throws")
+	}
+	if _, isException := err.(*term.Exception); !isException {
+		t.Errorf("Expected an exception, got %#v", err)
+	}
+}
+
+func TestMaxSteps(t *testing.T) {
+	m := NewMachine().Consult(`
+        loop :- loop.
+        count(N, N).
+        count(N0, N) :- succ(N0, N1), count(N1, N).
+    `).SetMaxSteps(1000)
+
+	ok, err := m.CanProveContext(context.Background(), `loop.`)
+	if ok {
+		t.Errorf("Proved an infinite loop")
+	}
+	e, isException := err.(*term.Exception)
+	if !isException {
+		t.Fatalf("Expected an exception, got %#v", err)
+	}
+	if e.Ball().String() != term.ResourceError("steps").String() {
+		t.Errorf("Wrong exception: %s", e.Ball())
+	}
+
+	// the limit applies to each goal separately
+	for i := 0; i < 3; i++ {
+		if !m.CanProve(`count(0, 10).`) {
+			t.Errorf("Couldn't prove a small goal after hitting the limit")
+		}
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	m := NewMachine().Consult(`
+        deep(0).
+        deep(N) :- succ(M, N), deep(M), true.
+    `).SetMaxDepth(100)
+
+	if !m.CanProve(`deep(5).`) {
+		t.Errorf("Couldn't prove a shallow goal")
+	}
+	goal := `catch(deep(1000), error(resource_error(R), _), true), R == depth.`
+	if !m.CanProve(goal) {
+		t.Errorf("Deep recursion didn't throw resource_error(depth)")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	m := NewMachine().Consult(`
+        person(alice, 34, [go, prolog]).
+        person(bob, 27, []).
+    `)
+
+	type person struct {
+		Name   string `golog:"N"`
+		Age    int
+		Skills []string
+		Extra  term.Term
+	}
+
+	var people []person
+	solutions := m.Solve(`person(N, Age, Skills).`)
+	for solutions.Next() {
+		var p person
+		if err := solutions.Bindings().Decode(&p); err != nil {
+			t.Fatal(err)
+		}
+		people = append(people, p)
+	}
+	if len(people) != 2 {
+		t.Fatalf("Wrong number of people: %d", len(people))
+	}
+	alice := people[0]
+	if alice.Name != "alice" || alice.Age != 34 || len(alice.Skills) != 2 || alice.Extra != nil {
+		t.Errorf("Wrong person: %+v", alice)
+	}
+	if people[1].Name != "bob" || len(people[1].Skills) != 0 {
+		t.Errorf("Wrong person: %+v", people[1])
+	}
+
+	// values of the wrong type
+	var p struct{ Age string }
+	err := m.ProveAll(`person(bob, Age, _).`)[0].Decode(&p)
+	if _, ok := err.(*term.Exception); !ok {
+		t.Errorf("Decoded an integer into a string: %v", err)
+	}
+}
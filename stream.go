@@ -0,0 +1,623 @@
+package golog
+
+// Prolog streams as described in ISO §7.10.  Each machine has a table of
+// open streams.  Three of them are always present with the aliases
+// user_input, user_output and user_error.  Prolog code refers to a stream
+// either by its alias or by a stream term like '$stream'(3).
+//
+// Like the database, changes to the stream table (opening, closing and
+// changing the current input or output) survive backtracking.  Reading
+// and writing are side effects on the underlying Go values, of course.
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/mndrix/golog/read"
+	. "github.com/mndrix/golog/term"
+	"github.com/mndrix/golog/write"
+	"github.com/mndrix/ps"
+)
+
+// stream is an open Prolog stream.  Unlike most values in a machine,
+// streams are mutable since reading and writing change them.
+type stream struct {
+	id       int64
+	alias    string // "" if the stream has no alias
+	fileName string // "" unless opened by open/3,4
+	mode     string // read, write or append
+	binary   bool   // true for binary streams, false for text streams
+
+	r       *bufio.Reader // nil for output streams
+	w       io.Writer     // nil for input streams
+	closer  io.Closer     // nil unless Golog opened the stream
+	pastEOF bool          // true once reading has hit the end of r
+}
+
+// streamCounter generates unique stream identifiers
+var streamCounter int64
+
+func newStream(alias string) *stream {
+	return &stream{
+		id:    atomic.AddInt64(&streamCounter, 1),
+		alias: alias,
+	}
+}
+
+// newInputStream creates a text stream which reads from r
+func newInputStream(alias string, r io.Reader) *stream {
+	s := newStream(alias)
+	s.mode = "read"
+	s.r = bufio.NewReader(r)
+	return s
+}
+
+// newOutputStream creates a text stream which writes to w
+func newOutputStream(alias string, w io.Writer) *stream {
+	s := newStream(alias)
+	s.mode = "write"
+	s.w = w
+	return s
+}
+
+// Term returns the stream term which refers to s
+func (s *stream) Term() Term {
+	return NewCallable("$stream", NewInt64(s.id))
+}
+
+func (s *stream) isInput() bool {
+	return s.r != nil
+}
+
+func (s *stream) isOutput() bool {
+	return s.w != nil
+}
+
+// isStandard returns true for the streams which are always open
+func (s *stream) isStandard() bool {
+	switch s.alias {
+	case "user_input", "user_output", "user_error":
+		return true
+	}
+	return false
+}
+
+// termReader returns a reader for the next Prolog term on this stream.
+// It reads no further than the character after the term's full stop,
+// so character input continues right after the term.
+func (s *stream) termReader() *read.TermReader {
+	r, err := read.NewTermReader(byteReader{s.r})
+	if err != nil {
+		panic(err) // can't happen with an io.Reader
+	}
+	return r
+}
+
+// byteReader reads one byte at a time.  A lexer reading from it only
+// takes the characters it needs to find its next token, which leaves
+// the rest on the underlying reader for whoever reads next.
+type byteReader struct {
+	r *bufio.Reader
+}
+
+func (br byteReader) Read(p []byte) (int, error) {
+	if len(p) == 0 {
+		return 0, nil
+	}
+	c, err := br.r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	p[0] = c
+	return 1, nil
+}
+
+// flush flushes any output buffered by the stream's writer
+func (s *stream) flush() error {
+	if f, ok := s.w.(interface {
+		Flush() error
+	}); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+// close closes the stream's underlying file, if Golog opened one
+func (s *stream) close() error {
+	if s.isOutput() {
+		if err := s.flush(); err != nil {
+			return err
+		}
+	}
+	if s.closer != nil {
+		return s.closer.Close()
+	}
+	return nil
+}
+
+// streams holds a machine's stream table.  It's immutable.
+type streams struct {
+	byId    ps.Map // stream id => *stream
+	aliases ps.Map // alias => *stream
+	input   *stream
+	output  *stream
+}
+
+// userInput is the user_input stream of every machine.  Sharing it
+// keeps one machine from buffering standard input that another
+// machine, or the Go program itself, wants to read.
+var userInput = newInputStream("user_input", os.Stdin)
+
+// UserInput returns the buffered reader behind the standard user_input
+// stream.  Go code which reads standard input while Golog machines do
+// too should read through it, so that neither takes input the other
+// expected.
+func UserInput() *bufio.Reader {
+	return userInput.r
+}
+
+// standardStreams returns a stream table with just user_input,
+// user_output and user_error
+func standardStreams() *streams {
+	in := userInput
+	out := newOutputStream("user_output", os.Stdout)
+	errs := newOutputStream("user_error", os.Stderr)
+	ss := &streams{
+		byId:    ps.NewMap(),
+		aliases: ps.NewMap(),
+		input:   in,
+		output:  out,
+	}
+	return ss.add(in).add(out).add(errs)
+}
+
+// add returns a new stream table which includes s.  If another stream
+// already has the same alias, s takes over the alias.  If that other
+// stream was current input or output, s replaces it there too.
+func (ss *streams) add(s *stream) *streams {
+	ss1 := *ss
+	key := fmt.Sprintf("%d", s.id)
+	ss1.byId = ss.byId.Set(key, s)
+	if s.alias != "" {
+		if old, ok := ss.aliases.Lookup(s.alias); ok {
+			old := old.(*stream)
+			ss1.byId = ss1.byId.Delete(fmt.Sprintf("%d", old.id))
+			if ss1.input == old {
+				ss1.input = s
+			}
+			if ss1.output == old {
+				ss1.output = s
+			}
+		}
+		ss1.aliases = ss.aliases.Set(s.alias, s)
+	}
+	return &ss1
+}
+
+// remove returns a new stream table without s.  If s was current input
+// or output, the standard stream takes its place.
+func (ss *streams) remove(s *stream) *streams {
+	ss1 := *ss
+	ss1.byId = ss.byId.Delete(fmt.Sprintf("%d", s.id))
+	if s.alias != "" {
+		ss1.aliases = ss.aliases.Delete(s.alias)
+	}
+	if ss1.input == s {
+		ss1.input = ss1.alias("user_input")
+	}
+	if ss1.output == s {
+		ss1.output = ss1.alias("user_output")
+	}
+	return &ss1
+}
+
+// alias returns the stream with the given alias or nil if there is none
+func (ss *streams) alias(name string) *stream {
+	if s, ok := ss.aliases.Lookup(name); ok {
+		return s.(*stream)
+	}
+	return nil
+}
+
+// lookup finds the stream referred to by a stream term or alias.  If
+// there's no such stream, it returns the appropriate ISO error term.
+func (ss *streams) lookup(t Term) (*stream, Term) {
+	if IsVariable(t) {
+		return nil, InstantiationError()
+	}
+	if IsAtom(t) {
+		if s := ss.alias(t.(*Atom).Name()); s != nil {
+			return s, nil
+		}
+		return nil, ExistenceError("stream", t)
+	}
+	if t.Indicator() == "$stream/1" {
+		id := t.(Callable).Arguments()[0]
+		if s, ok := ss.byId.Lookup(id.String()); ok {
+			return s.(*stream), nil
+		}
+		return nil, ExistenceError("stream", t)
+	}
+	return nil, DomainError("stream_or_alias", t)
+}
+
+// all returns every stream in the table, oldest first
+func (ss *streams) all() []*stream {
+	var all []*stream
+	ss.byId.ForEach(func(_ string, s interface{}) {
+		all = append(all, s.(*stream))
+	})
+	sort.Slice(all, func(i, j int) bool { return all[i].id < all[j].id })
+	return all
+}
+
+func (m *machine) setStreams(ss *streams) *machine {
+	m1 := m.clone()
+	m1.streams = ss
+	return m1
+}
+
+func (m *machine) AttachInput(alias string, r io.Reader) Machine {
+	return m.setStreams(m.streams.add(newInputStream(alias, r)))
+}
+
+func (m *machine) AttachOutput(alias string, w io.Writer) Machine {
+	return m.setStreams(m.streams.add(newOutputStream(alias, w)))
+}
+
+// openFile opens the named file for s in the stream's mode
+func (s *stream) openFile(name string) error {
+	var f *os.File
+	var err error
+	switch s.mode {
+	case "read":
+		f, err = os.Open(name)
+	case "write":
+		f, err = os.Create(name)
+	case "append":
+		f, err = os.OpenFile(name, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0666)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.fileName = name
+	s.closer = f
+	if s.mode == "read" {
+		s.r = bufio.NewReader(f)
+	} else {
+		s.w = f
+	}
+	return nil
+}
+
+// inputStream finds the stream from which a predicate like get_char/1,2
+// should read.  If args has more than arity elements, the first one
+// names the stream.  Otherwise, it's the current input stream.  binary
+// says whether the predicate reads bytes or characters.  If the stream
+// can't be used, it returns the appropriate ISO error.
+func inputStream(m Machine, args []Term, arity int, binary bool) (*stream, ForeignReturn) {
+	ms := m.(*machine).streams
+	if len(args) <= arity {
+		return checkStreamType(ms.input, ms.input.Term(), "input", binary)
+	}
+	s, ball := ms.lookup(args[0])
+	if ball != nil {
+		return nil, ForeignThrow(ball)
+	}
+	if !s.isInput() {
+		return nil, ForeignThrow(PermissionError("input", "stream", args[0]))
+	}
+	return checkStreamType(s, args[0], "input", binary)
+}
+
+// outputStream is like inputStream but for predicates which write
+func outputStream(m Machine, args []Term, arity int, binary bool) (*stream, ForeignReturn) {
+	ms := m.(*machine).streams
+	if len(args) <= arity {
+		return checkStreamType(ms.output, ms.output.Term(), "output", binary)
+	}
+	s, ball := ms.lookup(args[0])
+	if ball != nil {
+		return nil, ForeignThrow(ball)
+	}
+	if !s.isOutput() {
+		return nil, ForeignThrow(PermissionError("output", "stream", args[0]))
+	}
+	return checkStreamType(s, args[0], "output", binary)
+}
+
+// checkStreamType makes sure that byte I/O happens on binary streams
+// and character I/O on text streams
+func checkStreamType(s *stream, t Term, operation string, binary bool) (*stream, ForeignReturn) {
+	switch {
+	case binary && !s.binary:
+		return nil, ForeignThrow(PermissionError(operation, "text_stream", t))
+	case !binary && s.binary:
+		return nil, ForeignThrow(PermissionError(operation, "binary_stream", t))
+	}
+	return s, nil
+}
+
+// checkStreamTerm makes sure that t is either a variable or a stream
+// term.  Aliases aren't allowed.
+func checkStreamTerm(t Term) (ForeignReturn, bool) {
+	if IsVariable(t) || t.Indicator() == "$stream/1" {
+		return nil, true
+	}
+	return ForeignThrow(DomainError("stream", t)), false
+}
+
+// inputChar implements get_char, get_code, peek_char and peek_code.
+// If peek is true, the character isn't consumed.  If code is true,
+// the result is a character code instead of a one character atom.
+func inputChar(m Machine, args []Term, peek, code bool) ForeignReturn {
+	s, ret := inputStream(m, args, 1, false)
+	if ret != nil {
+		return ret
+	}
+	c := args[len(args)-1]
+	switch {
+	case IsVariable(c):
+	case code && !IsInteger(c):
+		return ForeignThrow(TypeError("integer", c))
+	case code && !isInCharacterCode(c):
+		return ForeignThrow(RepresentationError("in_character_code"))
+	case !code && !isCharacter(c) && c.String() != "end_of_file":
+		return ForeignThrow(TypeError("in_character", c))
+	}
+
+	r, _, err := s.r.ReadRune()
+	if err == io.EOF {
+		s.pastEOF = !peek
+		if code {
+			return ForeignUnify(c, NewInt64(-1))
+		}
+		return ForeignUnify(c, NewAtom("end_of_file"))
+	}
+	if err != nil {
+		return ForeignThrow(SystemError(err.Error()))
+	}
+	if peek {
+		s.r.UnreadRune()
+	}
+	if code {
+		return ForeignUnify(c, NewInt64(int64(r)))
+	}
+	return ForeignUnify(c, NewAtom(string(r)))
+}
+
+// inputByte implements get_byte and peek_byte
+func inputByte(m Machine, args []Term, peek bool) ForeignReturn {
+	s, ret := inputStream(m, args, 1, true)
+	if ret != nil {
+		return ret
+	}
+	b := args[len(args)-1]
+	if !IsVariable(b) && !isByte(b) && b.String() != "-1" {
+		return ForeignThrow(TypeError("in_byte", b))
+	}
+
+	x, err := s.r.ReadByte()
+	if err == io.EOF {
+		s.pastEOF = !peek
+		return ForeignUnify(b, NewInt64(-1))
+	}
+	if err != nil {
+		return ForeignThrow(SystemError(err.Error()))
+	}
+	if peek {
+		s.r.UnreadByte()
+	}
+	return ForeignUnify(b, NewInt64(int64(x)))
+}
+
+// writeString writes text to an output stream
+func writeString(s *stream, text string) ForeignReturn {
+	if _, err := io.WriteString(s.w, text); err != nil {
+		return ForeignThrow(SystemError(err.Error()))
+	}
+	return ForeignTrue()
+}
+
+// writeTerm implements the write family of predicates.  The last
+// element of args is the term to write.  If there's an element before
+// it, that's the stream.
+func writeTerm(m Machine, args []Term, opts *write.Options) ForeignReturn {
+	s, ret := outputStream(m, args, 1, false)
+	if ret != nil {
+		return ret
+	}
+	opts.Operators = m.(*machine).ops
+	return writeString(s, write.String(args[len(args)-1], opts))
+}
+
+// termText returns the text which write/1 (or writeq/1 if quoted is
+// true) produces for t with operator table ops
+func termText(t Term, quoted bool, ops *read.Operators) string {
+	opts := &write.Options{Quoted: quoted, NumberVars: true, Operators: ops}
+	return write.String(t, opts)
+}
+
+// writeOptions converts a list of write options, as described for
+// write_term/2, into the equivalent Go options
+func writeOptions(options Term) (*write.Options, ForeignReturn) {
+	if ret, ok := checkList(options); !ok {
+		return nil, ret
+	}
+	opts := &write.Options{}
+	for _, opt := range ProperListToTermSlice(options) {
+		if IsVariable(opt) {
+			return nil, ForeignThrow(InstantiationError())
+		}
+		bad := ForeignThrow(DomainError("write_option", opt))
+		if !IsCompound(opt) || opt.(*Compound).Arity() != 1 {
+			return nil, bad
+		}
+		value := opt.(*Compound).Arguments()[0]
+		if IsVariable(value) {
+			return nil, ForeignThrow(InstantiationError())
+		}
+
+		switch opt.(*Compound).Name() {
+		case "quoted", "ignore_ops", "numbervars":
+			var flag bool
+			switch value.String() {
+			case "true":
+				flag = true
+			case "false":
+				flag = false
+			default:
+				return nil, bad
+			}
+			switch opt.(*Compound).Name() {
+			case "quoted":
+				opts.Quoted = flag
+			case "ignore_ops":
+				opts.IgnoreOps = flag
+			case "numbervars":
+				opts.NumberVars = flag
+			}
+		case "max_depth":
+			if !isNonNegativeInteger(value) {
+				return nil, bad
+			}
+			opts.MaxDepth = int(value.(*Integer).Value().Int64())
+		case "variable_names":
+			if ret, ok := checkList(value); !ok {
+				return nil, ret
+			}
+			opts.VariableNames = make(map[*Variable]string)
+			for _, pair := range ProperListToTermSlice(value) {
+				if pair.Indicator() != "=/2" {
+					return nil, bad
+				}
+				name := pair.(*Compound).Arguments()[0]
+				v := pair.(*Compound).Arguments()[1]
+				if !IsAtom(name) {
+					return nil, bad
+				}
+				if IsVariable(v) {
+					opts.VariableNames[v.(*Variable)] = name.(*Atom).Name()
+				}
+			}
+		default:
+			return nil, bad
+		}
+	}
+	return opts, nil
+}
+
+// isCharacter returns true if t is a one character atom
+func isCharacter(t Term) bool {
+	return IsAtom(t) && utf8.RuneCountInString(t.(*Atom).Name()) == 1
+}
+
+// isInCharacterCode returns true if t is a character code or -1
+func isInCharacterCode(t Term) bool {
+	n := t.(*Integer).Value()
+	return n.IsInt64() && (n.Int64() == -1 || utf8.ValidRune(rune(n.Int64())))
+}
+
+// isByte returns true if t is an integer between 0 and 255
+func isByte(t Term) bool {
+	if !IsInteger(t) {
+		return false
+	}
+	n := t.(*Integer).Value()
+	return n.IsInt64() && n.Int64() >= 0 && n.Int64() <= 255
+}
+
+// properties returns the stream properties of s, as described for
+// stream_property/2
+func (s *stream) properties() []Term {
+	var props []Term
+	if s.fileName != "" {
+		props = append(props, NewCallable("file_name", NewAtom(s.fileName)))
+	}
+	props = append(props, NewCallable("mode", NewAtom(s.mode)))
+	if s.isInput() {
+		props = append(props, NewAtom("input"))
+	} else {
+		props = append(props, NewAtom("output"))
+	}
+	if s.alias != "" {
+		props = append(props, NewCallable("alias", NewAtom(s.alias)))
+	}
+	streamType := "text"
+	if s.binary {
+		streamType = "binary"
+	}
+	props = append(props, NewCallable("type", NewAtom(streamType)))
+	return props
+}
+
+// isStreamProperty returns true if t might be a stream property
+func isStreamProperty(t Term) bool {
+	switch t.Indicator() {
+	case "alias/1", "file_name/1", "mode/1", "input/0", "output/0", "type/1":
+		return true
+	}
+	return false
+}
+
+// nextTerm is like r.Next but returns an error, instead of panicking,
+// when the reader finds some kinds of malformed text.  The rest of the
+// bad term is skipped, as it is for other syntax errors.
+func nextTerm(r *read.TermReader) (t Term, err error) {
+	defer func() {
+		if x := recover(); x != nil {
+			r.SkipTerm()
+			t, err = nil, fmt.Errorf("%v", x)
+		}
+	}()
+	return r.Next()
+}
+
+// variableList returns a list of the variables in t, as needed by
+// read_term's variables option
+func variableList(t Term) Term {
+	var vars []Term
+	for _, v := range OrderedVariables(t) {
+		vars = append(vars, v)
+	}
+	return NewTermList(vars)
+}
+
+// variableNames returns a list of Name=Var terms for the named
+// variables in t, as needed by read_term's variable_names option.  If
+// singletons is true, only variables which appear once are included
+// (as for the singletons option).
+func variableNames(t Term, singletons bool) Term {
+	counts := make(map[*Variable]int)
+	countVariables(t, counts)
+
+	var names []Term
+	for _, v := range OrderedVariables(t) {
+		if v.Name == "_" {
+			continue
+		}
+		if singletons && (counts[v] > 1 || v.Name[0] == '_') {
+			continue
+		}
+		names = append(names, NewCallable("=", NewAtom(v.Name), v))
+	}
+	return NewTermList(names)
+}
+
+// countVariables counts how many times each variable appears in t
+func countVariables(t Term, counts map[*Variable]int) {
+	switch x := t.(type) {
+	case *Variable:
+		counts[x]++
+	case *Compound:
+		for _, arg := range x.Arguments() {
+			countVariables(arg, counts)
+		}
+	}
+}
@@ -0,0 +1,214 @@
+package golog
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	var out bytes.Buffer
+	m := NewMachine().AttachOutput("user_output", &out)
+
+	m.ProveAll(`write('hello world'), nl, writeq('hello world'), nl.`)
+	m.ProveAll(`write_term(foo('A'), [quoted(true)]), put_char(x), put_code(0'y), nl.`)
+	m.ProveAll(`format("~w-~a~n", [x, y]), format(user_output, '~d~n', 7).`)
+	m.ProveAll(`write_term(f(X, 'A'+1), [quoted(true), variable_names(['X'=X])]), nl.`)
+	m.ProveAll(`write_canonical([a, 1 + 2]), nl, print('$VAR'(1) - {x}), nl.`)
+	want := "hello world\n'hello world'\nfoo('A')xy\nx-y\n7\n" +
+		"f(X,'A'+1)\n[a,+(1,2)]\nB-{x}\n"
+	if got := out.String(); got != want {
+		t.Errorf("Wrong output: %q vs %q", got, want)
+	}
+}
+
+func TestRead(t *testing.T) {
+	in := strings.NewReader(`foo(X, Y, X). bar.`)
+	m := NewMachine().AttachInput("user_input", in)
+
+	proofs := m.ProveAll(`
+        read_term(T, [variable_names(Ns), singletons(Ss)]),
+        read(U),
+        read(V).
+    `)
+	if len(proofs) != 1 {
+		t.Fatalf("Wrong number of proofs: %d", len(proofs))
+	}
+	tests := map[string]string{
+		"T":  `foo(X, Y, X)`,
+		"Ns": `[=('X', X),=('Y', Y)]`,
+		"Ss": `[=('Y', Y)]`,
+		"U":  `bar`,
+		"V":  `end_of_file`,
+	}
+	for name, want := range tests {
+		if got := proofs[0].ByName_(name).String(); got != want {
+			t.Errorf("Wrong %s: %s vs %s", name, got, want)
+		}
+	}
+}
+
+func TestReadThenGetChar(t *testing.T) {
+	tests := map[string]string{
+		"a.\nb c.\n": `read(a), get_char(b), read(c).`,
+		"a.  b.\n":   `read(a), get_char(' '), read(b), get_char(end_of_file).`,
+		"a.\n":       `read(a), get_char(end_of_file).`,
+	}
+	for text, goal := range tests {
+		m := NewMachine().AttachInput("user_input", strings.NewReader(text))
+		if !m.CanProve(goal) {
+			t.Errorf("%q: character input didn't continue after the term", text)
+		}
+	}
+}
+
+func TestReadSyntaxError(t *testing.T) {
+	for _, text := range []string{"foo(. bar.", "foo(a . bar.\n"} {
+		m := NewMachine().AttachInput("in", strings.NewReader(text))
+		goal := `catch(read(in, _), E, true), nonvar(E), E = error(syntax_error(_), _), read(in, bar).`
+		if !m.CanProve(goal) {
+			t.Errorf("%q: reading didn't continue after a syntax error", text)
+		}
+	}
+}
+
+func TestCharacterInput(t *testing.T) {
+	in := strings.NewReader("ab")
+	m := NewMachine().AttachInput("in", in)
+	goal := `
+        peek_char(in, a),
+        get_char(in, a),
+        get_code(in, 0'b),
+        get_char(in, end_of_file),
+        peek_code(in, -1),
+        at_end_of_stream(in).
+    `
+	if !m.CanProve(goal) {
+		t.Errorf("Character input failed")
+	}
+}
+
+func TestSetOutput(t *testing.T) {
+	var out bytes.Buffer
+	m := NewMachine().AttachOutput("log", &out)
+
+	// changes to the current output survive backtracking
+	m.ProveAll(`( set_output(log), fail ; true ), write(one), current_output(S), write(S, two).`)
+	if got := out.String(); got != "onetwo" {
+		t.Errorf("Wrong output: %q", got)
+	}
+}
+
+func TestOpenClose(t *testing.T) {
+	dir, err := ioutil.TempDir("", "golog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "data.txt")
+
+	m := NewMachine().Consult(`
+        save(File) :-
+            open(File, write, S, [alias(data)]),
+            stream_property(S, alias(data)),
+            write(data, hello),
+            put_char(data, '.'),
+            close(S).
+        load(File, T) :-
+            open(File, read, S),
+            read(S, T),
+            close(S).
+    `)
+	if !m.CanProve(`save('` + file + `'), load('` + file + `', hello).`) {
+		t.Errorf("Couldn't write and then read a file")
+	}
+
+	errors := map[string]string{
+		`open(nonexistent, read, _)`:                              `existence_error(source_sink, nonexistent)`,
+		`open(foo, sideways, _)`:                                  `domain_error(io_mode, sideways)`,
+		`open(foo, read, bar)`:                                    `uninstantiation_error(bar)`,
+		`write(user_input, x)`:                                    `permission_error(output, stream, user_input)`,
+		`get_char(user_output, _)`:                                `permission_error(input, stream, user_output)`,
+		`put_byte(user_output, 1)`:                                `permission_error(output, text_stream, user_output)`,
+		`get_char(nope, _)`:                                       `existence_error(stream, nope)`,
+		`open('` + file + `', read, S), close(S), get_char(S, _)`: `existence_error(stream, '$stream'(`,
+	}
+	for goal, want := range errors {
+		proofs := m.ProveAll(`catch((` + goal + `), error(E, _), true).`)
+		if len(proofs) != 1 {
+			t.Errorf("%s: wrong number of proofs: %d", goal, len(proofs))
+			continue
+		}
+		if got := proofs[0].ByName_("E").String(); !strings.HasPrefix(got, want) {
+			t.Errorf("%s: wrong error %s vs %s", goal, got, want)
+		}
+	}
+}
+
+func TestOperators(t *testing.T) {
+	var out bytes.Buffer
+	in := strings.NewReader(`a ===> b.`)
+	m := NewMachine().
+		AttachInput("user_input", in).
+		AttachOutput("user_output", &out).
+		Consult(`:- op(700, xfx, ===>).`)
+
+	if !m.CanProve(`read(X), X = ===>(a, b).`) {
+		t.Errorf("read/1 didn't use the operator table")
+	}
+	m.ProveAll(`writeq(===>(c, d)), nl.`)
+	m.ProveAll(`op(200, xf, days), writeq(days(2)), nl.`)
+	m.ProveAll(`writeq(days(2)), nl.`)
+	want := "c===>d\n2 days\ndays(2)\n"
+	if got := out.String(); got != want {
+		t.Errorf("Wrong output: %q vs %q", got, want)
+	}
+}
+
+// reading part of a stream shouldn't leave goroutines running
+func TestCloseUnfinishedRead(t *testing.T) {
+	dir, err := ioutil.TempDir("", "golog")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "terms.pl")
+	text := strings.Repeat("a. ", 1000)
+	if err := ioutil.WriteFile(file, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewMachine()
+	goal := `open('` + file + `', read, S), read(S, a), close(S).`
+	before := runtime.NumGoroutine()
+	for i := 0; i < 50; i++ {
+		if !m.CanProve(goal) {
+			t.Fatalf("Couldn't read the first term")
+		}
+	}
+	for i := 0; i < 100 && runtime.NumGoroutine() > before+10; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine() - before; n > 10 {
+		t.Errorf("%d goroutines left running", n)
+	}
+}
+
+// machines shouldn't buffer standard input separately
+func TestSharedUserInput(t *testing.T) {
+	m1 := NewMachine().(*machine)
+	m2 := NewBlankMachine().(*machine)
+	s1 := m1.streams.alias("user_input")
+	s2 := m2.streams.alias("user_input")
+	if s1 != s2 {
+		t.Errorf("Machines have different user_input streams")
+	}
+	if s1.r != UserInput() {
+		t.Errorf("UserInput() isn't the user_input stream's reader")
+	}
+}
@@ -0,0 +1,172 @@
+% Tests for is/2 with the evaluable functors
+%
+% As defined in ISO §9
+:- use_module(library(tap)).
+
+% integers stay integers
+addition :-
+    X is 1 + 2,
+    X == 3.
+exact_division :-
+    X is 6 / 3,
+    X == 2.
+inexact_division :-
+    X is 7 / 2,
+    X =:= 3.5.
+integer_division :-
+    X is -7 // 2,
+    X == -3.
+div :-
+    X is -7 div 2,
+    X == -4.
+mod :-
+    X is -7 mod 2,
+    X == 1.
+rem :-
+    X is -7 rem 2,
+    X == -1.
+power :-
+    X is 2 ^ 100,
+    X == 1267650600228229401496703205376.
+power_float :-
+    X is 2 ** 3,
+    X =:= 8.0.
+negative_power(throws(error(type_error(float, 2), _))) :-
+    _ is 2 ^ -1.
+negative_power_one :-
+    X is -1 ^ -3,
+    X == -1.
+huge_power(throws(error(resource_error(memory), _))) :-
+    _ is 3 ^ 100000000000.
+huge_power_of_one :-
+    X is 1 ^ 100000000000000000000,
+    X == 1.
+huge_power_of_minus_one :-
+    X is -1 ^ 100000000000000000001,
+    Y is -1 ^ 100000000000000000000,
+    X == -1,
+    Y == 1.
+huge_power_of_zero :-
+    X is 0 ^ 100000000000000000000,
+    Y is 0 ^ 0,
+    X == 0,
+    Y == 1.
+
+% integer functions
+abs :-
+    X is abs(-3),
+    X == 3.
+sign :-
+    X is sign(-3),
+    X == -1.
+min :-
+    X is min(2, 3),
+    X == 2.
+max :-
+    X is max(2, 3),
+    X == 3.
+gcd :-
+    X is gcd(12, 18),
+    X == 6.
+msb :-
+    X is msb(1000),
+    X == 9.
+bit_and :-
+    X is 12 /\ 10,
+    X == 8.
+bit_or :-
+    X is 12 \/ 10,
+    X == 14.
+xor :-
+    X is xor(12, 10),
+    X == 6.
+bit_not :-
+    X is \ 5,
+    X == -6.
+shift_left :-
+    X is 1 << 4,
+    X == 16.
+shift_right :-
+    X is 16 >> 2,
+    X == 4.
+
+% conversion to integers
+floor :-
+    X is floor(-2.5),
+    X == -3.
+ceiling :-
+    X is ceiling(2.1),
+    X == 3.
+truncate :-
+    X is truncate(-2.5),
+    X == -2.
+round :-
+    X is round(2.5),
+    X == 3.
+integer :-
+    X is integer(-2.5),
+    X == -3.
+float_integer_part :-
+    X is float_integer_part(-2.5),
+    X =:= -2.
+float_fractional_part :-
+    X is float_fractional_part(2.75),
+    X =:= 0.75.
+
+% float functions
+float :-
+    X is float(3),
+    X == 3.0.
+sqrt :-
+    X is sqrt(16),
+    X =:= 4.
+trig :-
+    X is sin(0) + cos(0) + atan(0),
+    X =:= 1.
+atan2 :-
+    X is atan2(1, 1) * 4,
+    X =:= pi.
+exp_log :-
+    X is log(exp(2)),
+    X =:= 2.
+log_base :-
+    X is log(2, 8),
+    X =:= 3.
+constants :-
+    X is round(e),
+    X == 3,
+    Y is round(pi),
+    Y == 3.
+random :-
+    X is random(10),
+    Y is X mod 10,  % type_error unless X is an integer
+    X == Y.
+random_float :-
+    X is random_float,
+    0 is truncate(X).
+
+% errors
+unbound(throws(error(instantiation_error, _))) :-
+    _ is _ + 1.
+not_evaluable(throws(error(type_error(evaluable, foo/0), _))) :-
+    _ is foo + 1.
+unknown_function(throws(error(type_error(evaluable, foo/2), _))) :-
+    _ is foo(1, 2).
+zero_divisor(throws(error(evaluation_error(zero_divisor), _))) :-
+    _ is 1 / 0 .
+integer_zero_divisor(throws(error(evaluation_error(zero_divisor), _))) :-
+    _ is 1 mod 0 .
+sqrt_negative(throws(error(evaluation_error(undefined), _))) :-
+    _ is sqrt(-1).
+log_zero(throws(error(evaluation_error(undefined), _))) :-
+    _ is log(0).
+float_to_integer_function(throws(error(type_error(integer, 2.5), _))) :-
+    _ is 2.5 // 1.
+float_overflow_add(throws(error(evaluation_error(float_overflow), _))) :-
+    _ is 1.0e308 + 1.0e308.
+float_overflow_minus(throws(error(evaluation_error(float_overflow), _))) :-
+    _ is -1.0e308 - 1.0e308.
+float_overflow_multiply(throws(error(evaluation_error(float_overflow), _))) :-
+    _ is 1.0e308 * 10.
+float_overflow_divide(throws(error(evaluation_error(float_overflow), _))) :-
+    _ is 1.0e308 / 0.1.
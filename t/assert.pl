@@ -0,0 +1,60 @@
+% Tests for asserta/1 and assertz/1
+%
+% asserta/1 and assertz/1 are defined in ISO §8.9.1 and §8.9.2
+:- use_module(library(tap)).
+
+'assertz adds at the end' :-
+    assertz(counter(1)),
+    assertz(counter(2)),
+    findall(X, counter(X), Xs),
+    Xs = [1,2].
+'asserta adds at the start' :-
+    asserta(counter(1)),
+    asserta(counter(2)),
+    findall(X, counter(X), Xs),
+    Xs = [2,1].
+'assert is like assertz' :-
+    assert(counter(1)),
+    assert(counter(2)),
+    findall(X, counter(X), Xs),
+    Xs = [1,2].
+'assert a rule' :-
+    assertz((double(X, Y) :- Y is X * 2)),
+    double(3, Six),
+    Six = 6.
+'assert a rule with a variable body' :-
+    assertz((run(G) :- G)),
+    run(true).
+'later bindings are ignored' :-
+    assertz(thing(X)),
+    X = 1,
+    thing(2).
+'assert survives backtracking' :-
+    ( assertz(seen(a)), fail
+    ; true
+    ),
+    seen(a).
+'failure driven loop' :-
+    ( ( X = 1 ; X = 2 ), assertz(n(X)), fail
+    ; true
+    ),
+    findall(N, n(N), Ns),
+    Ns = [1,2].
+'assert inside findall' :-
+    findall(x, assertz(found(x)), _),
+    found(x).
+'assert inside negation' :-
+    \+ \+ assertz(negated(1)),
+    negated(1).
+'assert survives exceptions' :-
+    catch((assertz(caught(1)), throw(oops)), oops, true),
+    caught(1).
+
+'assert a variable'(throws(instantiation_error)) :-
+    assertz(_).
+'assert a number'(throws(type_error(callable, 4))) :-
+    assertz(4).
+'assert a number body'(throws(type_error(callable, 4))) :-
+    assertz((foo :- 4)).
+'assert a builtin'(throws(permission_error(modify, static_procedure, atom_codes/2))) :-
+    assertz(atom_codes(_, _)).
@@ -0,0 +1,31 @@
+% Tests for atom_chars/2
+%
+% atom_chars/2 is defined in ISO §8.16.4
+:- use_module(library(tap)).
+
+% Tests derived from examples in ISO §8.16.4.4
+empty_atom :-
+    atom_chars('', L),
+    L == [].
+nil :-
+    atom_chars([], L),
+    L == ['[', ']'].
+ant :-
+    atom_chars(ant, L),
+    L == [a, n, t].
+sop :-
+    atom_chars(A, [s, o, p]),
+    A == sop.
+partial_list :-
+    atom_chars('North', ['N' | X]),
+    X == [o, r, t, h].
+missing_a_char(fail) :-
+    atom_chars(soap, [s, o, p]).
+all_variables(throws(instantiation_error)) :-
+    atom_chars(_, _).
+
+not_a_character(throws(type_error(character, 1))) :-
+    atom_chars(_, [a, 1]).
+unicode :-
+    atom_chars('λx', L),
+    L == ['λ', x].
@@ -24,8 +24,8 @@ partial_list :-
     X = [0'o, 0'r, 0't, 0'h].
 missing_a_code(fail) :-
     atom_codes(soap, [0's, 0'o, 0'p]). % ' for syntax
-%all_variables(throws(instantiation_error)) :-
-%    atom_codes(_,_).
+all_variables(throws(instantiation_error)) :-
+    atom_codes(_,_).
 
 
 % Tests derived from Prolog: The Standard p. 53
@@ -34,8 +34,8 @@ anna_var :-
     X = anna.
 anna_ground :-
     atom_codes(anna, [0'a, 0'n, 0'n, 0'a]).
-%var_and_list_with_var(throws(instantiation_error)) :-
-%    atom_codes(_, [0'a | _]).  % ' for syntax
+var_and_list_with_var(throws(instantiation_error)) :-
+    atom_codes(_, [0'a | _]).  % ' for syntax
 
 
 % Tests covering edge cases I've encountered
@@ -0,0 +1,33 @@
+% Tests for atom_concat/3
+%
+% atom_concat/3 is defined in ISO §8.16.2
+:- use_module(library(tap)).
+
+% Tests derived from examples in ISO §8.16.2.4
+hello_world :-
+    atom_concat('hello', ' world', A),
+    A == 'hello world'.
+prefix :-
+    atom_concat(T, ' world', 'small world'),
+    T == small.
+wrong_suffix(fail) :-
+    atom_concat(hello, ' world', 'small world').
+all_splits :-
+    findall(T1-T2, atom_concat(T1, T2, hello), L),
+    L == [''-hello, h-ello, he-llo, hel-lo, hell-o, hello-''].
+unbound(throws(instantiation_error)) :-
+    atom_concat(small, _, _).
+
+number_argument(throws(type_error(atom, 1))) :-
+    atom_concat(1, a, _).
+unicode :-
+    atom_concat(X, 'ξη', 'λέξη'),
+    X == 'λέ'.
+suffix :-
+    atom_concat(hello, T, 'hello world'),
+    T == ' world'.
+wrong_prefix(fail) :-
+    atom_concat(goodbye, _, 'hello world').
+unicode_suffix :-
+    atom_concat('λέ', X, 'λέξη'),
+    X == 'ξη'.
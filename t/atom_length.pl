@@ -0,0 +1,26 @@
+% Tests for atom_length/2
+%
+% atom_length/2 is defined in ISO §8.16.1
+:- use_module(library(tap)).
+
+% Tests derived from examples in ISO §8.16.1.4
+enchanted_evening :-
+    atom_length('enchanted evening', N),
+    N == 17.
+empty :-
+    atom_length('', N),
+    N == 0 .
+wrong_length(fail) :-
+    atom_length(scarlet, 5).
+unbound(throws(instantiation_error)) :-
+    atom_length(_, 4).
+number_argument(throws(type_error(atom, 123))) :-
+    atom_length(123, _).
+not_an_integer(throws(type_error(integer, '4'))) :-
+    atom_length(atom, '4').
+
+negative(throws(domain_error(not_less_than_zero, -1))) :-
+    atom_length(atom, -1).
+unicode :-
+    atom_length('λέξη', N),
+    N == 4.
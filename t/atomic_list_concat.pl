@@ -0,0 +1,31 @@
+% Tests for atomic_list_concat/2 and atomic_list_concat/3
+%
+% These are SWI-Prolog extensions
+:- use_module(library(tap)).
+
+join :-
+    atomic_list_concat([a, 'B', 1, 2.5], A),
+    A == 'aB12.5'.
+empty :-
+    atomic_list_concat([], A),
+    A == ''.
+separator :-
+    atomic_list_concat([a, b, c], ', ', A),
+    A == 'a, b, c'.
+split :-
+    atomic_list_concat(L, ',', 'a,b,,c'),
+    L == [a, b, '', c].
+split_partial :-
+    atomic_list_concat([a, X, c], '-', 'a-b-c'),
+    X == b.
+split_unicode :-
+    atomic_list_concat(L, 'λ', 'aλbλc'),
+    L == [a, b, c].
+wrong_split(fail) :-
+    atomic_list_concat([a, b], '-', 'a+b').
+empty_separator(throws(domain_error(non_empty_atom, ''))) :-
+    atomic_list_concat(_, '', abc).
+unbound_element(throws(instantiation_error)) :-
+    atomic_list_concat([a, _], _).
+compound_element(throws(type_error(atomic, f(x)))) :-
+    atomic_list_concat([a, f(x)], _).
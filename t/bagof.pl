@@ -0,0 +1,105 @@
+% Tests for bagof/3, setof/3 and aggregate_all/3
+%
+% As defined in ISO §8.10.  aggregate_all/3 follows SWI-Prolog.
+age(peter, 7).
+age(ann, 11).
+age(pat, 8).
+age(tom, 5).
+age(mike, 11).
+
+class(a, x).
+class(b, y).
+class(c, x).
+class(a, z).
+
+member(X, [X|_]).
+member(X, [_|T]) :-
+    member(X, T).
+
+:- use_module(library(tap)).
+
+% bagof/3
+bagof_simple :-
+    bagof(X, member(X, [c, a, b, a]), L),
+    L == [c, a, b, a].
+bagof_no_solutions(fail) :-
+    bagof(X, fail, X).
+bagof_free_variables :-
+    findall(K-L, bagof(X, class(X, K), L), Groups),
+    Groups == [x-[a, c], y-[b], z-[a]].
+bagof_existential :-
+    bagof(X, K^class(X, K), L),
+    L == [a, b, c, a].
+bagof_nested_existential :-
+    bagof(N, N^A^age(N, A), L),
+    L == [peter, ann, pat, tom, mike].
+bagof_group_by_age :-
+    bagof(N, age(N, 11), L),
+    L == [ann, mike].
+bagof_unbound(throws(error(instantiation_error, _))) :-
+    bagof(_, _, _).
+bagof_not_callable(throws(error(type_error(callable, 1), _))) :-
+    bagof(_, 1, _).
+
+% setof/3
+setof_sorts :-
+    setof(X, member(X, [c, a, b, a]), L),
+    L == [a, b, c].
+setof_existential :-
+    setof(A-N, age(N, A), L),
+    L == [5-tom, 7-peter, 8-pat, 11-ann, 11-mike].
+setof_groups :-
+    findall(A-L, setof(N, age(N, A), L), Groups),
+    Groups == [5-[tom], 7-[peter], 8-[pat], 11-[ann, mike]].
+setof_no_solutions(fail) :-
+    setof(X, member(X, []), X).
+setof_keeps_variables :-
+    setof(X, member(X, [A, B, A]), L),
+    L = [P, Q],
+    P \== Q.
+
+% ^/2 outside bagof
+caret :-
+    _ ^ true.
+
+% aggregate_all/3
+count :-
+    aggregate_all(count, age(_, _), C),
+    C == 5.
+count_none :-
+    aggregate_all(count, fail, C),
+    C == 0 .
+sum :-
+    aggregate_all(sum(A), age(_, A), S),
+    S == 42.
+max :-
+    aggregate_all(max(A), age(_, A), M),
+    M == 11.
+max_none(fail) :-
+    aggregate_all(max(X), member(X, []), _).
+min :-
+    aggregate_all(min(A), age(_, A), M),
+    M == 5.
+max_expression :-
+    aggregate_all(max(A * 2), age(_, A), M),
+    M == 22.
+max_atom :-
+    aggregate_all(max(X), member(X, [a, c, b]), M),
+    M == c.
+min_atom :-
+    aggregate_all(min(X), member(X, [b, pi, a]), M),
+    M == a.
+max_mixed :-
+    aggregate_all(max(X), member(X, [f(1), 3, b]), M),
+    M == f(1).
+min_numbers :-
+    aggregate_all(min(X), member(X, [2, 1.5, 3]), M),
+    M == 1.5.
+bag :-
+    aggregate_all(bag(K), class(_, K), B),
+    B == [x, y, x, z].
+set :-
+    aggregate_all(set(K), class(_, K), S),
+    S == [x, y, z].
+bad_spec(throws(error(domain_error(aggregate_spec, foo), _))) :-
+    aggregate_all(foo, true, _).
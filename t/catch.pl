@@ -0,0 +1,104 @@
+% Tests for catch/3 and throw/1
+%
+% catch/3 and throw/1 are defined in ISO §7.8.9 and §7.8.10
+
+% helper predicates
+foo(X) :-
+    Y is X * 2,
+    throw(test(Y)).
+bar(X) :-
+    X = Y,
+    throw(Y).
+coo(X) :-
+    throw(X).
+car(X) :-
+    X = 1,
+    throw(X).
+g :-
+    catch(p, _, throw(wrong)),
+    coo(c).
+p.
+p :-
+    throw(b).
+choices(1).
+choices(2).
+choices(3).
+
+:- use_module(library(tap)).
+
+% Tests derived from examples in ISO §7.8.9.4
+'catch foo' :-
+    catch(foo(5), test(Y), true),
+    Y = 10.
+'catch bar' :-
+    catch(bar(_), error(instantiation_error, _), true).
+'recovery is called' :-
+    catch(true, C, true),
+    var(C).
+'catch any' :-
+    catch(number_codes(X, "1a"), _, true) ; true.
+'catch from inside' :-
+    catch(car(X), Y, true),
+    Y = 1,
+    var(X).
+'catcher is not active after goal exits' :-
+    catch(g, C, true),
+    C == c.
+
+% more tests
+'catcher does not match'(throws(oops)) :-
+    catch(throw(oops), other, true).
+'inner catch first' :-
+    catch(catch(throw(a), a, X = inner), a, X = outer),
+    X = inner.
+'outer catch when inner does not match' :-
+    catch(catch(throw(b), a, X = inner), b, X = outer),
+    X = outer.
+'recovery can rethrow' :-
+    catch(catch(throw(a), a, throw(b)), b, true).
+'catch is transparent to backtracking' :-
+    findall(X, catch(choices(X), _, true), Xs),
+    Xs = [1,2,3].
+'exception undoes bindings' :-
+    catch((X = bound, throw(oops)), oops, true),
+    var(X).
+'catch after backtracking into goal' :-
+    catch((choices(X), X \== 1, throw(found(X))), found(Y), true),
+    Y = 2.
+'cut inside catch is local' :-
+    findall(X, (choices(X), catch(!, _, true)), Xs),
+    Xs = [1,2,3].
+'throw variable'(throws(instantiation_error)) :-
+    throw(_).
+'undefined predicate'(throws(existence_error(procedure, no_such_thing/0))) :-
+    no_such_thing.
+'exception inside negation'(throws(oops)) :-
+    \+ throw(oops).
+'exception inside findall'(throws(oops)) :-
+    findall(X, throw(oops), X).
+'call variable'(throws(instantiation_error)) :-
+    call(_).
+'call number'(throws(type_error(callable, 1))) :-
+    call(1).
+'is with unbound variable'(throws(instantiation_error)) :-
+    _ is _ + 1.
+'is with unknown function'(throws(type_error(evaluable, foo/0))) :-
+    _ is foo + 1.
+'division by zero'(throws(evaluation_error(zero_divisor))) :-
+    _ is 1 / 0 .  % space avoids lexing "0." as a float
+'succ without integers'(throws(instantiation_error)) :-
+    succ(_, _).
+'succ of an atom'(throws(type_error(integer, a))) :-
+    succ(a, _).
+'succ of a negative' :-
+    X is 0 - 1,
+    catch(succ(X, _), error(type_error(not_less_than_zero, Y), _), true),
+    Y =:= X.
+'succ of zero'(fail) :-
+    succ(_, 0).
+'downcase a variable'(throws(instantiation_error)) :-
+    downcase_atom(_, _).
+'downcase a number'(throws(type_error(atom, 7))) :-
+    downcase_atom(7, _).
+'msort partial list'(throws(instantiation_error)) :-
+    msort([a|_], _).
@@ -0,0 +1,25 @@
+% Tests for char_code/2
+%
+% char_code/2 is defined in ISO §8.16.6
+:- use_module(library(tap)).
+
+% Tests derived from examples in ISO §8.16.6.4
+char_to_code :-
+    char_code(a, X),
+    X == 0'a.
+code_to_char :-
+    char_code(X, 0'c),
+    X == c.
+both_bound :-
+    char_code(b, 0'b).
+unbound(throws(instantiation_error)) :-
+    char_code(_, _).
+not_a_character(throws(type_error(character, ab))) :-
+    char_code(ab, _).
+not_a_code(throws(representation_error(character_code))) :-
+    char_code(_, -2).
+
+unicode :-
+    char_code(C, 955),
+    C == 'λ',
+    char_code('λ', 955).
@@ -0,0 +1,111 @@
+% Tests for grammar rules and dcg_translate_rule/2
+%
+% As described in the draft DCG standard for ISO Prolog
+
+% helper grammars
+greeting --> [hello], name.
+name --> [world].
+name --> [prolog].
+
+digits([D|T]) --> digit(D), digits(T).
+digits([D]) --> digit(D).
+digit(D) --> [D], { D >= 0'0, D =< 0'9 }.
+
+abc --> "abc".
+
+anything([]) --> [].
+anything([H|T]) --> [H], anything(T).
+
+first, [X] --> [X].
+
+not_x --> \+ [x], [_].
+
+once_a --> [a], !, [b].
+once_a --> [a].
+
+a_or_b --> ( [a] -> [] ; [b] ).
+
+call_it(G) --> call(G, x).
+
+x(x, S0, S) :- S0 = [x|S].
+
+variable_body(B) --> B.
+
+empty --> [].
+
+:- use_module(library(tap)).
+
+terminals :-
+    phrase(greeting, [hello, world]).
+alternatives :-
+    phrase(greeting, [hello, prolog]).
+wrong_terminal(fail) :-
+    phrase(greeting, [goodbye, world]).
+string_literal :-
+    phrase(abc, [0'a, 0'b, 0'c]).
+curly_goals :-
+    phrase(digits(Ds), "123"),
+    Ds == "123".
+rest :-
+    phrase(anything(X), [a, b, c], [c]),
+    X == [a, b].
+pushback :-
+    phrase(first, [a, b], Rest),
+    Rest == [a, b].
+negation :-
+    phrase(not_x, [y]).
+negation_fails(fail) :-
+    phrase(not_x, [x]).
+cut :-
+    findall(R, phrase(once_a, [a, b], R), Rs),
+    Rs == [[]].
+if_then_else :-
+    phrase(a_or_b, [a]),
+    phrase(a_or_b, [b]).
+call_dcg :-
+    phrase(call_it(x), [x]).
+variable_non_terminal :-
+    phrase(variable_body(empty), []).
+empty_body :-
+    phrase(empty, [a], [a]).
+list_body :-
+    phrase([a], [a]).
+list_body_rest :-
+    phrase([a, b], [a, b, c], Rest),
+    Rest == [c].
+string_body :-
+    phrase("ab", L),
+    L == [0'a, 0'b].
+conjunction_body :-
+    phrase(([a], greeting), L),
+    L == [a, hello, world].
+alternative_body :-
+    findall(L, phrase(([a] ; [b]), L), Ls),
+    Ls == [[a], [b]].
+curly_body :-
+    phrase({X = 1}, [], []),
+    X == 1.
+variable_list_body :-
+    phrase(variable_body([x, y]), [x, y]).
+variable_conjunction_body :-
+    phrase(variable_body((name, [z])), [world, z]).
+variable_phrase_body(throws(error(instantiation_error, _))) :-
+    phrase(_, []).
+bad_phrase_body(throws(error(type_error(callable, 1), _))) :-
+    phrase(1, []).
+
+translate :-
+    dcg_translate_rule((a --> b, [c]), Clause),
+    Clause = (a(S0, S) :- b(S0, S1), S1 = [c|S]).
+translate_pushback :-
+    dcg_translate_rule((a, [p] --> b), Clause),
+    Clause = (a(S0, S) :- b(S0, S1), S = [p|S1]).
+translate_curly :-
+    dcg_translate_rule((a --> {foo}), Clause),
+    Clause = (a(S0, S) :- foo, S0 = S).
+translate_not_a_rule(throws(error(type_error(callable, foo), _))) :-
+    dcg_translate_rule(foo, _).
+translate_variable_head(throws(error(instantiation_error, _))) :-
+    dcg_translate_rule((_ --> a), _).
+translate_bad_body(throws(error(type_error(callable, 1), _))) :-
+    dcg_translate_rule((a --> 1), _).
@@ -0,0 +1,32 @@
+% Tests for directives executed while consulting a file
+%
+% See ISO §7.4.2
+
+:- dynamic counter/1.
+:- dynamic((flag/1, [setting/2])).
+:- discontiguous color/1.
+
+:- assertz(asserted(by_directive)).
+:- initialization(assertz(asserted(by_initialization))).
+:- initialization(asserted_late). % defined below
+
+color(red).
+asserted_late.
+color(blue).
+
+:- use_module(library(tap)).
+
+'dynamic predicate without clauses'(fail) :-
+    counter(_).
+'dynamic predicates from a list'(fail) :-
+    setting(_, _).
+'dynamic predicate can be asserted' :-
+    assertz(flag(up)),
+    flag(up).
+'discontiguous clauses' :-
+    findall(C, color(C), Cs),
+    Cs = [red, blue].
+'directive goal' :-
+    asserted(by_directive).
+'initialization goal' :-
+    asserted(by_initialization).
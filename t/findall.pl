@@ -27,10 +27,10 @@ order(fail) :-
     findall(X, (X=1; X=2), [A,B]),
     A = 1, B = 2 .
 
-%all_variables(throws(instantiation_error)) :-
-%    findall(X, Goal, S).
-%type_error(throws(type_error(callable, 4)) :-
-%    findall(X, 4, S).
+all_variables(throws(instantiation_error)) :-
+    findall(X, Goal, S).
+type_error(throws(type_error(callable, 4))) :-
+    findall(X, 4, S).
 
 
 % Tests derived from Prolog: The Standard p. 89
@@ -0,0 +1,101 @@
+% Tests for format/2 and format/3
+%
+% As defined in SWI-Prolog
+:- use_module(library(tap)).
+
+'~w' :-
+    format(atom(A), "~w and ~w", [hello, 'World']),
+    A == 'hello and World'.
+'~q' :-
+    format(atom(A), '~q', ['World']),
+    A == '\'World\''.
+'~p' :-
+    format(atom(A), '~p', [foo('A')]),
+    A == 'foo(\'A\')'.
+'~a' :-
+    format(atom(A), '~a~a', [abc, 12]),
+    A == abc12.
+'~a compound'(throws(error(type_error(atomic, f(x)), _))) :-
+    format(atom(_), '~a', [f(x)]).
+'~d' :-
+    format(atom(A), '~d ~d', [42, -7]),
+    A == '42 -7'.
+'~2d' :-
+    format(atom(A), '~2d ~2d ~2d', [314, 5, -5]),
+    A == '3.14 0.05 -0.05'.
+'~D' :-
+    format(atom(A), '~D ~2D ~D', [1234567, 1234567, 999]),
+    A == '1,234,567 12,345.67 999'.
+'~d float'(throws(error(type_error(integer, 1.5), _))) :-
+    format(atom(_), '~d', [1.5]).
+'~f' :-
+    format(atom(A), '~f ~2f ~0f', [1, 0.125, 2.5]),
+    A == '1.000000 0.13 3'.
+'~e' :-
+    format(atom(A), '~e ~2e', [1, 12345]),
+    A == '1.000000e+00 1.23e+04'.
+'~g' :-
+    format(atom(A), '~g', [0.5]),
+    A == '0.5'.
+'~s' :-
+    format(atom(A), '~s and ~s', ["codes", [c,h,a,r,s]]),
+    A == 'codes and chars'.
+'~c' :-
+    format(atom(A), '~c~3c', [0'a, 0'b]),
+    A == abbb.
+'~r' :-
+    format(atom(A), '~2r ~16r ~16R', [5, 255, 255]),
+    A == '101 ff FF'.
+'~r without radix'(throws(error(format(_), _))) :-
+    format(atom(_), '~r', [5]).
+'~n and ~~' :-
+    format(atom(A), 'a~nb~2n~~', []),
+    atom_codes(A, [0'a, 10, 0'b, 10, 10, 0'~]).
+'~i' :-
+    format(atom(A), '~w~i~w', [a, b, c]),
+    A == ac.
+'~*c' :-
+    format(atom(A), '~*c', [3, 0'x]),
+    A == xxx.
+'~t~|' :-
+    format(atom(A), '~w~t~6|~w', [abc, def]),
+    A == 'abc   def'.
+right_align :-
+    format(atom(A), '~t~w~6|', [abc]),
+    A == '   abc'.
+center :-
+    format(atom(A), '~t~w~t~7|', [abc]),
+    A == '  abc  '.
+fill_character :-
+    format(atom(A), '~`-t~10|', []),
+    A == '----------'.
+column_plus :-
+    format(atom(A), '~w~t~4+~w~t~4+|', [a, b]),
+    A == 'a   b   |'.
+column_after_newline :-
+    format(atom(A), 'xxxxx~nab~t~4|c', []),
+    atom_codes(A, [0'x, 0'x, 0'x, 0'x, 0'x, 10, 0'a, 0'b, 0' , 0' , 0'c]).
+too_wide :-
+    format(atom(A), '~w~3|~w', [abcdef, g]),
+    A == abcdefg.
+single_argument :-
+    format(atom(A), 'x=~w', hello),
+    A == 'x=hello'.
+code_list_sink :-
+    format(codes(C), '~w', [ab]),
+    C == [0'a, 0'b].
+code_list_tail :-
+    format(codes(C, T), '~w', [ab]),
+    C = [0'a, 0'b|T],
+    var(T).
+chars_sink :-
+    format(chars(C), '~w', [ab]),
+    C == [a, b].
+not_enough_arguments(throws(error(format(_), _))) :-
+    format(atom(_), '~w ~w', [a]).
+too_many_arguments(throws(error(format(_), _))) :-
+    format(atom(_), '~w', [a, b]).
+unknown_directive(throws(error(format(_), _))) :-
+    format(atom(_), '~y', [a]).
+unbound_template(throws(error(instantiation_error, _))) :-
+    format(_, []).
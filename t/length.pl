@@ -1,4 +1,4 @@
-% Tests for sort/2
+% Tests for length/2
 %
 % Part of the de facto standard.
 :- use_module(library(tap)).
@@ -15,3 +15,22 @@ singleton :-
 'build a list' :-
     length(Xs, 3),
     Xs = [_,_,_].
+
+'generate lists' :-
+    findall(L, (length(L, N), (N >= 3, ! ; true)), Ls),
+    Ls = [[], [_], [_,_], [_,_,_]].
+
+'partial list' :-
+    length([a, b | T], N),
+    !,
+    T == [],
+    N == 2.
+
+'wrong length'(fail) :-
+    length([a, b], 3).
+
+'negative length'(throws(domain_error(not_less_than_zero, -1))) :-
+    length(_, -1).
+
+'not an integer'(throws(type_error(integer, a))) :-
+    length(_, a).
@@ -0,0 +1,177 @@
+% Tests for the list library
+%
+% These predicates follow library(lists) and library(apply) in
+% SWI-Prolog.
+
+% helper predicates
+double(X, Y) :- Y is 2 * X.
+add3(X, Y, Z, S) :- S is X + Y + Z.
+add5(A, B, C, D, E, S) :- S is A + B + C + D + E.
+even(X) :- 0 is X mod 2.
+sum(X, S0, S) :- S is S0 + X.
+multiply_add(X, Y, S0, S) :- S is S0 + X * Y.
+by_length(Order, A, B) :-
+    atom_length(A, LA),
+    atom_length(B, LB),
+    ( LA < LB -> Order = (<)
+    ; LA > LB -> Order = (>)
+    ; Order = (=)
+    ).
+
+:- use_module(library(tap)).
+
+append :-
+    append([a, b], [c], L),
+    L == [a, b, c].
+append_split :-
+    findall(X-Y, append(X, Y, [a, b]), L),
+    L == [[]-[a, b], [a]-[b], [a, b]-[]].
+
+member :-
+    findall(X, member(X, [a, b, c]), L),
+    L == [a, b, c].
+not_a_member(fail) :-
+    member(d, [a, b, c]).
+
+reverse :-
+    reverse([a, b, c], L),
+    L == [c, b, a].
+
+nth0 :-
+    nth0(1, [a, b, c], X),
+    X == b.
+nth0_generate :-
+    findall(I-X, nth0(I, [a, b], X), L),
+    L == [0-a, 1-b].
+nth1 :-
+    nth1(1, [a, b, c], X),
+    X == a.
+nth1_index :-
+    nth1(I, [a, b, c], c),
+    I == 3.
+nth0_out_of_range(fail) :-
+    nth0(3, [a, b, c], _).
+
+last :-
+    last([a, b, c], X),
+    X == c.
+last_empty(fail) :-
+    last([], _).
+
+select :-
+    findall(X-R, select(X, [a, b], R), L),
+    L == [a-[b], b-[a]].
+
+delete :-
+    delete([a, b, a, c], a, L),
+    L == [b, c].
+
+include :-
+    include(even, [1, 2, 3, 4], L),
+    L == [2, 4].
+exclude :-
+    exclude(even, [1, 2, 3, 4], L),
+    L == [1, 3].
+partition :-
+    partition(even, [1, 2, 3, 4], I, E),
+    I == [2, 4],
+    E == [1, 3].
+
+maplist_check :-
+    maplist(atom, [a, b, c]).
+maplist_fails(fail) :-
+    maplist(atom, [a, 1, c]).
+maplist_map :-
+    maplist(double, [1, 2, 3], L),
+    L == [2, 4, 6].
+maplist_4 :-
+    maplist(add3, [1, 2], [10, 20], [100, 200], L),
+    L == [111, 222].
+maplist_7 :-
+    maplist(add5, [1, 2], [1, 2], [1, 2], [1, 2], [1, 2], L),
+    L == [5, 10].
+maplist_unbound :-
+    maplist(=(z), L),
+    !,
+    L == [].
+maplist_closure :-
+    maplist(succ, [1, 2], L),
+    L == [2, 3].
+
+foldl :-
+    foldl(sum, [1, 2, 3], 0, S),
+    S == 6.
+foldl_5 :-
+    foldl(multiply_add, [1, 2], [3, 4], 0, S),
+    S == 11.
+
+sum_list :-
+    sum_list([1, 2, 3.5], S),
+    S =:= 6.5.
+sum_list_empty :-
+    sum_list([], S),
+    S == 0 .
+max_list :-
+    max_list([3, 1, 4, 1, 5], M),
+    M == 5.
+min_list :-
+    min_list([3, 1, 4, 1, 5], M),
+    M == 1.
+max_list_empty(fail) :-
+    max_list([], _).
+
+numlist :-
+    numlist(1, 5, L),
+    L == [1, 2, 3, 4, 5].
+numlist_empty(fail) :-
+    numlist(5, 1, _).
+
+between :-
+    findall(X, between(1, 3, X), L),
+    L == [1, 2, 3].
+between_check :-
+    between(1, 3, 2).
+between_outside(fail) :-
+    between(1, 3, 4).
+between_infinite :-
+    between(1, inf, X),
+    X > 2,
+    !.
+between_type(throws(type_error(integer, a))) :-
+    between(a, 3, _).
+
+permutation :-
+    findall(P, permutation([a, b, c], P), L),
+    L == [[a, b, c], [a, c, b], [b, a, c], [b, c, a], [c, a, b], [c, b, a]].
+permutation_reverse :-
+    permutation(X, [a, b]),
+    !,
+    X == [a, b].
+
+list_to_set :-
+    list_to_set([a, b, a, c, b], S),
+    S == [a, b, c].
+
+predsort :-
+    predsort(by_length, [ccc, a, bb, dd], L),
+    L == [a, bb, ccc].
+
+sort_4_key :-
+    sort(1, @=<, [f(2, a), f(1, b), f(2, c)], L),
+    L == [f(1, b), f(2, a), f(2, c)].
+sort_4_unique :-
+    sort(1, @<, [f(2, a), f(1, b), f(2, c)], L),
+    L == [f(1, b), f(2, a)].
+sort_4_descending :-
+    sort(1, @>=, [f(2, a), f(1, b), f(2, c)], L),
+    L == [f(2, a), f(2, c), f(1, b)].
+sort_4_whole :-
+    sort(0, @>, [b, a, c, a], L),
+    L == [c, b, a].
+sort_4_order(throws(domain_error(order, foo))) :-
+    sort(0, foo, [], _).
+
+user_definitions_win :-
+    assertz(last(_, mine)),
+    last([a, b], X),
+    X == mine.
@@ -0,0 +1,41 @@
+% Tests for number_codes/2 and number_chars/2
+%
+% They're defined in ISO §8.16.7 and §8.16.8
+:- use_module(library(tap)).
+
+% Tests derived from examples in ISO §8.16.7.4 and §8.16.8.4
+number_to_codes :-
+    number_codes(33, L),
+    L == "33".
+codes_to_number :-
+    number_codes(N, " 33"),
+    N == 33.
+negative :-
+    number_codes(N, "-25"),
+    N == -25.
+float :-
+    number_codes(N, "3.0e2"),
+    N == 300.0.
+hex :-
+    number_codes(N, "0xf"),
+    N == 15.
+character_code :-
+    number_codes(N, "0'a"),
+    N == 0'a.
+not_a_number(throws(syntax_error(_))) :-
+    number_codes(_, "3x").
+unbound(throws(instantiation_error)) :-
+    number_codes(_, _).
+number_to_chars :-
+    number_chars(33.0, L),
+    L == ['3', '3', '.', '0'].
+chars_to_number :-
+    number_chars(N, ['1', '2']),
+    N == 12.
+partial_list :-
+    number_chars(12, ['1' | T]),
+    T == ['2'].
+not_a_character(throws(type_error(character, 1))) :-
+    number_chars(_, [1]).
+atom_argument(throws(type_error(number, a))) :-
+    number_chars(a, _).
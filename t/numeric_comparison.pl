@@ -40,3 +40,38 @@ is :-
 'repeated is' :-
     X is 9,
     X is 3*3.
+
+less :-
+    1 < 2.
+'less failing'(fail) :-
+    2 < 2.
+'less or equal' :-
+    2 =< 2,
+    1.5 =< 2.
+greater :-
+    3 > 2.5.
+'greater failing'(fail) :-
+    2.5 > 3.
+'greater or equal' :-
+    3 >= 3.0,
+    4 >= 3.
+'not equal' :-
+    1 =\= 2.
+'not equal failing'(fail) :-
+    3 =\= 6 / 2.
+'compare expressions' :-
+    2 + 2 < 2 * 3.
+'rational versus float' :-
+    X is sqrt(2),
+    X < 1.4142135623731,
+    X > 1.4142135623730.
+'rational exceeds nearest float' :-
+    X is 0.1 + sin(0),  % the float64 nearest to 0.1
+    X > 0.1.
+'infinity' :-
+    X is inf,
+    X > 10 ^ 400.
+'unbound comparison'(throws(error(instantiation_error, _))) :-
+    _ < 1.
+'non-evaluable comparison'(throws(error(type_error(evaluable, foo/0), _))) :-
+    foo > 1.
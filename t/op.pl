@@ -0,0 +1,75 @@
+% Tests for op/3 and current_op/3
+%
+% As defined in ISO §8.14.3 and §8.14.4
+:- use_module(library(tap)).
+
+default_operator :-
+    current_op(P, T, mod),
+    P == 400,
+    T == yfx.
+prefix_and_infix :-
+    findall(P-T, current_op(P, T, -), Ops),
+    msort(Ops, Sorted),
+    Sorted == [200-fy, 500-yfx].
+not_an_operator(fail) :-
+    current_op(_, _, foo).
+define :-
+    op(700, xfx, ===>),
+    current_op(P, T, ===>),
+    P == 700,
+    T == xfx.
+define_several :-
+    op(200, xf, [days, weeks]),
+    current_op(200, xf, days),
+    current_op(200, xf, weeks).
+redefine :-
+    op(700, xfx, ===>),
+    op(710, xfy, ===>),
+    findall(P-T, current_op(P, T, ===>), Ops),
+    Ops == [710-xfy].
+remove :-
+    op(0, yfx, mod),
+    \+ current_op(_, _, mod).
+by_priority :-
+    findall(Op, current_op(1000, _, Op), Ops),
+    Ops == [','].
+bar :-
+    op(1100, xfy, '|'),
+    current_op(1100, xfy, '|'),
+    term_to_atom(T, '(a | b | c)'),
+    term_to_atom('|'(x, y), A),
+    op(0, xfy, '|'),
+    T == '|'(a, '|'(b, c)),
+    A == 'x|y'.
+writes_with_new_operators :-
+    op(700, xfx, ===>),
+    format(atom(A), '~w', [===>(a, b)]),
+    A == 'a===>b'.
+
+priority_too_high(throws(error(domain_error(operator_priority, 1201), _))) :-
+    op(1201, xfx, foo).
+bad_specifier(throws(error(domain_error(operator_specifier, yfy), _))) :-
+    op(700, yfy, foo).
+unbound_priority(throws(error(instantiation_error, _))) :-
+    op(_, xfx, foo).
+comma(throws(error(permission_error(modify, operator, ','), _))) :-
+    op(700, xfx, ',').
+curly_brackets(throws(error(permission_error(create, operator, {}), _))) :-
+    op(700, xfx, {}).
+bar_low_priority(throws(error(permission_error(create, operator, '|'), _))) :-
+    op(1000, xfy, '|').
+bar_prefix(throws(error(permission_error(create, operator, '|'), _))) :-
+    op(1150, fx, '|').
+infix_and_postfix(throws(error(permission_error(create, operator, ===>), _))) :-
+    op(700, xfx, ===>),
+    op(200, xf, ===>).
+not_an_atom(throws(error(type_error(atom, 1), _))) :-
+    op(700, xfx, 1).
+current_op_bad_specifier(throws(error(domain_error(operator_specifier, foo), _))) :-
+    current_op(_, foo, _).
+current_op_bad_name(throws(error(type_error(atom, 1), _))) :-
+    current_op(_, _, 1).
+current_op_priority_too_high(throws(error(domain_error(operator_priority, 1201), _))) :-
+    current_op(1201, _, _).
+current_op_negative_priority(throws(error(domain_error(operator_priority, -1), _))) :-
+    current_op(-1, _, _).
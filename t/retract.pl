@@ -0,0 +1,75 @@
+% Tests for retract/1, retractall/1 and abolish/1
+%
+% retract/1 and abolish/1 are defined in ISO §8.9.3 and §8.9.4
+
+% helper predicates
+fact(1).
+fact(2).
+fact(3).
+
+rule(X) :-
+    fact(X).
+
+:- use_module(library(tap)).
+
+'retract the first match' :-
+    retract(fact(X)),
+    X == 1,
+    findall(Y, fact(Y), Ys),
+    Ys = [2,3].
+'retract on backtracking' :-
+    findall(X, retract(fact(X)), Xs),
+    Xs = [1,2,3],
+    \+ fact(_).
+'retract a rule' :-
+    retract((rule(X) :- Body)),
+    Body == fact(X),
+    \+ rule(_).
+'retract a fact with a body' :-
+    retract((fact(2) :- true)),
+    findall(X, fact(X), Xs),
+    Xs = [1,3].
+'retract a missing clause'(fail) :-
+    retract(fact(4)).
+'retract an undefined predicate'(fail) :-
+    retract(nothing_like_this(_)).
+'logical update view' :-
+    findall(X, (retract(fact(X)), assertz(fact(9))), Xs),
+    Xs = [1,2,3],
+    findall(Y, fact(Y), Ys),
+    Ys = [9,9,9].
+'retract survives backtracking' :-
+    ( retract(fact(1)), fail
+    ; true
+    ),
+    \+ fact(1).
+
+'retractall everything' :-
+    retractall(fact(_)),
+    \+ fact(_).
+'retractall some things' :-
+    retractall(fact(2)),
+    findall(X, fact(X), Xs),
+    Xs = [1,3].
+'retractall an undefined predicate' :-
+    retractall(nothing_like_this(_)),
+    \+ nothing_like_this(_).
+
+'abolish a predicate' :-
+    abolish(fact/1),
+    catch(fact(_), error(existence_error(procedure, fact/1), _), true).
+
+'retract a variable'(throws(instantiation_error)) :-
+    retract(_).
+'retract a builtin'(throws(permission_error(modify, static_procedure, atom_codes/2))) :-
+    retract(atom_codes(_, _)).
+'retractall a number'(throws(type_error(callable, 7))) :-
+    retractall(7).
+'abolish a variable'(throws(instantiation_error)) :-
+    abolish(_).
+'abolish an atom'(throws(type_error(predicate_indicator, foo))) :-
+    abolish(foo).
+'abolish with a bad arity'(throws(type_error(integer, a))) :-
+    abolish(foo/a).
+'abolish a builtin'(throws(permission_error(modify, static_procedure, atom_codes/2))) :-
+    abolish(atom_codes/2).
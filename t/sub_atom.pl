@@ -0,0 +1,58 @@
+% Tests for sub_atom/5
+%
+% sub_atom/5 is defined in ISO §8.16.3
+:- use_module(library(tap)).
+
+% Tests derived from examples in ISO §8.16.3.4
+suffix :-
+    sub_atom(abracadabra, 0, 5, _, S),
+    S == abrac.
+after :-
+    sub_atom(abracadabra, _, 5, 0, S),
+    S == dabra.
+middle :-
+    sub_atom(abracadabra, 3, L, 3, S),
+    L == 5,
+    S == acada.
+occurrences :-
+    findall(B-A, sub_atom(abracadabra, B, 2, A, ab), L),
+    L == [0-9, 7-2].
+one_character :-
+    sub_atom('Banana', 3, 2, _, S),
+    S == an.
+all_of_length :-
+    findall(S, sub_atom(charity, _, 3, _, S), L),
+    L == [cha, har, ari, rit, ity].
+everything :-
+    findall(B-L-A, sub_atom(ab, B, L, A, _), Xs),
+    Xs == [0-0-2, 0-1-1, 0-2-0, 1-0-1, 1-1-0, 2-0-0].
+unbound(throws(instantiation_error)) :-
+    sub_atom(_, _, _, _, _).
+not_an_atom(throws(type_error(atom, f(x)))) :-
+    sub_atom(f(x), _, _, _, _).
+
+too_long(fail) :-
+    sub_atom(abc, _, 4, _, _).
+unicode :-
+    sub_atom('λέξη', 1, 2, A, S),
+    A == 1,
+    S == 'έξ'.
+only_after :-
+    findall(B-L-S, sub_atom(abc, B, L, 1, S), Xs),
+    Xs == [0-2-ab, 1-1-b, 2-0-''].
+before_and_after :-
+    findall(S, sub_atom(abcde, 1, _, 1, S), Xs),
+    Xs == [bcd].
+known_sub_atom_only :-
+    findall(B-A, sub_atom(banana, B, _, A, ana), Xs),
+    Xs == [1-2, 3-0].
+empty_sub_atom :-
+    findall(B, sub_atom(abc, B, _, _, ''), Bs),
+    Bs == [0, 1, 2, 3].
+first_of_many :-
+    length(Cs, 500),
+    maplist(=(0'a), Cs),
+    atom_codes(A, Cs),
+    sub_atom(A, B, L, _, S),
+    !,
+    B-L-S == 0-0-''.
@@ -0,0 +1,106 @@
+% Tests for functor/3, arg/3, =../2, copy_term/2 and term_variables/2
+%
+% As defined in ISO §8.5
+:- use_module(library(tap)).
+
+% functor/3
+functor_compound :-
+    functor(foo(a, b, c), N, A),
+    N == foo,
+    A == 3.
+functor_atom :-
+    functor(foo, N, A),
+    N == foo,
+    A == 0 .
+functor_number :-
+    functor(1.5, N, A),
+    N == 1.5,
+    A == 0 .
+functor_construct :-
+    functor(T, foo, 3),
+    T = foo(X, Y, Z),
+    var(X),
+    var(Y),
+    var(Z).
+functor_construct_atomic :-
+    functor(T, 7, 0),
+    T == 7.
+functor_unbound(throws(error(instantiation_error, _))) :-
+    functor(_, foo, _).
+functor_bad_arity(throws(error(type_error(integer, a), _))) :-
+    functor(_, foo, a).
+functor_negative_arity(throws(error(domain_error(not_less_than_zero, -1), _))) :-
+    functor(_, foo, -1).
+functor_compound_name(throws(error(type_error(atomic, foo(a)), _))) :-
+    functor(_, foo(a), 1).
+functor_number_name(throws(error(type_error(atom, 1.5), _))) :-
+    functor(_, 1.5, 1).
+
+% arg/3
+arg_first :-
+    arg(1, foo(a, b), X),
+    X == a.
+arg_out_of_range(fail) :-
+    arg(3, foo(a, b), _).
+arg_zero(fail) :-
+    arg(0, foo(a, b), _).
+arg_enumerate :-
+    findall(N-X, arg(N, foo(a, b, c), X), L),
+    L == [1-a, 2-b, 3-c].
+arg_unify :-
+    arg(2, foo(a, B), c),
+    B == c.
+arg_unbound(throws(error(instantiation_error, _))) :-
+    arg(1, _, _).
+arg_atom(throws(error(type_error(compound, foo), _))) :-
+    arg(1, foo, _).
+arg_bad_index(throws(error(type_error(integer, a), _))) :-
+    arg(a, foo(a), _).
+
+% =../2
+univ_compound :-
+    foo(a, B) =.. L,
+    L == [foo, a, B].
+univ_atomic :-
+    7 =.. L,
+    L == [7].
+univ_construct :-
+    T =.. [foo, a, b],
+    T == foo(a, b).
+univ_construct_atomic :-
+    T =.. [1.5],
+    T == 1.5.
+univ_partial_list(throws(error(instantiation_error, _))) :-
+    _ =.. [foo|_].
+univ_empty_list(throws(error(domain_error(non_empty_list, []), _))) :-
+    _ =.. [].
+univ_number_name(throws(error(type_error(atom, 1), _))) :-
+    _ =.. [1, a].
+univ_compound_name(throws(error(type_error(atomic, foo(a)), _))) :-
+    _ =.. [foo(a), b].
+univ_not_list(throws(error(type_error(list, foo), _))) :-
+    _ =.. foo.
+
+% copy_term/2
+copy_term_fresh :-
+    copy_term(f(X, Y, X), C),
+    C = f(A, B, A2),
+    A == A2,
+    A \== B,
+    A \== X.
+copy_term_ground :-
+    copy_term(foo(a), C),
+    C == foo(a).
+copy_term_leaves_original :-
+    copy_term(f(X), f(a)),
+    var(X).
+
+% term_variables/2
+term_variables_order :-
+    term_variables(f(X, g(Y, X), _Z), Vs),
+    Vs = [A, B, _],
+    A == X,
+    B == Y.
+term_variables_ground :-
+    term_variables(foo(a, b), Vs),
+    Vs == [].
@@ -0,0 +1,25 @@
+% Tests for term_to_atom/2
+%
+% term_to_atom/2 is an SWI-Prolog extension
+:- use_module(library(tap)).
+
+term_to_text :-
+    term_to_atom(f('A', 1 + 2, "b"), A),
+    A == 'f(\'A\',1+2,[98])'.
+text_to_term :-
+    term_to_atom(T, 'foo(X, bar, X)'),
+    T = foo(a, B, C),
+    B == bar,
+    C == a.
+both_bound :-
+    term_to_atom(1 + 2, '1+2').
+user_defined_operator :-
+    op(700, xfx, ===>),
+    term_to_atom(T, 'a ===> b'),
+    T == ===>(a, b).
+syntax_error(throws(syntax_error(_))) :-
+    term_to_atom(_, 'foo bar').
+unbound(throws(instantiation_error)) :-
+    term_to_atom(_, _).
+malformed_arguments(throws(syntax_error(_))) :-
+    term_to_atom(_, 'foo(a . bar').
@@ -0,0 +1,102 @@
+% Tests for the type testing predicates
+%
+% Except for is_list/1 and rational/1, these are defined in ISO §8.3.
+% Tests are derived from examples in ISO §8.3.2.4 through §8.3.9.4
+:- use_module(library(tap)).
+
+atom :-
+    atom(atom),
+    atom('string'),
+    atom([]).
+atom_compound(fail) :-
+    atom(a(b)).
+atom_variable(fail) :-
+    atom(_).
+atom_number(fail) :-
+    atom(6).
+
+integer :-
+    integer(3),
+    integer(-3),
+    integer(123456789012345678901234567890).
+integer_float(fail) :-
+    integer(3.3).
+integer_atom(fail) :-
+    integer(x).
+
+float :-
+    float(3.3),
+    float(-3.3),
+    X is sqrt(2),
+    float(X).
+float_integer(fail) :-
+    float(3).
+float_atom(fail) :-
+    float(atom).
+
+atomic :-
+    atomic(atom),
+    atomic(2.5),
+    atomic(7).
+atomic_compound(fail) :-
+    atomic(a(b)).
+atomic_variable(fail) :-
+    atomic(_).
+
+compound :-
+    compound(-(a)),
+    compound(-(-1)),
+    compound([a]).
+compound_atom(fail) :-
+    compound(-).
+compound_nil(fail) :-
+    compound([]).
+compound_variable(fail) :-
+    compound(_).
+
+nonvar :-
+    nonvar(33.3),
+    nonvar(foo),
+    nonvar(a(_)),
+    Foo = foo,
+    nonvar(Foo).
+nonvar_variable(fail) :-
+    nonvar(_).
+
+number :-
+    number(3),
+    number(3.3),
+    number(-4).
+number_atom(fail) :-
+    number(a).
+number_variable(fail) :-
+    number(_).
+
+callable :-
+    callable(a),
+    callable(3 + x),
+    callable((a, b)).
+callable_number(fail) :-
+    callable(3).
+callable_variable(fail) :-
+    callable(_).
+
+is_list :-
+    is_list([]),
+    is_list([a, b, c]),
+    is_list("codes").
+is_list_partial(fail) :-
+    is_list([a|_]).
+is_list_improper(fail) :-
+    is_list([a|b]).
+is_list_variable(fail) :-
+    is_list(_).
+
+rational :-
+    rational(7),
+    rational(0.5).
+rational_inexact(fail) :-
+    X is sqrt(2),
+    rational(X).
+rational_atom(fail) :-
+    rational(a).
@@ -0,0 +1,19 @@
+% Tests for upcase_atom/2
+%
+% upcase_atom/2 is an SWI-Prolog extension for converting an
+% atom to uppercase.
+:- use_module(library(tap)).
+
+already_uppercase :-
+    upcase_atom('FOO', 'FOO').
+all_lowercase :-
+    upcase_atom(yell, A),
+    A == 'YELL'.
+mixed_case :-
+    upcase_atom('Once upon a time...', A),
+    A == 'ONCE UPON A TIME...'.
+unicode :-
+    upcase_atom('λέξη', A),
+    A == 'ΛΈΞΗ'.
+unbound(throws(instantiation_error)) :-
+    upcase_atom(_, _).
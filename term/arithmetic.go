@@ -0,0 +1,457 @@
+package term
+
+// Evaluable functors as described in ISO §9.  Integer arguments produce
+// integer results where ISO requires it.  Floats are represented either
+// as *Rational (when the value is exact) or *Float.
+
+import (
+	"math"
+	"math/big"
+	"math/rand"
+)
+
+// constants are evaluable atoms
+var constants = map[string]func() (Number, error){
+	"e":            func() (Number, error) { return NewFloat64(math.E), nil },
+	"epsilon":      func() (Number, error) { return NewFloat64(epsilon), nil },
+	"inf":          func() (Number, error) { return NewFloat64(math.Inf(1)), nil },
+	"infinite":     func() (Number, error) { return NewFloat64(math.Inf(1)), nil },
+	"nan":          func() (Number, error) { return NewFloat64(math.NaN()), nil },
+	"pi":           func() (Number, error) { return NewFloat64(math.Pi), nil },
+	"random_float": func() (Number, error) { return NewFloat64(rand.Float64()), nil },
+}
+
+// difference between 1.0 and the next larger float64
+var epsilon = math.Nextafter(1, 2) - 1
+
+// unaryFunctions are evaluable functors of arity 1
+var unaryFunctions = map[string]func(Number) (Number, error){
+	"+":                     func(x Number) (Number, error) { return x, nil },
+	"-":                     ArithmeticNegate,
+	"abs":                   ArithmeticAbs,
+	"acos":                  floatFunction(math.Acos),
+	"asin":                  floatFunction(math.Asin),
+	"atan":                  floatFunction(math.Atan),
+	"ceiling":               ceiling,
+	"cos":                   floatFunction(math.Cos),
+	"exp":                   floatFunction(math.Exp),
+	"float":                 toFloat,
+	"float_fractional_part": floatFractionalPart,
+	"float_integer_part":    floatIntegerPart,
+	"floor":                 floor,
+	"integer":               round,
+	"log":                   logarithm,
+	"msb":                   msb,
+	"random":                random,
+	"round":                 round,
+	"sign":                  sign,
+	"sin":                   floatFunction(math.Sin),
+	"sqrt":                  floatFunction(math.Sqrt),
+	"tan":                   floatFunction(math.Tan),
+	"truncate":              truncate,
+	"\\":                    bitwiseNot,
+}
+
+// binaryFunctions are evaluable functors of arity 2
+var binaryFunctions = map[string]func(Number, Number) (Number, error){
+	"*":        ArithmeticMultiply,
+	"**":       power,
+	"+":        ArithmeticAdd,
+	"-":        ArithmeticMinus,
+	"/":        ArithmeticDivide,
+	"//":       integerFunction(intDivide),
+	"/\\":      integerFunction(intAnd),
+	"<<":       integerFunction(shiftLeft),
+	">>":       integerFunction(shiftRight),
+	"\\/":      integerFunction(intOr),
+	"^":        intPower,
+	"atan":     floatFunction2(math.Atan2),
+	"atan2":    floatFunction2(math.Atan2),
+	"copysign": floatFunction2(math.Copysign),
+	"div":      integerFunction(floorDivide),
+	"gcd":      integerFunction(gcd),
+	"log":      floatFunction2(logBase),
+	"max":      maximum,
+	"min":      minimum,
+	"mod":      integerFunction(modulo),
+	"rem":      integerFunction(remainder),
+	"xor":      integerFunction(intXor),
+}
+
+// ArithmeticNegate returns the additive inverse of a Golog number
+func ArithmeticNegate(x Number) (Number, error) {
+	switch n := x.(type) {
+	case *Integer:
+		return NewBigInt(new(big.Int).Neg(n.Value())), nil
+	case *Rational:
+		return NewBigRat(new(big.Rat).Neg(n.Value())), nil
+	}
+	return NewFloat64(-x.Float64()), nil
+}
+
+// ArithmeticAbs returns the absolute value of a Golog number
+func ArithmeticAbs(x Number) (Number, error) {
+	switch n := x.(type) {
+	case *Integer:
+		return NewBigInt(new(big.Int).Abs(n.Value())), nil
+	case *Rational:
+		return NewBigRat(new(big.Rat).Abs(n.Value())), nil
+	}
+	return NewFloat64(math.Abs(x.Float64())), nil
+}
+
+func sign(x Number) (Number, error) {
+	switch n := x.(type) {
+	case *Integer:
+		return NewInt64(int64(n.Value().Sign())), nil
+	case *Rational:
+		return NewBigRat(big.NewRat(int64(n.Value().Sign()), 1)), nil
+	}
+	f := x.Float64()
+	switch {
+	case f > 0:
+		return NewFloat64(1), nil
+	case f < 0:
+		return NewFloat64(-1), nil
+	}
+	return NewFloat64(f), nil // zero and NaN are their own sign
+}
+
+func minimum(x, y Number) (Number, error) {
+	if NumberCmp(y, x) < 0 {
+		return y, nil
+	}
+	return x, nil
+}
+
+func maximum(x, y Number) (Number, error) {
+	if NumberCmp(y, x) > 0 {
+		return y, nil
+	}
+	return x, nil
+}
+
+func toFloat(x Number) (Number, error) {
+	if IsInteger(x) {
+		r := new(big.Rat).SetInt(x.(*Integer).Value())
+		return NewBigRat(r), nil
+	}
+	return x, nil
+}
+
+// floatFunction converts a float64 function into an evaluable functor.
+// Integer arguments are converted to floats first.
+func floatFunction(f func(float64) float64) func(Number) (Number, error) {
+	return func(x Number) (Number, error) {
+		return floatResult(f(x.Float64()))
+	}
+}
+
+// floatFunction2 is like floatFunction for functions of two arguments
+func floatFunction2(f func(float64, float64) float64) func(Number, Number) (Number, error) {
+	return func(x, y Number) (Number, error) {
+		return floatResult(f(x.Float64(), y.Float64()))
+	}
+}
+
+// floatResult converts the result of a float calculation into a number,
+// raising ISO evaluation errors for exceptional values
+func floatResult(f float64) (Number, error) {
+	if math.IsNaN(f) {
+		return nil, NewException(EvaluationError("undefined"))
+	}
+	if math.IsInf(f, 0) {
+		return nil, NewException(EvaluationError("float_overflow"))
+	}
+	return NewFloat64(f), nil
+}
+
+// rationalResult is like floatResult for the exact result of a
+// calculation on floats.  It's an overflow if the result is too large
+// for a float.
+func rationalResult(r *big.Rat) (Number, error) {
+	if f, _ := r.Float64(); math.IsInf(f, 0) {
+		return nil, NewException(EvaluationError("float_overflow"))
+	}
+	return NewBigRat(r), nil
+}
+
+func logBase(base, x float64) float64 {
+	return math.Log(x) / math.Log(base)
+}
+
+// logarithm returns the natural logarithm of a positive number
+func logarithm(x Number) (Number, error) {
+	if x.Float64() <= 0 {
+		return nil, NewException(EvaluationError("undefined"))
+	}
+	return floatResult(math.Log(x.Float64()))
+}
+
+// power implements **/2 which always produces a float
+func power(x, y Number) (Number, error) {
+	if isZero(x) && y.Float64() < 0 {
+		return nil, NewException(EvaluationError("zero_divisor"))
+	}
+	return floatResult(math.Pow(x.Float64(), y.Float64()))
+}
+
+// intPower implements ^/2 which produces an integer if both arguments
+// are integers
+func intPower(x, y Number) (Number, error) {
+	if !IsInteger(x) || !IsInteger(y) {
+		return power(x, y)
+	}
+	base := x.(*Integer).Value()
+	exp := y.(*Integer).Value()
+
+	if exp.Sign() < 0 {
+		switch {
+		case base.Sign() == 0:
+			return nil, NewException(EvaluationError("zero_divisor"))
+		case base.CmpAbs(big.NewInt(1)) != 0:
+			return nil, NewException(TypeError("float", x))
+		case base.Sign() > 0 || exp.Bit(0) == 0:
+			return NewInt64(1), nil
+		}
+		return NewInt64(-1), nil
+	}
+
+	// 0, 1 and -1 stay small however large the exponent.  Other bases
+	// need at least (bits-1)*exp bits, so refuse hopeless results
+	// before computing them.
+	if base.CmpAbs(big.NewInt(1)) <= 0 {
+		if base.Sign() < 0 && exp.Bit(0) == 0 {
+			return NewInt64(1), nil
+		}
+		if base.Sign() == 0 && exp.Sign() == 0 {
+			return NewInt64(1), nil
+		}
+		return NewBigInt(new(big.Int).Set(base)), nil
+	}
+	bits := new(big.Int).Mul(exp, big.NewInt(int64(base.BitLen()-1)))
+	if !bits.IsInt64() || bits.Int64() > math.MaxInt32 {
+		return nil, NewException(ResourceError("memory"))
+	}
+	return NewBigInt(new(big.Int).Exp(base, exp, nil)), nil
+}
+
+// integerFunction converts a function on big integers into an evaluable
+// functor.  Arguments which aren't integers raise type errors.
+func integerFunction(f func(x, y *big.Int) (*big.Int, error)) func(Number, Number) (Number, error) {
+	return func(x, y Number) (Number, error) {
+		if !IsInteger(x) {
+			return nil, NewException(TypeError("integer", x))
+		}
+		if !IsInteger(y) {
+			return nil, NewException(TypeError("integer", y))
+		}
+		z, err := f(x.(*Integer).Value(), y.(*Integer).Value())
+		if err != nil {
+			return nil, err
+		}
+		return NewBigInt(z), nil
+	}
+}
+
+func checkDivisor(y *big.Int) error {
+	if y.Sign() == 0 {
+		return NewException(EvaluationError("zero_divisor"))
+	}
+	return nil
+}
+
+// intDivide implements //2 which truncates toward zero
+func intDivide(x, y *big.Int) (*big.Int, error) {
+	if err := checkDivisor(y); err != nil {
+		return nil, err
+	}
+	return new(big.Int).Quo(x, y), nil
+}
+
+// floorDivide implements div/2 which rounds toward negative infinity
+func floorDivide(x, y *big.Int) (*big.Int, error) {
+	m, err := modulo(x, y)
+	if err != nil {
+		return nil, err
+	}
+	z := new(big.Int).Sub(x, m)
+	return z.Quo(z, y), nil
+}
+
+// remainder implements rem/2 whose result has the sign of x
+func remainder(x, y *big.Int) (*big.Int, error) {
+	if err := checkDivisor(y); err != nil {
+		return nil, err
+	}
+	return new(big.Int).Rem(x, y), nil
+}
+
+// modulo implements mod/2 whose result has the sign of y
+func modulo(x, y *big.Int) (*big.Int, error) {
+	z, err := remainder(x, y)
+	if err != nil {
+		return nil, err
+	}
+	if z.Sign() != 0 && z.Sign() != y.Sign() {
+		z.Add(z, y)
+	}
+	return z, nil
+}
+
+func gcd(x, y *big.Int) (*big.Int, error) {
+	a := new(big.Int).Abs(x)
+	b := new(big.Int).Abs(y)
+	return new(big.Int).GCD(nil, nil, a, b), nil
+}
+
+func intAnd(x, y *big.Int) (*big.Int, error) {
+	return new(big.Int).And(x, y), nil
+}
+
+func intOr(x, y *big.Int) (*big.Int, error) {
+	return new(big.Int).Or(x, y), nil
+}
+
+func intXor(x, y *big.Int) (*big.Int, error) {
+	return new(big.Int).Xor(x, y), nil
+}
+
+func shiftLeft(x, y *big.Int) (*big.Int, error) {
+	if y.Sign() < 0 {
+		return shiftRight(x, new(big.Int).Neg(y))
+	}
+	if !y.IsUint64() || y.Uint64() > math.MaxInt32 {
+		return nil, NewException(ResourceError("memory"))
+	}
+	return new(big.Int).Lsh(x, uint(y.Uint64())), nil
+}
+
+func shiftRight(x, y *big.Int) (*big.Int, error) {
+	if y.Sign() < 0 {
+		return shiftLeft(x, new(big.Int).Neg(y))
+	}
+	if !y.IsUint64() || y.Uint64() > math.MaxInt32 {
+		if x.Sign() < 0 {
+			return big.NewInt(-1), nil
+		}
+		return big.NewInt(0), nil
+	}
+	return new(big.Int).Rsh(x, uint(y.Uint64())), nil
+}
+
+func bitwiseNot(x Number) (Number, error) {
+	if !IsInteger(x) {
+		return nil, NewException(TypeError("integer", x))
+	}
+	return NewBigInt(new(big.Int).Not(x.(*Integer).Value())), nil
+}
+
+// msb returns the position of an integer's most significant bit
+func msb(x Number) (Number, error) {
+	if !IsInteger(x) {
+		return nil, NewException(TypeError("integer", x))
+	}
+	i := x.(*Integer).Value()
+	if i.Sign() <= 0 {
+		return nil, NewException(TypeError("positive_integer", x))
+	}
+	return NewInt64(int64(i.BitLen() - 1)), nil
+}
+
+// random returns a random integer between 0 and x-1
+func random(x Number) (Number, error) {
+	if !IsInteger(x) {
+		return nil, NewException(TypeError("integer", x))
+	}
+	i := x.(*Integer).Value()
+	if i.Sign() <= 0 {
+		return nil, NewException(DomainError("positive_integer", x))
+	}
+	if i.IsInt64() {
+		return NewInt64(rand.Int63n(i.Int64())), nil
+	}
+	source := rand.New(rand.NewSource(rand.Int63())) // rand.Rand isn't thread safe
+	return NewBigInt(new(big.Int).Rand(source, i)), nil
+}
+
+// floatIntegerPart returns the integer part of a float, as a float
+func floatIntegerPart(x Number) (Number, error) {
+	switch n := x.(type) {
+	case *Integer:
+		return nil, NewException(TypeError("float", x))
+	case *Rational:
+		i := ratTruncate(n.Value())
+		return NewBigRat(new(big.Rat).SetInt(i)), nil
+	}
+	return NewFloat64(math.Trunc(x.Float64())), nil
+}
+
+// floatFractionalPart returns the fractional part of a float
+func floatFractionalPart(x Number) (Number, error) {
+	i, err := floatIntegerPart(x)
+	if err != nil {
+		return nil, err
+	}
+	return ArithmeticMinus(x, i)
+}
+
+// The following functions convert a number to an integer.  Integers
+// are returned unchanged.
+
+func floor(x Number) (Number, error) {
+	return toInteger(x, ratFloor, math.Floor)
+}
+
+func ceiling(x Number) (Number, error) {
+	return toInteger(x, ratCeiling, math.Ceil)
+}
+
+func truncate(x Number) (Number, error) {
+	return toInteger(x, ratTruncate, math.Trunc)
+}
+
+// round rounds half way cases away from zero
+func round(x Number) (Number, error) {
+	half := big.NewRat(1, 2)
+	roundRat := func(r *big.Rat) *big.Int {
+		if r.Sign() < 0 {
+			return ratCeiling(new(big.Rat).Sub(r, half))
+		}
+		return ratFloor(new(big.Rat).Add(r, half))
+	}
+	return toInteger(x, roundRat, math.Round)
+}
+
+func toInteger(x Number, r func(*big.Rat) *big.Int, f func(float64) float64) (Number, error) {
+	switch n := x.(type) {
+	case *Integer:
+		return x, nil
+	case *Rational:
+		return NewBigInt(r(n.Value())), nil
+	}
+
+	g := f(x.Float64())
+	if math.IsNaN(g) || math.IsInf(g, 0) {
+		return nil, NewException(EvaluationError("undefined"))
+	}
+	i, _ := big.NewFloat(g).Int(nil)
+	return NewBigInt(i), nil
+}
+
+func ratFloor(r *big.Rat) *big.Int {
+	// Rat denominators are positive, so Euclidean division is floored
+	return new(big.Int).Div(r.Num(), r.Denom())
+}
+
+func ratCeiling(r *big.Rat) *big.Int {
+	neg := new(big.Rat).Neg(r)
+	return new(big.Int).Neg(ratFloor(neg))
+}
+
+func ratTruncate(r *big.Rat) *big.Int {
+	if r.Sign() < 0 {
+		return ratCeiling(r)
+	}
+	return ratFloor(r)
+}
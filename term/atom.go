@@ -22,20 +22,7 @@ func NewAtomFromLexeme(possiblyQuotedName string) Callable {
 	runes := []rune(possiblyQuotedName)
 	if runes[0] == '\'' {
 		if runes[len(runes)-1] == '\'' {
-			raw := runes[1 : len(runes)-1]
-			unescaped := make([]rune, len(raw))
-			var i, j int
-			for i < len(raw) {
-				if raw[i] == '\\' && i < len(raw)-1 && raw[i+1] == '\'' {
-					unescaped[j] = '\''
-					i += 2
-				} else {
-					unescaped[j] = raw[i]
-					i++
-				}
-				j++
-			}
-			name = string(unescaped[0:j])
+			name = string(unescape(runes[1 : len(runes)-1]))
 		} else {
 			msg := Sprintf("Atom needs closing quote: %s", possiblyQuotedName)
 			panic(msg)
@@ -81,3 +68,88 @@ func (a *Atom) Unify(e Bindings, b Term) (Bindings, error) {
 		return e, CantUnify
 	}
 }
+
+// unescape replaces escape sequences in the content of a quoted atom
+// or double quoted string with the characters they represent.  See
+// "single quoted character" in ISO §6.4.2.1.  The closing backslash of
+// octal and hexadecimal escapes is optional, as it is for the lexer.
+func unescape(raw []rune) []rune {
+	unescaped := make([]rune, 0, len(raw))
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '\\' || i == len(raw)-1 {
+			unescaped = append(unescaped, raw[i])
+			continue
+		}
+
+		i++
+		switch c := raw[i]; c {
+		case '\\', '\'', '"', '`':
+			unescaped = append(unescaped, c)
+		case 'a':
+			unescaped = append(unescaped, '\a')
+		case 'b':
+			unescaped = append(unescaped, '\b')
+		case 'f':
+			unescaped = append(unescaped, '\f')
+		case 'n':
+			unescaped = append(unescaped, '\n')
+		case 'r':
+			unescaped = append(unescaped, '\r')
+		case 's':
+			unescaped = append(unescaped, ' ') // SWI-Prolog extension
+		case 't':
+			unescaped = append(unescaped, '\t')
+		case 'v':
+			unescaped = append(unescaped, '\v')
+		case '0', '1', '2', '3', '4', '5', '6', '7':
+			var r rune
+			i, r = escapedCode(raw, i, 8, 3)
+			unescaped = append(unescaped, r)
+		case 'x', 'u', 'U':
+			width := 2
+			if c == 'u' {
+				width = 4
+			} else if c == 'U' {
+				width = 8
+			}
+			var r rune
+			i, r = escapedCode(raw, i+1, 16, width)
+			unescaped = append(unescaped, r)
+		default:
+			unescaped = append(unescaped, '\\', c)
+		}
+	}
+	return unescaped
+}
+
+// escapedCode parses up to max digits in the given base starting at
+// raw[i].  It returns the index of the escape's last character along
+// with the character code.
+func escapedCode(raw []rune, i, base, max int) (int, rune) {
+	var r rune
+	j := i
+	for ; j < len(raw) && j-i < max; j++ {
+		d := digitValue(raw[j])
+		if d < 0 || d >= base {
+			break
+		}
+		r = r*rune(base) + rune(d)
+	}
+	if j < len(raw) && raw[j] == '\\' {
+		j++ // ISO closing backslash
+	}
+	return j - 1, r
+}
+
+// digitValue returns the value of a hexadecimal digit or -1
+func digitValue(c rune) int {
+	switch {
+	case c >= '0' && c <= '9':
+		return int(c - '0')
+	case c >= 'a' && c <= 'f':
+		return int(c-'a') + 10
+	case c >= 'A' && c <= 'F':
+		return int(c-'A') + 10
+	}
+	return -1
+}
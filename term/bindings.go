@@ -25,6 +25,10 @@ type Bindings interface {
 	// ByName_ is like ByName() but panics on error.
 	ByName_(string) Term
 
+	// Decode stores the values of named variables in the fields of
+	// a struct.  See Unmarshal for details.
+	Decode(interface{}) error
+
 	// Resolve follows bindings recursively until a term is found for
 	// which no binding exists.  If you want to know the value of a
 	// variable, this is your best bet.
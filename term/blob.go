@@ -0,0 +1,63 @@
+package term
+
+import (
+	. "fmt"
+	"sync/atomic"
+)
+
+// Blob is an opaque term which wraps an arbitrary Go value, like an
+// *os.File or a database connection.  Prolog code can't look inside a
+// blob.  It can only pass the blob along to foreign predicates which
+// know what to do with it.
+//
+// A blob only unifies with itself.  Wrapping the same Go value twice
+// produces two different blobs.  In the standard order of terms, blobs
+// come after atoms and before compound terms.  Blobs are ordered by
+// the time they were created.
+type Blob struct {
+	value interface{}
+	id    int64 // for standard order
+}
+
+var blobID int64
+
+// NewBlob returns a new blob wrapping value
+func NewBlob(value interface{}) *Blob {
+	return &Blob{value: value, id: atomic.AddInt64(&blobID, 1)}
+}
+
+// Returns true if term t is a blob
+func IsBlob(t Term) bool {
+	return t.Type() == BlobType
+}
+
+// Value returns the Go value wrapped by this blob
+func (self *Blob) Value() interface{} {
+	return self.value
+}
+
+func (self *Blob) String() string {
+	return Sprintf("<blob>(%p)", self)
+}
+
+func (self *Blob) Type() int {
+	return BlobType
+}
+
+func (self *Blob) Indicator() string {
+	return self.String()
+}
+
+func (self *Blob) ReplaceVariables(env Bindings) Term {
+	return self
+}
+
+func (a *Blob) Unify(e Bindings, b Term) (Bindings, error) {
+	if IsVariable(b) {
+		return b.Unify(e, a)
+	}
+	if a == b {
+		return e, nil
+	}
+	return e, CantUnify
+}
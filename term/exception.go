@@ -0,0 +1,103 @@
+package term
+
+// Exception is a Go error which carries a Prolog exception term.  Golog
+// produces these when a term thrown by throw/1 (or by a builtin predicate)
+// isn't caught by catch/3.  The thrown term is sometimes called the "ball".
+type Exception struct {
+	ball Term
+}
+
+// NewException returns a Go error wrapping the given exception term
+func NewException(ball Term) *Exception {
+	return &Exception{ball: ball}
+}
+
+// Ball returns the term that was thrown
+func (self *Exception) Ball() Term {
+	return self.ball
+}
+
+func (self *Exception) Error() string {
+	return "Unhandled exception: " + self.ball.String()
+}
+
+// The following functions construct error terms as described in ISO §7.12.
+// Each one returns a term like error(Formal, Context) where Context is
+// a fresh variable.
+
+// InstantiationError is raised when an argument is a variable but an
+// instantiated term was required.  See ISO §7.12.2(a)
+func InstantiationError() Term {
+	return isoError(NewAtom("instantiation_error"))
+}
+
+// UninstantiationError is raised when an argument should be a variable
+// but isn't, like the stream argument of open/4.  See ISO/IEC 13211-1
+// Cor.2 §7.12.2(k)
+func UninstantiationError(culprit Term) Term {
+	return isoError(NewCallable("uninstantiation_error", culprit))
+}
+
+// TypeError is raised when an argument has the wrong type.  For example,
+// TypeError("integer", NewAtom("a")).  See ISO §7.12.2(b)
+func TypeError(validType string, culprit Term) Term {
+	return isoError(NewCallable("type_error", NewAtom(validType), culprit))
+}
+
+// DomainError is raised when an argument has the right type but a value
+// outside of the acceptable domain.  See ISO §7.12.2(c)
+func DomainError(validDomain string, culprit Term) Term {
+	return isoError(NewCallable("domain_error", NewAtom(validDomain), culprit))
+}
+
+// ExistenceError is raised when an object on which an operation is to be
+// performed doesn't exist.  See ISO §7.12.2(d)
+func ExistenceError(objectType string, culprit Term) Term {
+	return isoError(NewCallable("existence_error", NewAtom(objectType), culprit))
+}
+
+// PermissionError is raised when an operation isn't permitted on an object.
+// See ISO §7.12.2(e)
+func PermissionError(operation, permissionType string, culprit Term) Term {
+	formal := NewCallable(
+		"permission_error",
+		NewAtom(operation),
+		NewAtom(permissionType),
+		culprit,
+	)
+	return isoError(formal)
+}
+
+// RepresentationError is raised when an implementation defined limit
+// has been breached.  See ISO §7.12.2(f)
+func RepresentationError(flag string) Term {
+	return isoError(NewCallable("representation_error", NewAtom(flag)))
+}
+
+// EvaluationError is raised when evaluating an arithmetic expression
+// produces an exceptional value like zero_divisor.  See ISO §7.12.2(g)
+func EvaluationError(e string) Term {
+	return isoError(NewCallable("evaluation_error", NewAtom(e)))
+}
+
+// ResourceError is raised when Golog runs out of some resource.
+// See ISO §7.12.2(h)
+func ResourceError(resource string) Term {
+	return isoError(NewCallable("resource_error", NewAtom(resource)))
+}
+
+// SyntaxError is raised when text being read as a Prolog term isn't
+// valid syntax.  See ISO §7.12.2(i)
+func SyntaxError(message string) Term {
+	return isoError(NewCallable("syntax_error", NewAtom(message)))
+}
+
+// SystemError is raised for problems which don't fit in any other
+// error category.  See ISO §7.12.2(j)
+func SystemError(message string) Term {
+	return isoError(NewCallable("system_error", NewAtom(message)))
+}
+
+func isoError(formal Term) Term {
+	return NewCallable("error", formal, NewVar("_"))
+}
@@ -1,6 +1,7 @@
 package term
 
-import "fmt"
+import "math"
+import "strings"
 import "strconv"
 import "math/big"
 
@@ -25,7 +26,21 @@ func (self *Float) Value() float64 {
 }
 
 func (self *Float) String() string {
-	return fmt.Sprintf("%g", self.Value())
+	return formatFloat(self.Value())
+}
+
+// formatFloat renders a float64 so that it reads back as a float.  Go's
+// shortest representation sometimes looks like an integer (2 or 1e+20),
+// so a fractional part is added when necessary.
+func formatFloat(f float64) string {
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if math.IsInf(f, 0) || math.IsNaN(f) || strings.ContainsAny(s, ".") {
+		return s
+	}
+	if i := strings.IndexByte(s, 'e'); i >= 0 {
+		return s[:i] + ".0" + s[i:]
+	}
+	return s + ".0"
 }
 func (self *Float) Type() int {
 	return FloatType
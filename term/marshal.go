@@ -0,0 +1,494 @@
+package term
+
+// Converting between Go values and terms
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var (
+	termType   = reflect.TypeOf((*Term)(nil)).Elem()
+	bigIntType = reflect.TypeOf((*big.Int)(nil))
+	bigRatType = reflect.TypeOf((*big.Rat)(nil))
+)
+
+// Marshal returns the term representing Go value v.  Values are
+// converted like this:
+//
+//	int, int8, ..., uint64  integer
+//	*big.Int                integer
+//	*big.Rat                integer or rational
+//	float32, float64        float
+//	string                  atom
+//	bool                    the atom true or false
+//	[]T                     list
+//	map[string]T            list of Key-Value pairs, sorted by key
+//	named struct            compound term
+//	anonymous struct        list of Key-Value pairs
+//	pointer                 the term for the value it points to
+//	Term                    the term itself (including a Blob)
+//
+// A named struct like Point becomes a compound term like point(X,Y),
+// with one argument for each exported field.  The functor is the
+// struct's name in snake_case, so LineSegment becomes line_segment.
+// In a list of pairs, each key is an atom: the field's name in
+// snake_case or the name given by a `golog:"name"` struct tag.  Fields
+// tagged `golog:"-"` are ignored.
+//
+// Marshal returns an error if v (or something it contains) is nil or
+// has a type that can't be converted.
+func Marshal(v interface{}) (Term, error) {
+	return marshal(reflect.ValueOf(v))
+}
+
+func marshal(v reflect.Value) (Term, error) {
+	if !v.IsValid() {
+		return nil, fmt.Errorf("term: can't marshal nil")
+	}
+	if v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			return nil, fmt.Errorf("term: can't marshal nil %s", v.Type())
+		}
+	}
+	if v.Type().Implements(termType) {
+		return v.Interface().(Term), nil
+	}
+	switch v.Type() {
+	case bigIntType:
+		return NewBigInt(new(big.Int).Set(v.Interface().(*big.Int))), nil
+	case bigRatType:
+		r := v.Interface().(*big.Rat)
+		if r.IsInt() {
+			return NewBigInt(new(big.Int).Set(r.Num())), nil
+		}
+		return NewBigRat(new(big.Rat).Set(r)), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		return marshal(v.Elem())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return NewInt64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return NewBigInt(new(big.Int).SetUint64(v.Uint())), nil
+	case reflect.Float32, reflect.Float64:
+		return NewFloat64(v.Float()), nil
+	case reflect.String:
+		return NewAtom(v.String()), nil
+	case reflect.Bool:
+		return NewAtom(fmt.Sprintf("%t", v.Bool())), nil
+	case reflect.Slice, reflect.Array:
+		ts := make([]Term, v.Len())
+		for i := range ts {
+			t, err := marshal(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			ts[i] = t
+		}
+		return SliceToList(ts), nil
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			break
+		}
+		keys := make([]string, 0, v.Len())
+		for _, k := range v.MapKeys() {
+			keys = append(keys, k.String())
+		}
+		sort.Strings(keys)
+		pairs := make([]Term, len(keys))
+		for i, k := range keys {
+			t, err := marshal(v.MapIndex(reflect.ValueOf(k).Convert(v.Type().Key())))
+			if err != nil {
+				return nil, err
+			}
+			pairs[i] = NewCallable("-", NewAtom(k), t)
+		}
+		return SliceToList(pairs), nil
+	case reflect.Struct:
+		fields := structFields(v.Type())
+		args := make([]Term, len(fields))
+		for i, f := range fields {
+			t, err := marshal(v.Field(f.index))
+			if err != nil {
+				return nil, err
+			}
+			args[i] = t
+			if v.Type().Name() == "" {
+				args[i] = NewCallable("-", NewAtom(f.key), t)
+			}
+		}
+		if v.Type().Name() == "" {
+			return SliceToList(args), nil
+		}
+		return NewCallable(snakeCase(v.Type().Name()), args...), nil
+	}
+	return nil, fmt.Errorf("term: can't marshal %s", v.Type())
+}
+
+// CanMarshal returns true if values of type t can be converted to terms
+// with Marshal and back again with Unmarshal.  An interface type is
+// accepted since its dynamic values are checked as they're converted.
+// Such a value is typically passed around in a Blob.
+func CanMarshal(t reflect.Type) bool {
+	return canMarshal(t, make(map[reflect.Type]bool))
+}
+
+func canMarshal(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] { // a recursive type, already being checked
+		return true
+	}
+	seen[t] = true
+	if t.Implements(termType) {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64, reflect.String, reflect.Bool,
+		reflect.Interface:
+		return true
+	case reflect.Ptr, reflect.Slice:
+		return canMarshal(t.Elem(), seen)
+	case reflect.Map:
+		return t.Key().Kind() == reflect.String && canMarshal(t.Elem(), seen)
+	case reflect.Struct:
+		for _, f := range structFields(t) {
+			if !canMarshal(t.Field(f.index).Type, seen) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// Unmarshal stores the Go value represented by term t in the value
+// pointed to by v.  It's the inverse of Marshal.  A struct can be
+// unmarshaled from either a compound term (with arguments in field
+// order) or a list of Key-Value or Key=Value pairs.  Pairs whose keys
+// don't match a field are ignored.  Unmarshaling into a Term or an
+// empty interface stores t itself.  Unmarshaling a blob stores the Go
+// value it wraps, if that value can be assigned to v.
+//
+// When t doesn't fit v, the error is an *Exception describing the
+// problem the way a builtin predicate would: a type error for a term
+// of the wrong type, an instantiation error for a variable or a
+// representation error for an integer which doesn't fit.
+func Unmarshal(t Term, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("term: Unmarshal needs a non-nil pointer, not %T", v)
+	}
+	return unmarshal(t, rv.Elem())
+}
+
+func unmarshal(t Term, v reflect.Value) error {
+	if v.Type() == termType || (v.Kind() == reflect.Interface && v.NumMethod() == 0) {
+		v.Set(reflect.ValueOf(&t).Elem())
+		return nil
+	}
+	if IsVariable(t) {
+		return NewException(InstantiationError())
+	}
+	if IsBlob(t) {
+		x := reflect.ValueOf(t.(*Blob).Value())
+		if x.IsValid() && x.Type().AssignableTo(v.Type()) {
+			v.Set(x)
+			return nil
+		}
+		return NewException(TypeError(v.Type().String(), t))
+	}
+
+	switch v.Type() {
+	case bigIntType:
+		if !IsInteger(t) {
+			return NewException(TypeError("integer", t))
+		}
+		v.Set(reflect.ValueOf(new(big.Int).Set(t.(*Integer).Value())))
+		return nil
+	case bigRatType:
+		var r *big.Rat
+		switch n := t.(type) {
+		case *Integer:
+			r = new(big.Rat).SetInt(n.Value())
+		case *Rational:
+			r = new(big.Rat).Set(n.Value())
+		default:
+			return NewException(TypeError("rational", t))
+		}
+		v.Set(reflect.ValueOf(r))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr:
+		p := reflect.New(v.Type().Elem())
+		if err := unmarshal(t, p.Elem()); err != nil {
+			return err
+		}
+		v.Set(p)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if !IsInteger(t) {
+			return NewException(TypeError("integer", t))
+		}
+		n := t.(*Integer).Value()
+		min := -(int64(1) << (v.Type().Bits() - 1))
+		max := int64(uint64(math.MaxUint64) >> (65 - v.Type().Bits()))
+		if err := checkRange(n, big.NewInt(min), big.NewInt(max)); err != nil {
+			return err
+		}
+		v.SetInt(n.Int64())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if !IsInteger(t) {
+			return NewException(TypeError("integer", t))
+		}
+		n := t.(*Integer).Value()
+		max := new(big.Int).SetUint64(uint64(math.MaxUint64) >> (64 - v.Type().Bits()))
+		if err := checkRange(n, new(big.Int), max); err != nil {
+			return err
+		}
+		v.SetUint(n.Uint64())
+	case reflect.Float32, reflect.Float64:
+		if !IsNumber(t) {
+			return NewException(TypeError("number", t))
+		}
+		v.SetFloat(t.(Number).Float64())
+	case reflect.String:
+		if !IsAtom(t) {
+			return NewException(TypeError("atom", t))
+		}
+		v.SetString(t.(*Atom).Name())
+	case reflect.Bool:
+		if !IsAtom(t) {
+			return NewException(TypeError("boolean", t))
+		}
+		switch t.(*Atom).Name() {
+		case "true":
+			v.SetBool(true)
+		case "false":
+			v.SetBool(false)
+		default:
+			return NewException(TypeError("boolean", t))
+		}
+	case reflect.Slice:
+		elems, err := listElements(t)
+		if err != nil {
+			return err
+		}
+		s := reflect.MakeSlice(v.Type(), len(elems), len(elems))
+		for i, elem := range elems {
+			if err := unmarshal(elem, s.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return fmt.Errorf("term: can't unmarshal into %s", v.Type())
+		}
+		pairs, err := listPairs(t)
+		if err != nil {
+			return err
+		}
+		m := reflect.MakeMapWithSize(v.Type(), len(pairs))
+		for _, pair := range pairs {
+			x := reflect.New(v.Type().Elem()).Elem()
+			if err := unmarshal(pair.value, x); err != nil {
+				return err
+			}
+			m.SetMapIndex(reflect.ValueOf(pair.key).Convert(v.Type().Key()), x)
+		}
+		v.Set(m)
+	case reflect.Struct:
+		return unmarshalStruct(t, v)
+	default:
+		return fmt.Errorf("term: can't unmarshal into %s", v.Type())
+	}
+	return nil
+}
+
+// unmarshalStruct unmarshals a compound term or a list of pairs into
+// struct v
+func unmarshalStruct(t Term, v reflect.Value) error {
+	fields := structFields(v.Type())
+
+	// a list of pairs
+	if IsEmptyList(t) || t.Indicator() == "./2" {
+		pairs, err := listPairs(t)
+		if err != nil {
+			return err
+		}
+		for _, pair := range pairs {
+			for _, f := range fields {
+				if f.key == pair.key {
+					if err := unmarshal(pair.value, v.Field(f.index)); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		return nil
+	}
+
+	// a compound term
+	if v.Type().Name() == "" {
+		return NewException(TypeError("list", t))
+	}
+	name := snakeCase(v.Type().Name())
+	if !IsCallable(t) || t.(Callable).Name() != name || t.(Callable).Arity() != len(fields) {
+		return NewException(TypeError(name, t))
+	}
+	args := t.(Callable).Arguments()
+	for i, f := range fields {
+		if err := unmarshal(args[i], v.Field(f.index)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkRange returns a representation error if n isn't between min
+// and max, inclusive
+func checkRange(n, min, max *big.Int) error {
+	if n.Cmp(min) < 0 {
+		return NewException(RepresentationError("min_integer"))
+	}
+	if n.Cmp(max) > 0 {
+		return NewException(RepresentationError("max_integer"))
+	}
+	return nil
+}
+
+// listElements returns the elements of list t.  Returns an error if t
+// isn't a proper list.
+func listElements(t Term) ([]Term, error) {
+	var elems []Term
+	for list := t; !IsEmptyList(list); {
+		if IsVariable(list) {
+			return nil, NewException(InstantiationError())
+		}
+		if list.Indicator() != "./2" {
+			return nil, NewException(TypeError("list", t))
+		}
+		args := list.(*Compound).Arguments()
+		elems = append(elems, args[0])
+		list = args[1]
+	}
+	return elems, nil
+}
+
+type pair struct {
+	key   string
+	value Term
+}
+
+// listPairs returns the elements of a list of Key-Value or Key=Value
+// pairs, where each key is an atom
+func listPairs(t Term) ([]pair, error) {
+	elems, err := listElements(t)
+	if err != nil {
+		return nil, err
+	}
+	pairs := make([]pair, len(elems))
+	for i, elem := range elems {
+		if IsVariable(elem) {
+			return nil, NewException(InstantiationError())
+		}
+		if elem.Indicator() != "-/2" && elem.Indicator() != "=/2" {
+			return nil, NewException(TypeError("pair", elem))
+		}
+		args := elem.(*Compound).Arguments()
+		if IsVariable(args[0]) {
+			return nil, NewException(InstantiationError())
+		}
+		if !IsAtom(args[0]) {
+			return nil, NewException(TypeError("atom", args[0]))
+		}
+		pairs[i] = pair{key: args[0].(*Atom).Name(), value: args[1]}
+	}
+	return pairs, nil
+}
+
+// field describes a struct field that's converted to and from terms
+type field struct {
+	index int    // index of the field in its struct
+	name  string // Go name of the field
+	key   string // key in a list of pairs
+	tag   string // the field's golog struct tag
+}
+
+// structFields returns the exported fields of struct type t which
+// aren't tagged `golog:"-"`
+func structFields(t reflect.Type) []field {
+	var fields []field
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("golog")
+		if f.PkgPath != "" || tag == "-" { // unexported or ignored
+			continue
+		}
+		key := tag
+		if key == "" {
+			key = snakeCase(f.Name)
+		}
+		fields = append(fields, field{index: i, name: f.Name, key: key, tag: tag})
+	}
+	return fields
+}
+
+// snakeCase converts a Go name like LineSegment into an atom name
+// like line_segment
+func snakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, c := range runes {
+		if unicode.IsUpper(c) {
+			// an upper case letter starts a word unless it's part of
+			// an acronym like the "ID" in "UserID"
+			prevLower := i > 0 && !unicode.IsUpper(runes[i-1])
+			nextLower := i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || nextLower {
+				b.WriteRune('_')
+			}
+			c = unicode.ToLower(c)
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// Decode stores the values of b's named variables in the struct
+// pointed to by v.  Each exported field is set from the variable with
+// the same name, or the name given by a `golog:"Name"` struct tag.
+// Fields whose variables are missing or unbound are left unchanged.
+// Values are converted as described for Unmarshal.
+func (self *envMap) Decode(v interface{}) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("term: Decode needs a pointer to a struct, not %T", v)
+	}
+	rv = rv.Elem()
+
+	for _, f := range structFields(rv.Type()) {
+		name := f.tag
+		if name == "" {
+			name = f.name
+		}
+		t, err := self.ByName(name)
+		if err != nil || IsVariable(t) {
+			continue
+		}
+		if err := unmarshal(t, rv.Field(f.index)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,193 @@
+package term
+
+import (
+	"io"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+type LineSegment struct {
+	From, To Point
+	Label    string `golog:"name"`
+	Weight   float64
+	Tags     []string `golog:"-"`
+	hidden   int
+}
+
+type Point struct {
+	X, Y int
+}
+
+type Tree struct {
+	Value    int
+	Children []Tree
+}
+
+func TestMarshal(t *testing.T) {
+	seven := 7
+	tests := []struct {
+		v    interface{}
+		want string
+	}{
+		{42, `42`},
+		{uint8(255), `255`},
+		{-1.5, `-1.5`},
+		{"hello world", `'hello world'`},
+		{true, `true`},
+		{big.NewInt(12), `12`},
+		{big.NewRat(4, 2), `2`},
+		{[]string{"a", "b"}, `[a,b]`},
+		{[]string{}, `[]`},
+		{&seven, `7`},
+		{map[string]int{"b": 2, "a": 1}, `[-(a, 1),-(b, 2)]`},
+		{Point{1, 2}, `point(1, 2)`},
+		{
+			LineSegment{From: Point{0, 0}, To: Point{3, 4}, Label: "a", Weight: 2.5},
+			`line_segment(point(0, 0), point(3, 4), a, 2.5)`,
+		},
+		{struct{ UserID, Name string }{"u1", "bob"}, `[-(user_id, u1),-(name, bob)]`},
+		{NewCallable("f", NewVar("X")), `f(X)`},
+	}
+	for _, test := range tests {
+		x, err := Marshal(test.v)
+		if err != nil {
+			t.Errorf("Can't marshal %#v: %s", test.v, err)
+			continue
+		}
+		if got := x.String(); got != test.want {
+			t.Errorf("Marshaling %#v gave %s instead of %s", test.v, got, test.want)
+		}
+	}
+
+	// values that can't be marshaled
+	var p *Point
+	for _, v := range []interface{}{nil, p, make(chan int), map[int]int{}} {
+		if _, err := Marshal(v); err == nil {
+			t.Errorf("Marshaled %#v", v)
+		}
+	}
+}
+
+func TestUnmarshal(t *testing.T) {
+	// a round trip through Marshal
+	seg := LineSegment{From: Point{0, 0}, To: Point{3, 4}, Label: "a", Weight: 2.5}
+	x, err := Marshal(seg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got LineSegment
+	if err := Unmarshal(x, &got); err != nil {
+		t.Fatalf("Can't unmarshal %s: %s", x, err)
+	}
+	if got.From != seg.From || got.To != seg.To || got.Label != "a" || got.Weight != 2.5 {
+		t.Errorf("Wrong line segment: %+v", got)
+	}
+
+	// structs from lists of pairs
+	x = SliceToList([]Term{
+		NewCallable("=", NewAtom("name"), NewAtom("b")),
+		NewCallable("-", NewAtom("weight"), NewInt64(3)),
+		NewCallable("-", NewAtom("unknown"), NewAtom("ignored")),
+	})
+	got = LineSegment{}
+	if err := Unmarshal(x, &got); err != nil {
+		t.Fatalf("Can't unmarshal %s: %s", x, err)
+	}
+	if got.Label != "b" || got.Weight != 3.0 {
+		t.Errorf("Wrong line segment from pairs: %+v", got)
+	}
+
+	// maps, pointers and terms
+	var m map[string]*int
+	x = SliceToList([]Term{NewCallable("-", NewAtom("a"), NewInt64(1))})
+	if err := Unmarshal(x, &m); err != nil || *m["a"] != 1 {
+		t.Errorf("Wrong map: %v (%v)", m, err)
+	}
+	var anything interface{}
+	v := NewVar("X")
+	if err := Unmarshal(v, &anything); err != nil || anything != Term(v) {
+		t.Errorf("Wrong interface value: %v (%v)", anything, err)
+	}
+	var r *big.Rat
+	if err := Unmarshal(NewInt64(3), &r); err != nil || r.Cmp(big.NewRat(3, 1)) != 0 {
+		t.Errorf("Wrong rational: %v (%v)", r, err)
+	}
+
+	// errors are ISO exceptions
+	var i8 int8
+	var s string
+	var p Point
+	var is []int
+	errors := []struct {
+		t    Term
+		v    interface{}
+		want string
+	}{
+		{NewAtom("a"), &i8, `type_error(integer, a)`},
+		{NewInt64(200), &i8, `representation_error(max_integer)`},
+		{NewVar("X"), &s, `instantiation_error`},
+		{NewInt64(1), &s, `type_error(atom, 1)`},
+		{NewCallable("f", NewInt64(1)), &p, `type_error(point, f(1))`},
+		{NewCallable(".", NewInt64(1), NewVar("T")), &is, `instantiation_error`},
+		{NewAtom("foo"), &is, `type_error(list, foo)`},
+	}
+	for _, test := range errors {
+		err := Unmarshal(test.t, test.v)
+		e, ok := err.(*Exception)
+		if !ok {
+			t.Errorf("Unmarshaling %s gave %v", test.t, err)
+			continue
+		}
+		got := e.Ball().(*Compound).Arguments()[0].String()
+		if got != test.want {
+			t.Errorf("Unmarshaling %s threw %s instead of %s", test.t, got, test.want)
+		}
+	}
+	if err := Unmarshal(NewInt64(1), i8); err == nil {
+		t.Errorf("Unmarshaled into a non-pointer")
+	}
+}
+
+func TestCanMarshal(t *testing.T) {
+	type C struct{ C chan int }
+	type c struct{ c chan int }
+	writer := reflect.TypeOf((*io.Writer)(nil)).Elem()
+	tests := []struct {
+		v    interface{}
+		want bool
+	}{
+		{int8(0), true},
+		{uint64(0), true},
+		{"", true},
+		{false, true},
+		{1.5, true},
+		{new(big.Int), true},
+		{new(big.Rat), true},
+		{NewAtom("a"), true},
+		{[]string{}, true},
+		{map[string][]int{}, true},
+		{struct{ A int }{}, true},
+		{LineSegment{}, true},
+		{Tree{}, true},
+		{&Point{}, true},
+		{c{}, true},
+		{make(chan int), false},
+		{func() {}, false},
+		{map[int]string{}, false},
+		{[]chan int{}, false},
+		{C{}, false},
+		{complex(1, 2), false},
+		{[2]int{}, false},
+		{map[string]func(){}, false},
+	}
+	for _, test := range tests {
+		typ := reflect.TypeOf(test.v)
+		if got := CanMarshal(typ); got != test.want {
+			t.Errorf("CanMarshal(%s) gave %t", typ, got)
+		}
+	}
+	if !CanMarshal(writer) {
+		t.Errorf("CanMarshal(%s) gave false", writer)
+	}
+}
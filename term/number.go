@@ -1,6 +1,6 @@
 package term
 
-import "fmt"
+import "math"
 import "math/big"
 import . "github.com/mndrix/golog/util"
 
@@ -50,8 +50,10 @@ func IsNumber(t Term) bool {
 }
 
 // Evaluate an arithmetic expression to produce a number.  This is
-// conceptually similar to Prolog: X is Expression.  Returns false if
-// the expression cannot be evaluated (unbound variables, in)
+// conceptually similar to Prolog: X is Expression.  All evaluable functors
+// from ISO §9 are supported along with some common extensions (see
+// arithmetic.go).  Returns an *Exception error if the expression cannot be
+// evaluated (unbound variables, unknown functions, division by zero, etc.)
 func ArithmeticEval(t0 Term) (Number, error) {
 	Debugf("arith eval: %s\n", t0)
 
@@ -59,39 +61,44 @@ func ArithmeticEval(t0 Term) (Number, error) {
 	if IsNumber(t0) {
 		return t0.(Number), nil
 	}
+	if IsVariable(t0) {
+		return nil, NewException(InstantiationError())
+	}
 	var t Callable
 	if IsCallable(t0) {
 		t = t0.(Callable)
 	} else {
-		msg := fmt.Sprintf("Unexpected arithmetic term: %s", t0)
-		panic(msg)
+		return nil, NewException(TypeError("evaluable", t0))
 	}
 
 	// evaluate arithmetic expressions
-	if t.Arity() == 2 {
-		// evaluate arguments recursively
-		args := t.Arguments()
-		a, b, err := ArithmeticEval2(args[0], args[1])
-		if err != nil {
-			return nil, err
+	switch t.Arity() {
+	case 0:
+		if f, ok := constants[t.Name()]; ok {
+			return f()
 		}
-
-		switch t.Name() {
-		case "*": // multiplication
-			return ArithmeticMultiply(a, b)
-		case "+": // addition
-			return ArithmeticAdd(a, b)
-		case "-": // subtraction
-			return ArithmeticMinus(a, b)
-		case "/": // division
-			return ArithmeticDivide(a, b)
+	case 1:
+		if f, ok := unaryFunctions[t.Name()]; ok {
+			x, err := ArithmeticEval(t.Arguments()[0])
+			if err != nil {
+				return nil, err
+			}
+			return f(x)
+		}
+	case 2:
+		if f, ok := binaryFunctions[t.Name()]; ok {
+			args := t.Arguments()
+			a, b, err := ArithmeticEval2(args[0], args[1])
+			if err != nil {
+				return nil, err
+			}
+			return f(a, b)
 		}
-
 	}
 
 	// this term doesn't look like an expression
-	msg := fmt.Errorf("Not an expression: %s", t)
-	return nil, msg
+	indicator := NewCallable("/", NewAtom(t.Name()), NewInt64(int64(t.Arity())))
+	return nil, NewException(TypeError("evaluable", indicator))
 }
 
 func ArithmeticEval2(first, second Term) (Number, Number, error) {
@@ -111,97 +118,95 @@ func ArithmeticEval2(first, second Term) (Number, Number, error) {
 func ArithmeticAdd(a, b Number) (Number, error) {
 
 	// as integers?
-	if xi, ok := a.LosslessInt(); ok {
-		if yi, ok := b.LosslessInt(); ok {
-			r := new(big.Int).Add(xi, yi)
-			return NewBigInt(r), nil
-		}
+	if IsInteger(a) && IsInteger(b) {
+		xi := a.(*Integer).Value()
+		yi := b.(*Integer).Value()
+		r := new(big.Int).Add(xi, yi)
+		return NewBigInt(r), nil
 	}
 
 	// as rationals?
 	if xr, ok := a.LosslessRat(); ok {
 		if yr, ok := b.LosslessRat(); ok {
-			r := new(big.Rat).Add(xr, yr)
-			return NewBigRat(r), nil
+			return rationalResult(new(big.Rat).Add(xr, yr))
 		}
 	}
 
 	// as floats?
-	r := a.Float64() + b.Float64()
-	return NewFloat64(r), nil
+	return floatResult(a.Float64() + b.Float64())
 }
 
 // Divide two Golog numbers returning the result as a new Golog number.
 // The return value uses the most precise internal type possible.
 func ArithmeticDivide(a, b Number) (Number, error) {
+	if isZero(b) {
+		return nil, NewException(EvaluationError("zero_divisor"))
+	}
 
-	// as integers?
-	if xi, ok := a.LosslessInt(); ok {
-		if yi, ok := b.LosslessInt(); ok {
-			r := new(big.Rat).SetFrac(xi, yi)
-			return NewBigRat(r), nil
+	// as integers?  exact quotients stay integers
+	if IsInteger(a) && IsInteger(b) {
+		xi := a.(*Integer).Value()
+		yi := b.(*Integer).Value()
+		q, m := new(big.Int).QuoRem(xi, yi, new(big.Int))
+		if m.Sign() == 0 {
+			return NewBigInt(q), nil
 		}
+		return rationalResult(new(big.Rat).SetFrac(xi, yi))
 	}
 
 	// as rationals?
 	if xr, ok := a.LosslessRat(); ok {
 		if yr, ok := b.LosslessRat(); ok {
-			r := new(big.Rat).Quo(xr, yr)
-			return NewBigRat(r), nil
+			return rationalResult(new(big.Rat).Quo(xr, yr))
 		}
 	}
 
 	// as floats?
-	r := a.Float64() / b.Float64()
-	return NewFloat64(r), nil
+	return floatResult(a.Float64() / b.Float64())
 }
 
 // Subtract two Golog numbers returning the result as a new Golog number
 func ArithmeticMinus(a, b Number) (Number, error) {
 
 	// as integers?
-	if xi, ok := a.LosslessInt(); ok {
-		if yi, ok := b.LosslessInt(); ok {
-			r := new(big.Int).Sub(xi, yi)
-			return NewBigInt(r), nil
-		}
+	if IsInteger(a) && IsInteger(b) {
+		xi := a.(*Integer).Value()
+		yi := b.(*Integer).Value()
+		r := new(big.Int).Sub(xi, yi)
+		return NewBigInt(r), nil
 	}
 
 	// as rationals?
 	if xr, ok := a.LosslessRat(); ok {
 		if yr, ok := b.LosslessRat(); ok {
-			r := new(big.Rat).Sub(xr, yr)
-			return NewBigRat(r), nil
+			return rationalResult(new(big.Rat).Sub(xr, yr))
 		}
 	}
 
 	// as floats?
-	r := a.Float64() - b.Float64()
-	return NewFloat64(r), nil
+	return floatResult(a.Float64() - b.Float64())
 }
 
 // Multiply two Golog numbers returning the result as a new Golog number
 func ArithmeticMultiply(a, b Number) (Number, error) {
 
 	// as integers?
-	if xi, ok := a.LosslessInt(); ok {
-		if yi, ok := b.LosslessInt(); ok {
-			r := new(big.Int).Mul(xi, yi)
-			return NewBigInt(r), nil
-		}
+	if IsInteger(a) && IsInteger(b) {
+		xi := a.(*Integer).Value()
+		yi := b.(*Integer).Value()
+		r := new(big.Int).Mul(xi, yi)
+		return NewBigInt(r), nil
 	}
 
 	// as rationals?
 	if xr, ok := a.LosslessRat(); ok {
 		if yr, ok := b.LosslessRat(); ok {
-			r := new(big.Rat).Mul(xr, yr)
-			return NewBigRat(r), nil
+			return rationalResult(new(big.Rat).Mul(xr, yr))
 		}
 	}
 
 	// as floats?
-	r := a.Float64() * b.Float64()
-	return NewFloat64(r), nil
+	return floatResult(a.Float64() * b.Float64())
 }
 
 // Compare two Golog numbers.  Returns
@@ -209,28 +214,54 @@ func ArithmeticMultiply(a, b Number) (Number, error) {
 //    -1 if a <  b
 //     0 if a == b
 //    +1 if a > b
+//
+// Finite floats are compared exactly, as if they were rationals.  So the
+// rational 1/10 (as read from "0.1") is less than the float64 nearest
+// to it.
 func NumberCmp(a, b Number) int {
 	// compare as integers?
-	if xi, ok := a.LosslessInt(); ok {
-		if yi, ok := b.LosslessInt(); ok {
-			return xi.Cmp(yi)
-		}
+	if IsInteger(a) && IsInteger(b) {
+		return a.(*Integer).Value().Cmp(b.(*Integer).Value())
 	}
 
 	// compare as rationals?
-	if xr, ok := a.LosslessRat(); ok {
-		if yr, ok := b.LosslessRat(); ok {
+	if xr, ok := exactRat(a); ok {
+		if yr, ok := exactRat(b); ok {
 			return xr.Cmp(yr)
 		}
 	}
 
-	// compare as floats?
-	diff := a.Float64() - b.Float64()
-	if diff < 0 {
+	// compare as floats (at least one is infinite or NaN)
+	x, y := a.Float64(), b.Float64()
+	switch {
+	case x < y:
 		return -1
-	}
-	if diff > 0 {
+	case x > y:
 		return 1
 	}
 	return 0
 }
+
+// exactRat returns the exact rational value of a number.  Only infinite
+// and NaN floats have no such value.
+func exactRat(n Number) (*big.Rat, bool) {
+	if r, ok := n.LosslessRat(); ok {
+		return r, true
+	}
+	f := n.Float64()
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil, false
+	}
+	return new(big.Rat).SetFloat64(f), true
+}
+
+// isZero returns true if a number is equal to zero
+func isZero(n Number) bool {
+	if i, ok := n.LosslessInt(); ok {
+		return i.Sign() == 0
+	}
+	if r, ok := n.LosslessRat(); ok {
+		return r.Sign() == 0
+	}
+	return n.Float64() == 0
+}
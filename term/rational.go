@@ -1,6 +1,5 @@
 package term
 
-import "fmt"
 import "math/big"
 
 // Rational is a specialized, internal representation of floats.
@@ -26,12 +25,8 @@ func (self *Rational) Value() *big.Rat {
 }
 
 func (self *Rational) String() string {
-	val := self.Value()
-	if val.IsInt() {
-		return val.RatString()
-	}
-	f, _ := val.Float64()
-	return fmt.Sprintf("%g", f)
+	f, _ := self.Value().Float64()
+	return formatFloat(f)
 }
 
 func (self *Rational) Type() int {
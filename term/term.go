@@ -24,6 +24,7 @@ const (
 	FloatType
 	IntegerType
 	AtomType
+	BlobType
 	CompoundType
 
 	// odd man out
@@ -91,6 +92,7 @@ func IsClause(t Term) bool {
 		VariableType,
 		IntegerType,
 		FloatType,
+		BlobType,
 		ErrorType:
 		return false
 	}
@@ -146,6 +148,7 @@ func renameVariables(t Term, renamed map[string]*Variable) Term {
 	case FloatType,
 		IntegerType,
 		AtomType,
+		BlobType,
 		ErrorType:
 		return t
 	case CompoundType:
@@ -159,10 +162,7 @@ func renameVariables(t Term, renamed map[string]*Variable) Term {
 		return newTerm
 	case VariableType:
 		x := t.(*Variable)
-		name := x.Name
-		if name == "_" {
-			name = x.Indicator()
-		}
+		name := x.Name + x.Indicator() // same name might have many ids
 		v, ok := renamed[name]
 		if ok {
 			return v
@@ -183,6 +183,7 @@ func Variables(t Term) ps.Map {
 	case AtomType,
 		FloatType,
 		IntegerType,
+		BlobType,
 		ErrorType:
 		return names
 	case CompoundType:
@@ -204,6 +205,31 @@ func Variables(t Term) ps.Map {
 	panic("Unexpected term implementation")
 }
 
+// OrderedVariables returns the distinct variables of term t in the
+// order they're first encountered by a depth-first, left-to-right
+// traversal.  That's the order ISO calls a term's variable set.
+func OrderedVariables(t Term) []*Variable {
+	seen := make(map[string]bool)
+	return orderedVariables(t, seen, nil)
+}
+
+func orderedVariables(t Term, seen map[string]bool, vars []*Variable) []*Variable {
+	switch t.Type() {
+	case CompoundType:
+		for _, arg := range t.(*Compound).Arguments() {
+			vars = orderedVariables(arg, seen, vars)
+		}
+	case VariableType:
+		x := t.(*Variable)
+		key := x.Name + x.Indicator()
+		if !seen[key] {
+			seen[key] = true
+			vars = append(vars, x)
+		}
+	}
+	return vars
+}
+
 // QuoteFunctor returns a canonical representation of a term's name
 // by quoting characters that require quoting
 func QuoteFunctor(name string) string {
@@ -220,7 +246,7 @@ func QuoteFunctor(name string) string {
 			break
 		}
 	}
-	if allGraphic || name == "[]" || name == "!" || name == ";" {
+	if allGraphic || name == "[]" || name == "{}" || name == "!" || name == ";" {
 		return name
 	}
 
@@ -228,7 +254,8 @@ func QuoteFunctor(name string) string {
 	maybePanic(err)
 	nonLower, err := MatchString(`^[^a-z]`, name)
 	if nonAlpha || nonLower {
-		escapedName := strings.Replace(name, `'`, `\'`, -1)
+		escapedName := strings.Replace(name, `\`, `\\`, -1)
+		escapedName = strings.Replace(escapedName, `'`, `\'`, -1)
 		return Sprintf("'%s'", escapedName)
 	}
 
@@ -249,9 +276,10 @@ func NewCodeListFromDoubleQuotedString(s string) Term {
 	}
 
 	// build a cons cell chain, starting at the end ([])
+	content := unescape(runes[1 : end+1])
 	codes := NewAtom(`[]`)
-	for i := end; i > 0; i-- {
-		c := NewCode(runes[i])
+	for i := len(content) - 1; i >= 0; i-- {
+		c := NewCode(content[i])
 		codes = NewCallable(".", c, codes)
 	}
 
@@ -291,6 +319,8 @@ func Precedes(a, b Term) bool {
 		x := a.(*Atom)
 		y := b.(*Atom)
 		return x.Name() < y.Name()
+	case BlobType:
+		return a.(*Blob).id < b.(*Blob).id
 	case CompoundType:
 		x := a.(*Compound)
 		y := b.(*Compound)
@@ -319,6 +349,45 @@ func Precedes(a, b Term) bool {
 	msg := Sprintf("Unexpected term type %s\n", a)
 	panic(msg)
 }
+
+// Variant returns true if a and b are identical terms after
+// consistently renaming variables.  For example, f(X,Y,X) is a variant
+// of f(A,B,A) but not of f(A,A,B).  This is =@=/2 in many Prologs.
+func Variant(a, b Term) bool {
+	return variant(a, b, make(map[string]string), make(map[string]string))
+}
+func variant(a, b Term, ab, ba map[string]string) bool {
+	if IsVariable(a) && IsVariable(b) {
+		x := a.Indicator()
+		y := b.Indicator()
+		xy, xok := ab[x]
+		yx, yok := ba[y]
+		if !xok && !yok {
+			ab[x] = y
+			ba[y] = x
+			return true
+		}
+		return xy == y && yx == x
+	}
+	if IsCompound(a) && IsCompound(b) {
+		x := a.(*Compound)
+		y := b.(*Compound)
+		if x.Arity() != y.Arity() || x.Name() != y.Name() {
+			return false
+		}
+		for i := 0; i < x.Arity(); i++ {
+			if !variant(x.Args[i], y.Args[i], ab, ba) {
+				return false
+			}
+		}
+		return true
+	}
+	if a.Type() != b.Type() || IsVariable(a) || IsCompound(a) {
+		return false
+	}
+	return !Precedes(a, b) && !Precedes(b, a)
+}
+
 func precedence(t Term) int {
 	value := t.Type()       // Type() promises values in precedence order
 	if value == FloatType { // See Note_1
@@ -395,6 +464,9 @@ func UnificationHash(terms []Term, n uint, preparation bool) uint64 {
 		case *Float:
 			str := strconv.FormatFloat(t.Value(), 'b', 0, 64)
 			hash = hash | (hashString(str) & mask)
+		case *Blob:
+			str := Sprintf("<blob>%d", t.id)
+			hash = hash | (hashString(str) & mask)
 		case *Error:
 			panic("No UnificationHash for Error terms")
 		case *Compound:
@@ -156,3 +156,115 @@ func TestFloat(t *testing.T) {
 		}
 	}
 }
+
+func TestFloatString(t *testing.T) {
+	tests := []struct {
+		n    Number
+		want string
+	}{
+		{NewFloat64(2), `2.0`},
+		{NewFloat64(-0.5), `-0.5`},
+		{NewFloat64(1e20), `1.0e+20`},
+		{NewFloat64(1.5e-7), `1.5e-07`},
+		{NewFloat("3.0"), `3.0`},
+		{NewFloat("0.25"), `0.25`},
+	}
+	for _, test := range tests {
+		if got := test.n.String(); got != test.want {
+			t.Errorf("Float printed as `%s` wanted `%s`", got, test.want)
+		}
+	}
+}
+
+func TestRenameVariables(t *testing.T) {
+	// two distinct variables which happen to share a name
+	x1 := NewVar("X").WithNewId()
+	x2 := NewVar("X").WithNewId()
+	renamed := RenameVariables(NewCallable("f", x1, x2, x1)).(Callable)
+	args := renamed.Arguments()
+	if args[0] == args[1] {
+		t.Errorf("Distinct variables were merged: %s", renamed)
+	}
+	if args[0] != args[2] {
+		t.Errorf("Same variable was renamed twice: %s", renamed)
+	}
+
+	vars := OrderedVariables(NewCallable("g", x2, NewCallable("h", x1), x2))
+	if len(vars) != 2 || vars[0] != x2 || vars[1] != x1 {
+		t.Errorf("Wrong variable order: %v", vars)
+	}
+}
+
+func TestVariant(t *testing.T) {
+	x := NewVar("X").WithNewId()
+	y := NewVar("Y").WithNewId()
+	a := NewVar("A").WithNewId()
+	b := NewVar("B").WithNewId()
+
+	f := func(args ...Term) Term { return NewCallable("f", args...) }
+	variants := [][2]Term{
+		{f(x, y, x), f(a, b, a)},
+		{f(x), f(x)},
+		{f(NewAtom("a"), x), f(NewAtom("a"), y)},
+		{NewInt64(3), NewInt64(3)},
+	}
+	for _, pair := range variants {
+		if !Variant(pair[0], pair[1]) {
+			t.Errorf("%s should be a variant of %s", pair[0], pair[1])
+		}
+	}
+
+	others := [][2]Term{
+		{f(x, y, x), f(a, a, b)},
+		{f(x, x), f(a, b)},
+		{f(x, y), f(a, a)},
+		{f(NewAtom("a")), f(x)},
+		{NewInt64(3), NewFloat64(3.0)},
+	}
+	for _, pair := range others {
+		if Variant(pair[0], pair[1]) {
+			t.Errorf("%s shouldn't be a variant of %s", pair[0], pair[1])
+		}
+	}
+}
+
+func TestBlob(t *testing.T) {
+	value := []int{1, 2, 3}
+	a := NewBlob(value)
+	b := NewBlob(value)
+
+	if !IsBlob(a) || IsBlob(NewAtom("a")) {
+		t.Errorf("IsBlob is wrong")
+	}
+	if a.Value().([]int)[2] != 3 {
+		t.Errorf("Blob has the wrong value: %v", a.Value())
+	}
+	if ok, _ := MatchString(`^<blob>\(0x[0-9a-f]+\)$`, a.String()); !ok {
+		t.Errorf("Blob has the wrong text: %s", a.String())
+	}
+
+	// blobs unify by identity
+	env := NewBindings()
+	if _, err := a.Unify(env, a); err != nil {
+		t.Errorf("A blob doesn't unify with itself")
+	}
+	if _, err := a.Unify(env, b); err != CantUnify {
+		t.Errorf("Different blobs unify")
+	}
+	x := NewVar("X")
+	env, err := x.Unify(env, a)
+	if err != nil || env.Resolve_(x) != Term(a) {
+		t.Errorf("Variable didn't bind to blob")
+	}
+
+	// standard order: atom < blob < compound
+	order := []Term{NewAtom("z"), a, b, NewCallable("a", NewAtom("b"))}
+	for i := 0; i < len(order)-1; i++ {
+		if !Precedes(order[i], order[i+1]) || Precedes(order[i+1], order[i]) {
+			t.Errorf("%s should precede %s", order[i], order[i+1])
+		}
+	}
+	if !Variant(a, a) || Variant(a, b) {
+		t.Errorf("Blob variants are wrong")
+	}
+}
@@ -0,0 +1,204 @@
+package golog
+
+// Helpers for the builtins which convert between atoms, numbers and
+// lists of characters or character codes.  Text is always handled as
+// a sequence of Unicode code points, never as bytes.
+
+import (
+	"strings"
+
+	"github.com/mndrix/golog/read"
+	. "github.com/mndrix/golog/term"
+)
+
+// atomicText returns the text of an atom or a number, the way
+// atomic_list_concat/3 sees it
+func atomicText(t Term) (string, bool) {
+	switch {
+	case IsAtom(t):
+		return t.(*Atom).Name(), true
+	case IsNumber(t):
+		return t.String(), true
+	}
+	return "", false
+}
+
+// listText returns the text represented by a list of character codes
+// (if codes is true) or a list of characters.  On failure, returns the
+// ISO exception term to throw.
+func listText(list Term, codes bool) (string, Term) {
+	var b strings.Builder
+	for {
+		switch {
+		case IsVariable(list):
+			return "", InstantiationError()
+		case IsEmptyList(list):
+			return b.String(), nil
+		case list.Indicator() != "./2":
+			return "", TypeError("list", list)
+		}
+		args := list.(*Compound).Arguments()
+		x := args[0]
+		switch {
+		case IsVariable(x):
+			return "", InstantiationError()
+		case codes && !IsInteger(x):
+			return "", TypeError("integer", x)
+		case codes && (!isInCharacterCode(x) || x.(*Integer).Code() < 0):
+			return "", RepresentationError("character_code")
+		case codes:
+			b.WriteRune(x.(*Integer).Code())
+		case !isCharacter(x):
+			return "", TypeError("character", x)
+		default:
+			b.WriteString(x.(*Atom).Name())
+		}
+		list = args[1]
+	}
+}
+
+// textList builds a list of character codes (if codes is true) or a
+// list of characters from text
+func textList(text string, codes bool) Term {
+	var ts []Term
+	for _, c := range text {
+		if codes {
+			ts = append(ts, NewCode(c))
+		} else {
+			ts = append(ts, NewAtom(string(c)))
+		}
+	}
+	return SliceToList(ts)
+}
+
+// parseNumber converts text into a number using Prolog syntax.  Leading
+// layout is allowed, as is a minus sign.
+func parseNumber(text string) (n Number, ok bool) {
+	if strings.TrimSpace(text) == "" {
+		return nil, false
+	}
+	defer func() { // the reader panics on some malformed text
+		if x := recover(); x != nil {
+			n, ok = nil, false
+		}
+	}()
+	ts, err := read.TermAll(text + " .")
+	if err != nil || len(ts) != 1 || !IsNumber(ts[0]) {
+		return nil, false
+	}
+	return ts[0].(Number), true
+}
+
+// numberText implements number_codes/2 and number_chars/2
+func numberText(args []Term, codes bool) ForeignReturn {
+	n, list := args[0], args[1]
+	if !IsVariable(n) && !IsNumber(n) {
+		return ForeignThrow(TypeError("number", n))
+	}
+
+	text, ball := listText(list, codes)
+	if ball != nil {
+		if IsVariable(n) || !isInstantiationError(ball) {
+			return ForeignThrow(ball)
+		}
+		return ForeignUnify(list, textList(n.String(), codes))
+	}
+
+	x, ok := parseNumber(text)
+	if !ok {
+		return ForeignThrow(SyntaxError("illegal_number"))
+	}
+	return ForeignUnify(n, x)
+}
+
+// isInstantiationError returns true if ball is an ISO instantiation error
+func isInstantiationError(ball Term) bool {
+	if ball.Indicator() != "error/2" {
+		return false
+	}
+	formal := ball.(*Compound).Arguments()[0]
+	return IsAtom(formal) && formal.(*Atom).Name() == "instantiation_error"
+}
+
+// alternatives builds a goal which unifies pattern with each of
+// solutions in turn, like (P = S1 ; P = S2 ; ... ; fail)
+func alternatives(pattern Term, solutions []Term) Callable {
+	var goal Callable = NewAtom("fail")
+	for i := len(solutions) - 1; i >= 0; i-- {
+		goal = NewCallable(";", NewCallable("=", pattern, solutions[i]), goal)
+	}
+	return goal
+}
+
+// atomSplits iterates over the ways of splitting an atom in two for
+// atom_concat/3
+type atomSplits struct {
+	left, right Term
+	runes       []rune
+	i           int // length of the next left part
+}
+
+func (x *atomSplits) Next(m Machine) (ForeignReturn, bool) {
+	if x.i > len(x.runes) {
+		return nil, false
+	}
+	left := NewAtom(string(x.runes[:x.i]))
+	right := NewAtom(string(x.runes[x.i:]))
+	x.i++
+	return ForeignUnify(x.left, left, x.right, right), true
+}
+
+func (x *atomSplits) Close() {}
+
+// subAtoms iterates over the parts of an atom for sub_atom/5.  Each
+// part starts at b and has length l.  Lengths which don't fit a known
+// Length, After or SubAtom are skipped without building anything.
+type subAtoms struct {
+	args   []Term // Before, Length, After and SubAtom
+	runes  []rune
+	sub    []rune // SubAtom, if it's known
+	length int    // -1 if unknown
+	after  int    // -1 if unknown
+	b      int    // start of the next part to try
+	l      int    // length of the next part to try
+	last   int    // last start worth trying
+}
+
+func (x *subAtoms) Next(m Machine) (ForeignReturn, bool) {
+	n := len(x.runes)
+	for ; x.b >= 0 && x.b <= x.last; x.b, x.l = x.b+1, 0 {
+		lo, hi := 0, n-x.b
+		if x.length >= 0 {
+			lo, hi = x.length, x.length
+		}
+		if x.after >= 0 {
+			l := n - x.b - x.after
+			if l > lo {
+				lo = l
+			}
+			if l < hi {
+				hi = l
+			}
+		}
+		if x.l < lo {
+			x.l = lo
+		}
+		for ; x.l <= hi && x.b+x.l <= n; x.l++ {
+			part := x.runes[x.b : x.b+x.l]
+			if x.sub != nil && string(part) != string(x.sub) {
+				continue
+			}
+			b, l := x.b, x.l
+			x.l++
+			return ForeignUnify(
+				x.args[0], NewInt64(int64(b)),
+				x.args[1], NewInt64(int64(l)),
+				x.args[2], NewInt64(int64(n-b-l)),
+				x.args[3], NewAtom(string(part)),
+			), true
+		}
+	}
+	return nil, false
+}
+
+func (x *subAtoms) Close() {}
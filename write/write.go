@@ -0,0 +1,444 @@
+// Write Prolog terms.  Typical usage is like
+//
+//	write.Term(os.Stdout, t, &write.Options{Quoted: true})
+//
+// Operators are written in operator notation according to an operator
+// table.  With the Quoted option, the output can be read back by
+// package read to produce the same term.
+package write
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/mndrix/golog/lex"
+	"github.com/mndrix/golog/read"
+	"github.com/mndrix/golog/term"
+)
+
+// Options control how a term is written.  They correspond to the
+// write options of write_term/2 in ISO §7.10.4.  The zero value writes
+// like write_canonical/1 without quoting.
+type Options struct {
+	// Quoted quotes atoms where necessary so that the term can be read
+	// back
+	Quoted bool
+
+	// IgnoreOps writes operators in functional notation, like +(1,2)
+	IgnoreOps bool
+
+	// NumberVars writes '$VAR'(N) terms as variable names: A, B, ...
+	// Z, A1, ...
+	NumberVars bool
+
+	// MaxDepth limits how deeply nested terms are written.  Deeper
+	// terms are written as ... and long lists are cut short.  Zero
+	// means there's no limit.
+	MaxDepth int
+
+	// VariableNames gives the names used when writing specific
+	// variables.  Other variables are written as _G123.
+	VariableNames map[*term.Variable]string
+
+	// Operators is the operator table to use.  If it's nil,
+	// read.DefaultOperators() is used.
+	Operators *read.Operators
+}
+
+// Term writes t to w.  If opts is nil, zero options are used.
+func Term(w io.Writer, t term.Term, opts *Options) error {
+	_, err := io.WriteString(w, String(t, opts))
+	return err
+}
+
+// String returns the text that Term would write
+func String(t term.Term, opts *Options) string {
+	if opts == nil {
+		opts = &Options{}
+	}
+	w := &writer{opts: opts, ops: opts.Operators}
+	if w.ops == nil {
+		w.ops = read.DefaultOperators()
+	}
+	if len(opts.VariableNames) > 0 {
+		w.names = make(map[string]string)
+		for v, name := range opts.VariableNames {
+			w.names[variableKey(v)] = name
+		}
+	}
+	w.term(t, 1200, 1)
+	return w.buf.String()
+}
+
+// writer holds the state for writing a single term
+type writer struct {
+	opts  *Options
+	ops   *read.Operators
+	names map[string]string // variable key => name
+
+	buf         strings.Builder
+	last        rune // last character written (0 at the start)
+	afterPrefix bool // was the last token a prefix operator?
+	afterSign   bool // was the last token a prefix - or +?
+}
+
+// term writes t in a context which allows terms up to priority
+// maxPriority.  depth is the nesting depth of t, starting at 1.
+func (w *writer) term(t term.Term, maxPriority, depth int) {
+	if w.opts.MaxDepth > 0 && depth > w.opts.MaxDepth {
+		w.token("...")
+		return
+	}
+
+	switch x := t.(type) {
+	case *term.Variable:
+		w.token(w.variableName(x))
+	case *term.Atom:
+		w.atom(x.Name(), w.priority(x.Name()) > maxPriority)
+	case *term.Compound:
+		w.compound(x, maxPriority, depth)
+	default:
+		w.token(t.String()) // numbers, etc.
+	}
+}
+
+// compound writes a compound term using list, curly bracket, operator
+// or functional notation
+func (w *writer) compound(x *term.Compound, maxPriority, depth int) {
+	name, args := x.Name(), x.Arguments()
+
+	switch {
+	case name == "." && len(args) == 2:
+		w.list(x, depth)
+		return
+	case name == "{}" && len(args) == 1:
+		w.token("{")
+		w.term(args[0], 1200, depth+1)
+		w.token("}")
+		return
+	case name == "$VAR" && len(args) == 1 && w.opts.NumberVars:
+		if v, ok := numberVar(args[0]); ok {
+			w.token(v)
+			return
+		}
+	}
+
+	if !w.opts.IgnoreOps && w.operator(x, maxPriority, depth) {
+		return
+	}
+
+	// functional notation
+	w.atom(name, false)
+	w.token("(")
+	for i, arg := range args {
+		if i > 0 {
+			w.token(",")
+		}
+		w.term(arg, 999, depth+1)
+	}
+	w.token(")")
+}
+
+// operator writes x in operator notation, if possible.  Returns false
+// if x isn't an operator term.
+func (w *writer) operator(x *term.Compound, maxPriority, depth int) bool {
+	name, args := x.Name(), x.Arguments()
+
+	switch len(args) {
+	case 2:
+		p, spec := w.ops.Infix(name)
+		if p == 0 {
+			return false
+		}
+		left, right := p-1, p-1
+		switch spec {
+		case "yfx":
+			left = p
+		case "xfy":
+			right = p
+		}
+
+		open := w.open(p > maxPriority, p)
+		w.operand(args[0], left, depth+1)
+		if name == "," || name == "|" { // solo characters, never quoted
+			w.token(name)
+		} else {
+			w.atom(name, false)
+		}
+		w.operand(args[1], right, depth+1)
+		w.close(open)
+		return true
+	case 1:
+		if p, spec := w.ops.Prefix(name); p > 0 {
+			if (name == "-" || name == "+") && term.IsNumber(args[0]) {
+				return false // -(1) is not the same as -1
+			}
+			arg := p - 1
+			if spec == "fy" {
+				arg = p
+			}
+
+			open := w.open(p > maxPriority, p)
+			w.atom(name, false)
+			w.afterPrefix = true
+			w.afterSign = name == "-" || name == "+"
+			w.operand(args[0], arg, depth+1)
+			w.close(open)
+			return true
+		}
+		if p, spec := w.ops.Postfix(name); p > 0 {
+			arg := p - 1
+			if spec == "yf" {
+				arg = p
+			}
+
+			open := w.open(p > maxPriority, p)
+			w.operand(args[0], arg, depth+1)
+			w.atom(name, false)
+			w.close(open)
+			return true
+		}
+	}
+	return false
+}
+
+// operand writes an operand of an operator.  Atoms which are operators
+// are bracketed so they're not mistaken for an operator application.
+func (w *writer) operand(t term.Term, maxPriority, depth int) {
+	if term.IsAtom(t) {
+		name := t.(*term.Atom).Name()
+		w.atom(name, w.priority(name) > 0)
+		return
+	}
+	w.term(t, maxPriority, depth)
+}
+
+// priority returns the highest priority of any operator called name.
+// It's 0 if there's no such operator.
+func (w *writer) priority(name string) int {
+	p, _ := w.ops.Prefix(name)
+	if i, _ := w.ops.Infix(name); i > p {
+		p = i
+	}
+	if s, _ := w.ops.Postfix(name); s > p {
+		p = s
+	}
+	return p
+}
+
+// list writes a list in list notation
+func (w *writer) list(x *term.Compound, depth int) {
+	w.token("[")
+	var t term.Term = x
+	for i := 0; ; i++ {
+		args := t.(*term.Compound).Arguments()
+		if w.opts.MaxDepth > 0 && i+1 >= w.opts.MaxDepth {
+			w.token("|")
+			w.token("...")
+			break
+		}
+		if i > 0 {
+			w.token(",")
+		}
+		w.term(args[0], 999, depth+1)
+
+		t = args[1]
+		if term.IsEmptyList(t) {
+			break
+		}
+		if t.Indicator() != "./2" {
+			w.token("|")
+			w.term(t, 999, depth+1)
+			break
+		}
+	}
+	w.token("]")
+}
+
+// atom writes the name of an atom, in parentheses if bracket is true
+func (w *writer) atom(name string, bracket bool) {
+	open := w.open(bracket, 0)
+	if w.opts.Quoted {
+		w.token(Quote(name))
+	} else {
+		w.token(name)
+	}
+	w.close(open)
+}
+
+// open writes an opening parenthesis if bracket is true.  p is the
+// priority of the bracketed term.  It returns bracket for passing to
+// close.
+func (w *writer) open(bracket bool, p int) bool {
+	if bracket {
+		// "-(" and "foo(" start functional notation, which only allows
+		// arguments up to priority 999
+		if (w.afterPrefix && p > 999) || isAlphanumeric(w.last) {
+			w.write(" ")
+		}
+		w.token("(")
+	}
+	return bracket
+}
+
+// close writes a closing parenthesis if bracket is true
+func (w *writer) close(bracket bool) {
+	if bracket {
+		w.token(")")
+	}
+}
+
+// token writes text, separating it from the previous token if the
+// two would otherwise run together
+func (w *writer) token(text string) {
+	if text == "" {
+		return
+	}
+	first := []rune(text)[0]
+	if w.last != 0 && glues(w.last, first) {
+		w.write(" ")
+	}
+	if w.afterSign && unicode.IsDigit(first) {
+		w.write(" ") // "-1^2" would read as (-1)^2
+	}
+	w.write(text)
+}
+
+func (w *writer) write(text string) {
+	w.buf.WriteString(text)
+	runes := []rune(text)
+	w.last = runes[len(runes)-1]
+	w.afterPrefix = false
+	w.afterSign = false
+}
+
+// glues returns true if a token ending in a followed by a token
+// starting with b would be read as a single token
+func glues(a, b rune) bool {
+	switch {
+	case isAlphanumeric(a) && isAlphanumeric(b):
+		return true
+	case lex.IsGraphic(a) && lex.IsGraphic(b):
+		return true
+	case a == '\'' && b == '\'':
+		return true
+	case unicode.IsDigit(a) && b == '\'': // like 0'c
+		return true
+	}
+	return false
+}
+
+func isAlphanumeric(c rune) bool {
+	return c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+// variableName returns the name to write for variable v
+func (w *writer) variableName(v *term.Variable) string {
+	if name, ok := w.names[variableKey(v)]; ok {
+		return name
+	}
+	if v.Id() == 0 {
+		return v.Name
+	}
+	return fmt.Sprintf("_G%d", v.Id())
+}
+
+// variableKey identifies a variable.  The same name might have many ids.
+func variableKey(v *term.Variable) string {
+	return v.Name + v.Indicator()
+}
+
+// numberVar returns the variable name for the argument of '$VAR'/1
+// as described for the numbervars write option.  Returns false if
+// the argument isn't suitable.
+func numberVar(t term.Term) (string, bool) {
+	if term.IsAtom(t) {
+		return t.(*term.Atom).Name(), true // SWI-Prolog extension
+	}
+	if !term.IsInteger(t) {
+		return "", false
+	}
+	n := t.(*term.Integer).Value()
+	if n.Sign() < 0 || !n.IsInt64() {
+		return "", false
+	}
+	i := n.Int64()
+	name := string(rune('A' + i%26))
+	if i >= 26 {
+		name += fmt.Sprintf("%d", i/26)
+	}
+	return name, true
+}
+
+// Quote returns the name of an atom in the form that produces the same
+// atom when read.  Quotes are only added if they're necessary.
+func Quote(name string) string {
+	if !needsQuotes(name) {
+		return name
+	}
+
+	var b strings.Builder
+	b.WriteRune('\'')
+	for _, c := range name {
+		switch c {
+		case '\'', '\\':
+			b.WriteRune('\\')
+			b.WriteRune(c)
+		case '\a':
+			b.WriteString(`\a`)
+		case '\b':
+			b.WriteString(`\b`)
+		case '\f':
+			b.WriteString(`\f`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\v':
+			b.WriteString(`\v`)
+		default:
+			b.WriteRune(c)
+		}
+	}
+	b.WriteRune('\'')
+	return b.String()
+}
+
+// needsQuotes returns true if an atom called name must be quoted to be
+// read back.  See ISO §6.4.2
+func needsQuotes(name string) bool {
+	switch name {
+	case "":
+		return true
+	case "[]", "{}", "!", ";":
+		return false
+	}
+
+	runes := []rune(name)
+	if unicode.IsLower(runes[0]) {
+		for _, c := range runes {
+			if !isAlphanumeric(c) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if lex.IsGraphic(runes[0]) {
+		if name == "." || strings.HasPrefix(name, "/*") {
+			return true
+		}
+		for _, c := range runes {
+			if !lex.IsGraphic(c) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,140 @@
+package write
+
+import (
+	"testing"
+
+	"github.com/mndrix/golog/read"
+	"github.com/mndrix/golog/term"
+)
+
+func TestWriteq(t *testing.T) {
+	tests := map[string]string{
+		`hello.`:                  `hello`,
+		`'Hello'.`:                `'Hello'`,
+		`'hello world'.`:          `'hello world'`,
+		`'don\'t'.`:               `'don\'t'`,
+		`'a\\b'.`:                 `'a\\b'`,
+		`''.`:                     `''`,
+		`[].`:                     `[]`,
+		`'{}'.`:                   `{}`,
+		`'.'.`:                    `'.'`,
+		`'/*'.`:                   `'/*'`,
+		`'λx'.`:                   `λx`,
+		`f(a, 'B', "c").`:         `f(a,'B',[99])`,
+		`1 + 2.`:                  `1+2`,
+		`1 + 2 * 3.`:              `1+2*3`,
+		`(1 + 2) * 3.`:            `(1+2)*3`,
+		`1 - (2 - 3).`:            `1-(2-3)`,
+		`(1 - 2) - 3.`:            `1-2-3`,
+		`a ^ b ^ c.`:              `a^b^c`,
+		`(a ^ b) ^ c.`:            `(a^b)^c`,
+		`1 - -1.`:                 `1- -1`,
+		`- 1.`:                    `-(1)`,
+		`-(-(1)).`:                `- -(1)`,
+		`- a.`:                    `-a`,
+		`- - a.`:                  `- -a`,
+		`-(a + b).`:               `-(a+b)`,
+		`- (a :- b).`:             `- (a:-b)`,
+		`\+ a.`:                   `\+a`,
+		`\+ (a, b).`:              `\+ (a,b)`,
+		`x is 1 + 2.`:             `x is 1+2`,
+		`1 mod 2.`:                `1 mod 2`,
+		`a :- b, c ; d -> e.`:     `a:-b,c;d->e`,
+		`(a :- b) :- c.`:          `(a:-b):-c`,
+		`f((a, b)).`:              `f((a,b))`,
+		`f((a :- b)).`:            `f((a:-b))`,
+		`f(-).`:                   `f(-)`,
+		`f(:-).`:                  `f((:-))`,
+		`(-) = a.`:                `(-)=a`,
+		`[a, b | c].`:             `[a,b|c]`,
+		`[(a, b)].`:               `[(a,b)]`,
+		`{a, b}.`:                 `{a,b}`,
+		`:- dynamic foo/1.`:       `:-dynamic foo/1`,
+		`'$VAR'(1) + '$VAR'(27).`: `B+B1`,
+		`'$VAR'('Foo').`:          `Foo`,
+		`- (1.5).`:                `-(1.5)`,
+		`-(1 ^ 2).`:               `- 1^2`,
+		`-(1.5 ** a).`:            `- 1.5**a`,
+		`+(2 ^ a).`:               `+ 2^a`,
+		`-(a ^ 2).`:               `-a^2`,
+		`(-1) ^ 2.`:               `-1^2`,
+		`1.0e10.`:                 `1.0e+10`,
+	}
+	for test, want := range tests {
+		x, err := read.Term(test)
+		if err != nil {
+			t.Errorf("Can't read %s: %s", test, err)
+			continue
+		}
+		got := String(x, &Options{Quoted: true, NumberVars: true})
+		if got != want {
+			t.Errorf("Writing `%s` gave `%s` instead of `%s`", test, got, want)
+		}
+	}
+}
+
+func TestOptions(t *testing.T) {
+	x := read.Term_(`f('A', 1 + 2, [a, b, c, d], g(h(i(j)))).`)
+	tests := []struct {
+		opts *Options
+		want string
+	}{
+		{nil, `f(A,1+2,[a,b,c,d],g(h(i(j))))`},
+		{&Options{IgnoreOps: true, Quoted: true}, `f('A',+(1,2),[a,b,c,d],g(h(i(j))))`},
+		{&Options{MaxDepth: 3}, `f(A,1+2,[a,b|...],g(h(...)))`},
+	}
+	for _, test := range tests {
+		if got := String(x, test.opts); got != test.want {
+			t.Errorf("Writing with %+v gave `%s` instead of `%s`", test.opts, got, test.want)
+		}
+	}
+
+	// variable names
+	v := term.NewVar("_").WithNewId()
+	x = term.NewCallable("f", v, term.NewVar("_"))
+	opts := &Options{VariableNames: map[*term.Variable]string{v: "Foo"}}
+	if got := String(x, opts); got[:6] != "f(Foo," || got[6:8] != "_G" {
+		t.Errorf("Wrong variable names: %s", got)
+	}
+
+	// user defined operators
+	ops, err := read.DefaultOperators().Define(700, "xfx", "===>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x = term.NewCallable("===>", term.NewAtom("a"), term.NewAtom("b"))
+	if got := String(x, &Options{Operators: ops}); got != "a===>b" {
+		t.Errorf("Wrong user defined operator: %s", got)
+	}
+}
+
+// terms written with quoted(true) should read back as the same term
+func TestRoundTrip(t *testing.T) {
+	tests := []string{
+		`a - (-1) - -(1) - - 1 - (- a).`,
+		`f(';', '|', '[]', [], {}, '{}'(x), 'hello world', "codes").`,
+		`(a :- b, c ; \+ d -> e ; f).`,
+		`[- , +, (:-), 'x y' | T].`,
+		`- (-) = (\+) + a.`,
+		`1 - 2 - 3 + (4 - 5) ** (6 ^ 7 ^ 8).`,
+		`f(a = b, (c, d), (e :- f), [(g :- h)]).`,
+		`'a\nb\\c\'d'.`,
+		`- (1) + - (1.5) + -(-(2)).`,
+		`X = f(X, Y, _).`,
+		`-(1 ^ 2) + -(1.5 ** 2) + +(3 ^ 4).`,
+		`(-1) ^ 2 + - (1) ^ 2 + -(-(1) ^ 2).`,
+		`- (2 ^ 3 ^ 4) - (- (1)) ** 2.`,
+	}
+	for _, test := range tests {
+		x := read.Term_(test)
+		text := String(x, &Options{Quoted: true})
+		y, err := read.Term(text + " .")
+		if err != nil {
+			t.Errorf("Can't read back `%s` written as `%s`: %s", test, text, err)
+			continue
+		}
+		if !term.Variant(x, y) {
+			t.Errorf("Writing `%s` gave `%s` which reads as `%s`", test, text, y)
+		}
+	}
+}